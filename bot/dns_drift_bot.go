@@ -0,0 +1,46 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"domain0/config"
+)
+
+const (
+	notifyDnsDriftFmt = "域名: %s\n检测时间: %s\n以下记录在 Domain0 之外被修改:\n%s"
+	driftPostTitle    = "域名记录漂移通知"
+)
+
+type DnsDriftRecord struct {
+	Domain     string
+	DetectTime time.Time
+	Changes    []string // one line for each changed record
+}
+
+func NotifyDnsDrift(record DnsDriftRecord) {
+	if config.CONFIG.Feishu.BotUrl == "" {
+		logrus.Warnf("dns drift of domain %s is not notified, bot url is empty", record.Domain)
+		return
+	}
+
+	notifyTxt := fmt.Sprintf(notifyDnsDriftFmt, record.Domain,
+		record.DetectTime.Format("2006-01-02T15:04:05 -070000"), strings.Join(record.Changes, "\n"))
+	request, err := constructHttpRequest(driftPostTitle, []string{notifyTxt})
+	if err != nil {
+		logrus.Errorf("construct Feishu bot request error:%v", err)
+		return
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		logrus.Errorf("notify Feishu bot error:%v", err)
+		return
+	}
+	defer response.Body.Close()
+	if response.StatusCode/100 != 2 {
+		logrus.Errorf("Feishu response error:%v", response)
+	}
+}
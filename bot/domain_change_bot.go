@@ -0,0 +1,50 @@
+package bot
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"domain0/config"
+)
+
+const (
+	notifyChangeOutcomeFmt = "申请人: %s\n域名: %s\n申请内容: %s\n结果: %s\n处理人: %s\n备注: %s\n时间: %s"
+	changePostTitle        = "域名变更申请结果通知"
+)
+
+type ChangeOutcomeRecord struct {
+	Requester string
+	Domain    string
+	Reason    string // what is requested
+	Outcome   string // Approved, Rejected, Cancelled, Expired or Failed
+	Operator  string // reviewer or requester, empty if expired
+	Comment   string
+	Time      time.Time
+}
+
+// NotifyChangeOutcome tells the requester how his domain change is closed
+func NotifyChangeOutcome(record ChangeOutcomeRecord) {
+	if config.CONFIG.Feishu.BotUrl == "" {
+		logrus.Warnf("outcome of domain change of %s is not notified, bot url is empty", record.Requester)
+		return
+	}
+
+	notifyTxt := fmt.Sprintf(notifyChangeOutcomeFmt, record.Requester, record.Domain, record.Reason,
+		record.Outcome, record.Operator, record.Comment, record.Time.Format("2006-01-02T15:04:05 -070000"))
+	request, err := constructHttpRequest(changePostTitle, []string{notifyTxt})
+	if err != nil {
+		logrus.Errorf("construct Feishu bot request error:%v", err)
+		return
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		logrus.Errorf("notify Feishu bot error:%v", err)
+		return
+	}
+	defer response.Body.Close()
+	if response.StatusCode/100 != 2 {
+		logrus.Errorf("Feishu response error:%v", response)
+	}
+}
@@ -41,13 +41,44 @@ type OIDCInfoPath struct {
 	Email string `yaml:"email"`
 	Error string `yaml:"error"`
 }
+type DriftConfig struct {
+	Interval int `yaml:"interval"` // seconds between two polls of vendor records, 0 to disable
+}
+type ChangeConfig struct {
+	Expiry int `yaml:"expiry"` // seconds a domain change waits for review before expired, 0 to never expire
+}
+type LocalDNSConfig struct {
+	Listen        string   `yaml:"listen"`         // udp and tcp address of the built-in authoritative server, empty to disable
+	Nameserver    string   `yaml:"nameserver"`     // SOA MNAME and default NS of local zones, ns.<zone> if empty
+	Hostmaster    string   `yaml:"hostmaster"`     // SOA RNAME of local zones, hostmaster.<zone> if empty
+	AllowTransfer []string `yaml:"allow_transfer"` // ip or cidr of secondaries allowed to AXFR / IXFR
+	Notify        []string `yaml:"notify"`         // host:port of secondaries to NOTIFY on change
+}
+type AcmeConfig struct {
+	ChallengeTTL int `yaml:"challenge_ttl"` // seconds before a challenge TXT record not cleaned up by client is deleted
+}
+type JwtConfig struct {
+	Algorithm      string `yaml:"algorithm"`       // RS256 or EdDSA
+	RotateInterval int    `yaml:"rotate_interval"` // seconds a signing key signs before a new one is generated
+	Expire         int    `yaml:"expire"`          // seconds an access token is valid, refreshed by refresh token
+	RefreshExpire  int    `yaml:"refresh_expire"`  // seconds a refresh token is valid, rotated on every refresh
+}
+type ProxyConfig struct {
+	Header         string   `yaml:"header"`          // header of the client ip set by the reverse proxy, e.g. X-Forwarded-For, empty if there is no proxy
+	TrustedProxies []string `yaml:"trusted_proxies"` // ip or cidr of proxies whose header is trusted
+}
 type Config struct {
 	BindAddr string         `yaml:"bind_addr"`
 	Database DatabaseConfig `yaml:"database"`
 	LogLevel int            `yaml:"log_level"` // 0: debug, 1: info, 2: warn, 3: error
-	JwtKey   string         `yaml:"jwt_key"`
+	Jwt      JwtConfig      `yaml:"jwt"`
 	Feishu   FeishuConfig   `yaml:"feishu"`
 	OIDC     OIDCConfig     `yaml:"oidc"`
+	Drift    DriftConfig    `yaml:"drift"`
+	Change   ChangeConfig   `yaml:"change"`
+	LocalDNS LocalDNSConfig `yaml:"local_dns"`
+	Acme     AcmeConfig     `yaml:"acme"`
+	Proxy    ProxyConfig    `yaml:"proxy"`
 }
 
 var CONFIG = Config{
@@ -61,12 +92,30 @@ var CONFIG = Config{
 		DbName:   "",
 	},
 	LogLevel: 1,
-	JwtKey:   "secretissecretbutsecretisnotsecure",
+	Jwt: JwtConfig{
+		Algorithm:      "RS256",
+		RotateInterval: 30 * 24 * 3600,
+		Expire:         15 * 60,
+		RefreshExpire:  30 * 24 * 3600,
+	},
 	Feishu: FeishuConfig{
 		AppID:       "",
 		AppSecret:   "",
 		RedirectURL: "",
 	},
+	Drift: DriftConfig{
+		Interval: 600,
+	},
+	Change: ChangeConfig{
+		Expiry: 7 * 24 * 3600,
+	},
+	Acme: AcmeConfig{
+		ChallengeTTL: 3600,
+	},
+	Proxy: ProxyConfig{
+		Header:         "X-Forwarded-For",
+		TrustedProxies: []string{"127.0.0.1", "::1"},
+	},
 }
 
 func Read(filename string) error {
@@ -18,6 +18,19 @@ func migrate(db *gorm.DB) error {
 	flag = db.AutoMigrate(m.UserDomain{}) != nil || flag
 	flag = db.AutoMigrate(m.User{}) != nil || flag
 	flag = db.AutoMigrate(m.SSOState{}) != nil || flag
+	flag = db.AutoMigrate(m.DnsSnapshot{}) != nil || flag
+	flag = db.AutoMigrate(m.AuditEvent{}) != nil || flag
+	flag = db.AutoMigrate(m.ApprovalPolicy{}) != nil || flag
+	flag = db.AutoMigrate(m.DomainChangeVote{}) != nil || flag
+	flag = db.AutoMigrate(m.LocalRecord{}) != nil || flag
+	flag = db.AutoMigrate(m.LocalZone{}) != nil || flag
+	flag = db.AutoMigrate(m.LocalZoneChange{}) != nil || flag
+	flag = db.AutoMigrate(m.DdnsToken{}) != nil || flag
+	flag = db.AutoMigrate(m.AcmeCredential{}) != nil || flag
+	flag = db.AutoMigrate(m.AcmeChallenge{}) != nil || flag
+	flag = db.AutoMigrate(m.AccessToken{}) != nil || flag
+	flag = db.AutoMigrate(m.RefreshToken{}) != nil || flag
+	flag = db.AutoMigrate(m.SigningKey{}) != nil || flag
 	if flag {
 		logrus.Errorf("migrate error")
 		return gorm.ErrInvalidDB
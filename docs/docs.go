@@ -23,78 +23,47 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/api/v1/domain": {
+        "/.well-known/jwks.json": {
             "get": {
-                "description": "List domains\nuser can list all domains if user role level is admin\nuser can list domains which user has read access if user role level is not admin",
+                "description": "Public keys to verify jwt issued by domain0, including keys to be active and retired\nones whose tokens are not expired yet, tokens are signed by the key of kid in header",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "domain"
+                    "user"
                 ],
-                "summary": "List domains",
+                "summary": "JWKS",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/web.Domain"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "type": "array",
-                                            "items": {
-                                                "$ref": "#/definitions/models.Domain"
-                                            }
-                                        }
-                                    }
-                                }
-                            ]
-                        }
-                    },
-                    "500": {
-                        "description": "Internal Server Error",
-                        "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/web.Domain"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "type": "string"
-                                        }
-                                    }
-                                }
-                            ]
+                            "$ref": "#/definitions/web.Jwks"
                         }
                     }
                 }
-            },
+            }
+        },
+        "/api/v1/acme/cleanup": {
             "post": {
-                "description": "Create domain\nuser must have contributor role or higher",
+                "description": "Delete the challenge TXT record, compatible with lego httpreq /cleanup in default and raw mode\nusername and password of the acme credential are the basic auth",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
-                    "application/json"
+                    "text/plain"
                 ],
                 "tags": [
-                    "domain"
+                    "acme"
                 ],
-                "summary": "Create domain",
+                "summary": "ACME Cleanup",
                 "parameters": [
                     {
-                        "description": "domain info",
-                        "name": "domain",
+                        "description": "{\\",
+                        "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/web.DomainInfoUpdate"
+                            "type": "object"
                         }
                     }
                 ],
@@ -102,181 +71,224 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/web.Domain"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "$ref": "#/definitions/models.Domain"
-                                        }
-                                    }
-                                }
-                            ]
+                            "type": "string"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/web.Domain"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "$ref": "#/definitions/web.DomainInfoUpdate"
-                                        }
-                                    }
-                                }
-                            ]
+                            "type": "string"
                         }
                     },
-                    "403": {
-                        "description": "Forbidden",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/web.Domain"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "type": "string"
-                                        }
-                                    }
-                                }
-                            ]
+                            "type": "string"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/web.Domain"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "type": "string"
-                                        }
-                                    }
-                                }
-                            ]
+                            "type": "string"
                         }
                     }
                 }
             }
         },
-        "/api/v1/domain/change/myapply": {
-            "get": {
-                "produces": [
+        "/api/v1/acme/present": {
+            "post": {
+                "description": "Create the challenge TXT record, compatible with lego httpreq /present in default and raw mode\nusername and password of the acme credential are the basic auth",
+                "consumes": [
                     "application/json"
                 ],
+                "produces": [
+                    "text/plain"
+                ],
                 "tags": [
-                    "domain"
+                    "acme"
+                ],
+                "summary": "ACME Present",
+                "parameters": [
+                    {
+                        "description": "{\\",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
                 ],
-                "summary": "list all domain change requests generated by the user",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/web.Domain"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "type": "array",
-                                            "items": {
-                                                "$ref": "#/definitions/models.DomainChange"
-                                            }
-                                        }
-                                    }
-                                }
-                            ]
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "string"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/web.Domain"
+                            "type": "string"
                         }
                     }
                 }
             }
         },
-        "/api/v1/domain/change/myapprove": {
-            "get": {
+        "/api/v1/acme/update": {
+            "post": {
+                "description": "Set the challenge TXT record, compatible with acme-dns /update, the two latest values\nare kept for a name and its wildcard, older ones are deleted\nsubdomain is the username of the credential, the TXT record is _acme-challenge.\u003cname\u003e",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "domain"
+                    "acme"
+                ],
+                "summary": "ACME DNS Update",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "username of acme credential",
+                        "name": "X-Api-User",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "password of acme credential",
+                        "name": "X-Api-Key",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "{\\",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
                 ],
-                "summary": "list all domain change requests that the user can approve",
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "{\\\"txt\\\": \\\"\u003cvalue\u003e\\\"}",
                         "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/web.Domain"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "type": "array",
-                                            "items": {
-                                                "$ref": "#/definitions/models.DomainChange"
-                                            }
-                                        }
-                                    }
-                                }
-                            ]
+                            "type": "object"
+                        }
+                    },
+                    "400": {
+                        "description": "{\\\"error\\\": \\\"bad_txt\\\"}",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "{\\\"error\\\": \\\"forbidden\\\"}",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "409": {
+                        "description": "{\\\"error\\\": \\\"\u003cconflict with the zone\u003e\\\"}",
+                        "schema": {
+                            "type": "object"
                         }
                     },
                     "500": {
-                        "description": "Internal Server Error",
+                        "description": "{\\\"error\\\": \\\"\u003cmessage\u003e\\\"}",
                         "schema": {
-                            "$ref": "#/definitions/web.Domain"
+                            "type": "object"
                         }
                     }
                 }
             }
         },
-        "/api/v1/domain/change/{id}": {
-            "put": {
+        "/api/v1/audit": {
+            "get": {
+                "description": "List audit events of mutating requests, newest first\nadmin can list all events except those of privacy domains not granted to him,\nother users can list events of domains they own",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "domain"
+                    "audit"
                 ],
-                "summary": "modify domain change request",
+                "summary": "List audit events",
                 "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "domain id",
+                        "name": "domain_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "actor user id",
+                        "name": "user_id",
+                        "in": "query"
+                    },
                     {
                         "type": "string",
-                        "description": "domain change id",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "dns record id",
+                        "name": "record_id",
+                        "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "operation: accept or reject",
-                        "name": "opt",
-                        "in": "query",
-                        "required": true
+                        "description": "substring of action, e.g. /dns",
+                        "name": "action",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "true for succeeded, false for failed",
+                        "name": "result",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC 3339 time, inclusive",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC 3339 time, exclusive",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page, start from 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page size, default 50, max 500",
+                        "name": "page_size",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -291,13 +303,19 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/models.DomainChange"
+                                            "$ref": "#/definitions/web.AuditPage"
                                         }
                                     }
                                 }
                             ]
                         }
                     },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/web.Domain"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -307,46 +325,109 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/domain/{id}": {
+        "/api/v1/ddns/update": {
             "get": {
-                "description": "Get domain by id\nuser must have read permission to domain or be admin",
+                "description": "Update the address of the record of a ddns token, compatible with dyndns2 protocol,\nthe token is the password of basic auth, username is ignored, or given by token param\nhostname is the fqdn of the record, optional, myip is the new address, the address of\ncaller is used if it is absent or has no address of the record type\nthe record is only updated if the address is changed\nresponse is plain text of dyndns2: \"good \u003cip\u003e\", \"nochg \u003cip\u003e\", \"badauth\", \"nohost\" or \"dnserr\"",
                 "produces": [
-                    "application/json"
+                    "text/plain"
                 ],
                 "tags": [
-                    "domain"
+                    "ddns"
                 ],
-                "summary": "Get domain by id",
+                "summary": "DDNS Update",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "domain id",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
+                        "description": "fqdn of the record, comma separated for several",
+                        "name": "hostname",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "new address, comma separated ipv4 and ipv6",
+                        "name": "myip",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ddns token, if not by basic auth",
+                        "name": "token",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/web.Domain"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "$ref": "#/definitions/models.Domain"
-                                        }
-                                    }
-                                }
-                            ]
+                            "type": "string"
                         }
                     },
-                    "403": {
-                        "description": "Forbidden",
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Update the address of the record of a ddns token, compatible with dyndns2 protocol,\nthe token is the password of basic auth, username is ignored, or given by token param\nhostname is the fqdn of the record, optional, myip is the new address, the address of\ncaller is used if it is absent or has no address of the record type\nthe record is only updated if the address is changed\nresponse is plain text of dyndns2: \"good \u003cip\u003e\", \"nochg \u003cip\u003e\", \"badauth\", \"nohost\" or \"dnserr\"",
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "ddns"
+                ],
+                "summary": "DDNS Update",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "fqdn of the record, comma separated for several",
+                        "name": "hostname",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "new address, comma separated ipv4 and ipv6",
+                        "name": "myip",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ddns token, if not by basic auth",
+                        "name": "token",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/domain": {
+            "get": {
+                "description": "List domains\nuser can list all domains if user role level is admin\nuser can list domains which user has read access if user role level is not admin",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "domain"
+                ],
+                "summary": "List domains",
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
                             "allOf": [
                                 {
@@ -356,15 +437,18 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "type": "integer"
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.Domain"
+                                            }
                                         }
                                     }
                                 }
                             ]
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "allOf": [
                                 {
@@ -374,7 +458,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "type": "integer"
+                                            "type": "string"
                                         }
                                     }
                                 }
@@ -383,8 +467,8 @@ const docTemplate = `{
                     }
                 }
             },
-            "put": {
-                "description": "Update domain\nuser must have manager role to domain or be admin\n**ICP_reg param can't be updated**",
+            "post": {
+                "description": "Create domain\nuser must have contributor role or higher\nvendor must be one of the providers listed by /api/v1/providers",
                 "consumes": [
                     "application/json"
                 ],
@@ -394,15 +478,8 @@ const docTemplate = `{
                 "tags": [
                     "domain"
                 ],
-                "summary": "Update domain",
+                "summary": "Create domain",
                 "parameters": [
-                    {
-                        "type": "string",
-                        "description": "domain id",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
                     {
                         "description": "domain info",
                         "name": "domain",
@@ -487,28 +564,17 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "Delete domain\nuser must have owner role to domain or be admin",
-                "consumes": [
-                    "application/json"
-                ],
+            }
+        },
+        "/api/v1/domain/change/myapply": {
+            "get": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "domain"
                 ],
-                "summary": "Delete domain",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "domain id",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
+                "summary": "list all domain change requests generated by the user",
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -521,33 +587,37 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "type": "string"
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.DomainChange"
+                                            }
                                         }
                                     }
                                 }
                             ]
                         }
                     },
-                    "403": {
-                        "description": "Forbidden",
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/web.Domain"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "type": "string"
-                                        }
-                                    }
-                                }
-                            ]
+                            "$ref": "#/definitions/web.Domain"
                         }
-                    },
-                    "404": {
-                        "description": "Not Found",
+                    }
+                }
+            }
+        },
+        "/api/v1/domain/change/myapprove": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "domain"
+                ],
+                "summary": "list all domain change requests that the user can approve",
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
                             "allOf": [
                                 {
@@ -557,7 +627,10 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "type": "string"
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.DomainChange"
+                                            }
                                         }
                                     }
                                 }
@@ -567,44 +640,42 @@ const docTemplate = `{
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/web.Domain"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "type": "string"
-                                        }
-                                    }
-                                }
-                            ]
+                            "$ref": "#/definitions/web.Domain"
                         }
                     }
                 }
             }
         },
-        "/api/v1/domain/{id}/dns": {
-            "get": {
-                "description": "List Domain Dns **AliDNS as Example, read modules for others**\nuser must have read permission to domain or be admin",
-                "consumes": [
-                    "application/json"
-                ],
+        "/api/v1/domain/change/{id}": {
+            "put": {
+                "description": "vote for the domain change, the change is applied once the approval policy of domain is met,\nand rejected by any reject vote, requester can't vote for his own change\nthe approval is refused with the diff if records are changed since the change is submitted",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "domain"
                 ],
-                "summary": "List Domain Dns",
+                "summary": "modify domain change request",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "domain id",
+                        "description": "domain change id",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "operation: accept or reject",
+                        "name": "opt",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "comment of the vote, shown to the requester if rejected",
+                        "name": "comment",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -619,10 +690,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "type": "array",
-                                            "items": {
-                                                "$ref": "#/definitions/dns.AliDNSList"
-                                            }
+                                            "$ref": "#/definitions/models.DomainChange"
                                         }
                                     }
                                 }
@@ -632,41 +700,23 @@ const docTemplate = `{
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/web.Domain"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "type": "integer"
-                                        }
-                                    }
-                                }
-                            ]
+                            "$ref": "#/definitions/web.Domain"
                         }
                     },
                     "403": {
                         "description": "Forbidden",
                         "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/web.Domain"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "type": "integer"
-                                        }
-                                    }
-                                }
-                            ]
+                            "$ref": "#/definitions/web.Domain"
                         }
                     },
                     "404": {
                         "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/web.Domain"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
                             "allOf": [
                                 {
@@ -676,43 +726,48 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "type": "integer"
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/modules.RecordDiff"
+                                            }
                                         }
                                     }
                                 }
                             ]
                         }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/web.Domain"
+                        }
                     }
                 }
-            },
+            }
+        },
+        "/api/v1/domain/change/{id}/cancel": {
             "post": {
-                "description": "Create Domain Dns **AliDNS as Example, read modules for others**\nuser must have readwrite permission to domain or be admin\nfor now only owner can edit domain which ICP_reg is true",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "withdraw the domain change by its requester, it must be still reviewing",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "domain"
                 ],
-                "summary": "Create Domain Dns",
+                "summary": "cancel domain change request",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "domain id",
+                        "description": "domain change id",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "dns info",
-                        "name": "dns",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/dns.AliDNS"
-                        }
+                        "type": "string",
+                        "description": "why the change is cancelled",
+                        "name": "comment",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -727,15 +782,62 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/dns.CloudflareDNS"
+                                            "$ref": "#/definitions/models.DomainChange"
                                         }
                                     }
                                 }
                             ]
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/web.Domain"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/web.Domain"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/web.Domain"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/web.Domain"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/domain/change/{id}/diff": {
+            "get": {
+                "description": "three-way diff of the records touched by the domain change: the state when submitted,\nthe live state and the proposed state, the change can't be approved if there is a conflict\nuser must be the requester or a reviewer of the change",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "domain"
+                ],
+                "summary": "diff of domain change request",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "domain change id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
                             "allOf": [
                                 {
@@ -745,7 +847,10 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "type": "integer"
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/modules.RecordDiff"
+                                            }
                                         }
                                     }
                                 }
@@ -754,6 +859,47 @@ const docTemplate = `{
                     },
                     "403": {
                         "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/web.Domain"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/web.Domain"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/web.Domain"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/domain/{id}": {
+            "get": {
+                "description": "Get domain by id\nuser must have read permission to domain or be admin",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "domain"
+                ],
+                "summary": "Get domain by id",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "domain id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
                             "allOf": [
                                 {
@@ -763,15 +909,15 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "type": "integer"
+                                            "$ref": "#/definitions/models.Domain"
                                         }
                                     }
                                 }
                             ]
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "allOf": [
                                 {
@@ -788,8 +934,8 @@ const docTemplate = `{
                             ]
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "allOf": [
                                 {
@@ -807,11 +953,9 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/api/v1/domain/{id}/dns/{dnsId}": {
+            },
             "put": {
-                "description": "Update Domain Dns **AliDNS as Example, read modules for others**\nuser must have readwrite permission to domain or be admin\nfor now only owner can edit domain which ICP_reg is true",
+                "description": "Update domain\nuser must have manager role to domain or be admin\n**ICP_reg param can't be updated**",
                 "consumes": [
                     "application/json"
                 ],
@@ -821,7 +965,7 @@ const docTemplate = `{
                 "tags": [
                     "domain"
                 ],
-                "summary": "Update Domain Dns",
+                "summary": "Update domain",
                 "parameters": [
                     {
                         "type": "string",
@@ -831,19 +975,12 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "dns id",
-                        "name": "dnsId",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "dns info",
-                        "name": "dns",
+                        "description": "domain info",
+                        "name": "domain",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dns.AliDNS"
+                            "$ref": "#/definitions/web.DomainInfoUpdate"
                         }
                     }
                 ],
@@ -859,7 +996,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/dns.CloudflareDNS"
+                                            "$ref": "#/definitions/models.Domain"
                                         }
                                     }
                                 }
@@ -877,7 +1014,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "type": "integer"
+                                            "$ref": "#/definitions/web.DomainInfoUpdate"
                                         }
                                     }
                                 }
@@ -895,25 +1032,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "type": "integer"
-                                        }
-                                    }
-                                }
-                            ]
-                        }
-                    },
-                    "404": {
-                        "description": "Not Found",
-                        "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/web.Domain"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "type": "integer"
+                                            "type": "string"
                                         }
                                     }
                                 }
@@ -931,7 +1050,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "type": "integer"
+                                            "type": "string"
                                         }
                                     }
                                 }
@@ -941,7 +1060,7 @@ const docTemplate = `{
                 }
             },
             "delete": {
-                "description": "Delete Domain Dns\nuser must have readwrite permission to domain or be admin",
+                "description": "Delete domain\nuser must have owner role to domain or be admin",
                 "consumes": [
                     "application/json"
                 ],
@@ -951,7 +1070,7 @@ const docTemplate = `{
                 "tags": [
                     "domain"
                 ],
-                "summary": "Delete Domain Dns",
+                "summary": "Delete domain",
                 "parameters": [
                     {
                         "type": "string",
@@ -959,13 +1078,6 @@ const docTemplate = `{
                         "name": "id",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "dns id",
-                        "name": "dnsId",
-                        "in": "path",
-                        "required": true
                     }
                 ],
                 "responses": {
@@ -980,25 +1092,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "type": "integer"
-                                        }
-                                    }
-                                }
-                            ]
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/web.Domain"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "type": "integer"
+                                            "type": "string"
                                         }
                                     }
                                 }
@@ -1016,7 +1110,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "type": "integer"
+                                            "type": "string"
                                         }
                                     }
                                 }
@@ -1034,7 +1128,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "type": "integer"
+                                            "type": "string"
                                         }
                                     }
                                 }
@@ -1052,7 +1146,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "type": "integer"
+                                            "type": "string"
                                         }
                                     }
                                 }
@@ -1062,9 +1156,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/domain/{id}/user": {
-            "get": {
-                "description": "List UserDomain Relation\nuser must have manager permission to domain or be admin",
+        "/api/v1/domain/{id}/access/grant": {
+            "post": {
+                "description": "Request a role on domain for the user himself, with a justification\nuser must have a role lower than requested, or the domain is not privacy\nmanagers can approve roles below manager, owners can approve all",
                 "consumes": [
                     "application/json"
                 ],
@@ -1074,7 +1168,7 @@ const docTemplate = `{
                 "tags": [
                     "domain"
                 ],
-                "summary": "List UserDomain Relation",
+                "summary": "Request Domain Access",
                 "parameters": [
                     {
                         "type": "string",
@@ -1082,11 +1176,20 @@ const docTemplate = `{
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "role and reason",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/web.AccessRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "208": {
+                        "description": "Already Reported",
                         "schema": {
                             "allOf": [
                                 {
@@ -1096,10 +1199,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "type": "array",
-                                            "items": {
-                                                "$ref": "#/definitions/web.DomainUserDetail"
-                                            }
+                                            "type": "string"
                                         }
                                     }
                                 }
@@ -1124,8 +1224,8 @@ const docTemplate = `{
                             ]
                         }
                     },
-                    "403": {
-                        "description": "Forbidden",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "allOf": [
                                 {
@@ -1142,8 +1242,26 @@ const docTemplate = `{
                             ]
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "allOf": [
                                 {
@@ -1161,9 +1279,11 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
+            }
+        },
+        "/api/v1/domain/{id}/access/revoke": {
             "post": {
-                "description": "Create UserDomain Relation\nuser must have manager permission to domain or be admin\nuser cant create permission higher than himself",
+                "description": "Request to revoke the role of a user on domain, with a justification\nuser must have a role on domain\nmanagers can approve revoking roles below owner, owners can approve all",
                 "consumes": [
                     "application/json"
                 ],
@@ -1173,7 +1293,7 @@ const docTemplate = `{
                 "tags": [
                     "domain"
                 ],
-                "summary": "Create UserDomain Relation",
+                "summary": "Request Domain Access Revoke",
                 "parameters": [
                     {
                         "type": "string",
@@ -1183,18 +1303,18 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "userRole",
-                        "name": "userRole",
+                        "description": "user and reason",
+                        "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/web.DomainUser"
+                            "$ref": "#/definitions/web.AccessRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "208": {
+                        "description": "Already Reported",
                         "schema": {
                             "allOf": [
                                 {
@@ -1204,7 +1324,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/models.UserDomain"
+                                            "type": "string"
                                         }
                                     }
                                 }
@@ -1264,23 +1384,56 @@ const docTemplate = `{
                                 }
                             ]
                         }
-                    }
-                }
-            }
-        },
-        "/api/v1/domain/{id}/user/{uid}": {
-            "delete": {
-                "description": "Delete UserDomain Relation **(no update, just delete and create)**\nuser must have manager permission to domain or be admin\nuser cant delete permission higher than himself",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/domain/{id}/acme": {
+            "get": {
+                "description": "List credentials for ACME DNS-01 challenges of the domain, keys are not responded\nuser must have readwrite permission to domain or be admin",
+                "produces": [
+                    "application/json"
                 ],
                 "tags": [
                     "domain"
                 ],
-                "summary": "Delete UserDomain Relation",
+                "summary": "List ACME Credentials",
                 "parameters": [
                     {
                         "type": "string",
@@ -1288,13 +1441,6 @@ const docTemplate = `{
                         "name": "id",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "user id",
-                        "name": "uid",
-                        "in": "path",
-                        "required": true
                     }
                 ],
                 "responses": {
@@ -1309,15 +1455,18 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "type": "integer"
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.AcmeCredential"
+                                            }
                                         }
                                     }
                                 }
                             ]
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "allOf": [
                                 {
@@ -1334,8 +1483,8 @@ const docTemplate = `{
                             ]
                         }
                     },
-                    "403": {
-                        "description": "Forbidden",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "allOf": [
                                 {
@@ -1352,8 +1501,8 @@ const docTemplate = `{
                             ]
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "allOf": [
                                 {
@@ -1371,11 +1520,9 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/api/v1/user": {
-            "get": {
-                "description": "Get user list\nOnly admin can get user list.",
+            },
+            "post": {
+                "description": "Create a credential which can only create and delete TXT records of _acme-challenge.\u003cname\u003e,\nfor acme-dns clients it is the registered account, for lego httpreq username and password\nare the basic auth, the password is only responded here, keep it safe\nuser must have readwrite permission to domain or be admin,\nfor ICP domain user must be owner, as changes by the credential are not reviewed",
                 "consumes": [
                     "application/json"
                 ],
@@ -1383,25 +1530,40 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "domain"
+                ],
+                "summary": "Create ACME Credential",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "domain id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "name the certificate is for",
+                        "name": "credential",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/web.AcmeCredentialCreate"
+                        }
+                    }
                 ],
-                "summary": "Get user list",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "allOf": [
                                 {
-                                    "$ref": "#/definitions/web.User"
+                                    "$ref": "#/definitions/web.Domain"
                                 },
                                 {
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "type": "array",
-                                            "items": {
-                                                "$ref": "#/definitions/models.User"
-                                            }
+                                            "$ref": "#/definitions/web.AcmeCredential"
                                         }
                                     }
                                 }
@@ -1413,13 +1575,16 @@ const docTemplate = `{
                         "schema": {
                             "allOf": [
                                 {
-                                    "$ref": "#/definitions/web.User"
+                                    "$ref": "#/definitions/web.Domain"
                                 },
                                 {
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "type": "integer"
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/modules.FieldError"
+                                            }
                                         }
                                     }
                                 }
@@ -1431,7 +1596,7 @@ const docTemplate = `{
                         "schema": {
                             "allOf": [
                                 {
-                                    "$ref": "#/definitions/web.User"
+                                    "$ref": "#/definitions/web.Domain"
                                 },
                                 {
                                     "type": "object",
@@ -1449,7 +1614,7 @@ const docTemplate = `{
                         "schema": {
                             "allOf": [
                                 {
-                                    "$ref": "#/definitions/web.User"
+                                    "$ref": "#/definitions/web.Domain"
                                 },
                                 {
                                     "type": "object",
@@ -1467,7 +1632,7 @@ const docTemplate = `{
                         "schema": {
                             "allOf": [
                                 {
-                                    "$ref": "#/definitions/web.User"
+                                    "$ref": "#/definitions/web.Domain"
                                 },
                                 {
                                     "type": "object",
@@ -1483,26 +1648,29 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/user/callback": {
-            "get": {
-                "description": "oauth callback api\nuser can login with feishu for now",
+        "/api/v1/domain/{id}/acme/{cid}": {
+            "delete": {
+                "description": "Revoke an acme credential of the domain, its challenge records are deleted\nuser must have readwrite permission to domain or be admin",
                 "produces": [
                     "application/json"
                 ],
-                "summary": "oauth callback",
+                "tags": [
+                    "domain"
+                ],
+                "summary": "Delete ACME Credential",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "oauth code",
-                        "name": "code",
-                        "in": "query",
+                        "description": "domain id",
+                        "name": "id",
+                        "in": "path",
                         "required": true
                     },
                     {
                         "type": "string",
-                        "description": "oauth state",
-                        "name": "state",
-                        "in": "query",
+                        "description": "acme credential id",
+                        "name": "cid",
+                        "in": "path",
                         "required": true
                     }
                 ],
@@ -1512,25 +1680,43 @@ const docTemplate = `{
                         "schema": {
                             "allOf": [
                                 {
-                                    "$ref": "#/definitions/web.User"
+                                    "$ref": "#/definitions/web.Domain"
                                 },
                                 {
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "type": "string"
+                                            "type": "integer"
                                         }
                                     }
                                 }
                             ]
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "allOf": [
                                 {
-                                    "$ref": "#/definitions/web.User"
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
                                 },
                                 {
                                     "type": "object",
@@ -1548,7 +1734,7 @@ const docTemplate = `{
                         "schema": {
                             "allOf": [
                                 {
-                                    "$ref": "#/definitions/web.User"
+                                    "$ref": "#/definitions/web.Domain"
                                 },
                                 {
                                     "type": "object",
@@ -1564,46 +1750,22 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/user/feishu": {
+        "/api/v1/domain/{id}/approval_policy": {
             "get": {
-                "description": "feishu auth redirect api",
-                "produces": [
-                    "application/json"
-                ],
-                "summary": "feishu auth redirect",
-                "responses": {
-                    "302": {
-                        "description": "Found"
-                    },
-                    "400": {
-                        "description": "Bad Request"
-                    }
-                }
-            }
-        },
-        "/api/v1/user/login": {
-            "post": {
-                "description": "login api\nuser can login with email or stu_id(Not implemented)",
+                "description": "Get the quorum of domain changes, domain without policy needs 1 owner\nuser must have read permission to domain or be admin",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "domain"
                 ],
-                "summary": "login",
+                "summary": "Get Domain Approval Policy",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "user email or stu_id",
-                        "name": "user",
-                        "in": "formData",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "user password",
-                        "name": "pass",
-                        "in": "formData",
+                        "description": "domain id",
+                        "name": "id",
+                        "in": "path",
                         "required": true
                     }
                 ],
@@ -1613,43 +1775,25 @@ const docTemplate = `{
                         "schema": {
                             "allOf": [
                                 {
-                                    "$ref": "#/definitions/web.User"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "type": "string"
-                                        }
-                                    }
-                                }
-                            ]
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/web.User"
+                                    "$ref": "#/definitions/web.Domain"
                                 },
                                 {
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "type": "integer"
+                                            "$ref": "#/definitions/models.ApprovalPolicy"
                                         }
                                     }
                                 }
                             ]
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "allOf": [
                                 {
-                                    "$ref": "#/definitions/web.User"
+                                    "$ref": "#/definitions/web.Domain"
                                 },
                                 {
                                     "type": "object",
@@ -1662,12 +1806,12 @@ const docTemplate = `{
                             ]
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "allOf": [
                                 {
-                                    "$ref": "#/definitions/web.User"
+                                    "$ref": "#/definitions/web.Domain"
                                 },
                                 {
                                     "type": "object",
@@ -1681,29 +1825,35 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/api/v1/user/register": {
-            "post": {
-                "description": "register api\nuser can register with email",
+            },
+            "put": {
+                "description": "Set the quorum of domain changes, e.g. 2 owners, or 1 owner and 1 admin\na vote counts for owners or admins, not both, any reject vote rejects the change\nthe quorum must be reachable by the current owners and admins, and privacy domain needs no admins\nuser must have owner permission to domain",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "register",
+                "tags": [
+                    "domain"
+                ],
+                "summary": "Update Domain Approval Policy",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "user email",
-                        "name": "email",
-                        "in": "formData",
+                        "description": "domain id",
+                        "name": "id",
+                        "in": "path",
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "user password",
-                        "name": "pass",
-                        "in": "formData",
-                        "required": true
+                        "description": "owners and admins",
+                        "name": "policy",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ApprovalPolicy"
+                        }
                     }
                 ],
                 "responses": {
@@ -1712,13 +1862,13 @@ const docTemplate = `{
                         "schema": {
                             "allOf": [
                                 {
-                                    "$ref": "#/definitions/web.User"
+                                    "$ref": "#/definitions/web.Domain"
                                 },
                                 {
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "type": "string"
+                                            "$ref": "#/definitions/models.ApprovalPolicy"
                                         }
                                     }
                                 }
@@ -1730,7 +1880,43 @@ const docTemplate = `{
                         "schema": {
                             "allOf": [
                                 {
-                                    "$ref": "#/definitions/web.User"
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
                                 },
                                 {
                                     "type": "object",
@@ -1748,7 +1934,7 @@ const docTemplate = `{
                         "schema": {
                             "allOf": [
                                 {
-                                    "$ref": "#/definitions/web.User"
+                                    "$ref": "#/definitions/web.Domain"
                                 },
                                 {
                                     "type": "object",
@@ -1764,23 +1950,30 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/user/{id}": {
-            "get": {
-                "description": "Get user info by id\nif id is not the same as jwt sub, jwt role must be admin",
+        "/api/v1/domain/{id}/ddns/{tid}": {
+            "delete": {
+                "description": "Revoke a ddns token of the domain\nuser must have readwrite permission to domain or be admin",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "domain"
                 ],
-                "summary": "Get user info",
+                "summary": "Delete DDNS Token",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "user id",
+                        "description": "domain id",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ddns token id",
+                        "name": "tid",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
@@ -1789,13 +1982,13 @@ const docTemplate = `{
                         "schema": {
                             "allOf": [
                                 {
-                                    "$ref": "#/definitions/web.User"
+                                    "$ref": "#/definitions/web.Domain"
                                 },
                                 {
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/models.User"
+                                            "type": "integer"
                                         }
                                     }
                                 }
@@ -1807,7 +2000,7 @@ const docTemplate = `{
                         "schema": {
                             "allOf": [
                                 {
-                                    "$ref": "#/definitions/web.User"
+                                    "$ref": "#/definitions/web.Domain"
                                 },
                                 {
                                     "type": "object",
@@ -1825,7 +2018,25 @@ const docTemplate = `{
                         "schema": {
                             "allOf": [
                                 {
-                                    "$ref": "#/definitions/web.User"
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
                                 },
                                 {
                                     "type": "object",
@@ -1839,9 +2050,11 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "put": {
-                "description": "Update user info by id\nOnly admin can update other user info whoes role is lower than his.",
+            }
+        },
+        "/api/v1/domain/{id}/dns": {
+            "get": {
+                "description": "List Domain Dns\nuser must have read permission to domain or be admin",
                 "consumes": [
                     "application/json"
                 ],
@@ -1849,25 +2062,52 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "domain"
                 ],
-                "summary": "Update user info",
+                "summary": "List Domain Dns",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "user id",
+                        "description": "domain id",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "user info",
-                        "name": "user",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.User"
-                        }
+                        "type": "string",
+                        "description": "filter by record type",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "filter by substring of record name",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "sort by name, type, content or ttl",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "asc or desc",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page number, start from 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "records per page, 0 for all",
+                        "name": "page_size",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -1876,13 +2116,13 @@ const docTemplate = `{
                         "schema": {
                             "allOf": [
                                 {
-                                    "$ref": "#/definitions/web.User"
+                                    "$ref": "#/definitions/web.Domain"
                                 },
                                 {
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/models.User"
+                                            "$ref": "#/definitions/web.DnsRecordPage"
                                         }
                                     }
                                 }
@@ -1894,7 +2134,7 @@ const docTemplate = `{
                         "schema": {
                             "allOf": [
                                 {
-                                    "$ref": "#/definitions/web.User"
+                                    "$ref": "#/definitions/web.Domain"
                                 },
                                 {
                                     "type": "object",
@@ -1912,7 +2152,7 @@ const docTemplate = `{
                         "schema": {
                             "allOf": [
                                 {
-                                    "$ref": "#/definitions/web.User"
+                                    "$ref": "#/definitions/web.Domain"
                                 },
                                 {
                                     "type": "object",
@@ -1930,25 +2170,7 @@ const docTemplate = `{
                         "schema": {
                             "allOf": [
                                 {
-                                    "$ref": "#/definitions/web.User"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "type": "integer"
-                                        }
-                                    }
-                                }
-                            ]
-                        }
-                    },
-                    "500": {
-                        "description": "Internal Server Error",
-                        "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/web.User"
+                                    "$ref": "#/definitions/web.Domain"
                                 },
                                 {
                                     "type": "object",
@@ -1963,8 +2185,8 @@ const docTemplate = `{
                     }
                 }
             },
-            "delete": {
-                "description": "Delete user by id\nOnly admin can delete user, and the user must has none role.",
+            "post": {
+                "description": "Create Domain Dns\nuser must have readwrite permission to domain or be admin\nfor now only owner can edit domain which ICP_reg is true\nrecords conflicting with the zone are refused: CNAME with other records at a name, or duplicates",
                 "consumes": [
                     "application/json"
                 ],
@@ -1972,16 +2194,31 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "domain"
                 ],
-                "summary": "Delete user",
+                "summary": "Create Domain Dns",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "user id",
+                        "description": "domain id",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "dns info",
+                        "name": "dns",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dns.Record"
+                        }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "only preview the change, data is mw.DnsDryRun",
+                        "name": "dry_run",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -1990,13 +2227,13 @@ const docTemplate = `{
                         "schema": {
                             "allOf": [
                                 {
-                                    "$ref": "#/definitions/web.User"
+                                    "$ref": "#/definitions/web.Domain"
                                 },
                                 {
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "type": "integer"
+                                            "$ref": "#/definitions/dns.Record"
                                         }
                                     }
                                 }
@@ -2008,13 +2245,16 @@ const docTemplate = `{
                         "schema": {
                             "allOf": [
                                 {
-                                    "$ref": "#/definitions/web.User"
+                                    "$ref": "#/definitions/web.Domain"
                                 },
                                 {
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "type": "integer"
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/modules.FieldError"
+                                            }
                                         }
                                     }
                                 }
@@ -2026,7 +2266,7 @@ const docTemplate = `{
                         "schema": {
                             "allOf": [
                                 {
-                                    "$ref": "#/definitions/web.User"
+                                    "$ref": "#/definitions/web.Domain"
                                 },
                                 {
                                     "type": "object",
@@ -2044,7 +2284,7 @@ const docTemplate = `{
                         "schema": {
                             "allOf": [
                                 {
-                                    "$ref": "#/definitions/web.User"
+                                    "$ref": "#/definitions/web.Domain"
                                 },
                                 {
                                     "type": "object",
@@ -2057,12 +2297,33 @@ const docTemplate = `{
                             ]
                         }
                     },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/modules.FieldError"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
                             "allOf": [
                                 {
-                                    "$ref": "#/definitions/web.User"
+                                    "$ref": "#/definitions/web.Domain"
                                 },
                                 {
                                     "type": "object",
@@ -2077,317 +2338,4021 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "dns.AliDNS": {
-            "type": "object",
-            "properties": {
-                "comment": {
-                    "type": "string"
-                },
-                "content": {
-                    "type": "string"
-                },
-                "custom": {
-                    "$ref": "#/definitions/dns.AliDNSCustom"
-                },
-                "id": {
-                    "type": "string"
-                },
-                "name": {
-                    "type": "string"
-                },
-                "priority": {
-                    "description": "Data     string ` + "`" + `json:\"data\"` + "`" + `",
-                    "type": "integer"
-                },
-                "ttl": {
-                    "type": "integer"
-                },
-                "type": {
-                    "type": "string"
-                }
-            }
-        },
-        "dns.AliDNSCustom": {
-            "type": "object",
-            "properties": {
-                "line": {
-                    "type": "string"
-                },
-                "status": {
-                    "type": "string"
-                }
-            }
-        },
-        "dns.AliDNSList": {
-            "type": "object",
-            "properties": {
-                "errors": {
-                    "type": "array",
-                    "items": {}
-                },
-                "messages": {
-                    "type": "array",
-                    "items": {}
-                },
-                "result": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/dns.AliDNS"
-                    }
-                },
-                "success": {
-                    "type": "boolean"
-                }
-            }
         },
-        "dns.CloudflareDNS": {
-            "type": "object",
-            "properties": {
-                "comment": {
-                    "type": "string"
-                },
-                "content": {
-                    "type": "string"
-                },
-                "data": {},
-                "id": {
+        "/api/v1/domain/{id}/dns/batch": {
+            "post": {
+                "description": "Create, update and delete dns records together, all operations are validated first,\nthen applied in order, if any of them fails the applied ones are rolled back\nuser must have readwrite permission to domain or be admin\nfor ICP domain the batch of non-owner is sent to owner for approval as one domain change",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "domain"
+                ],
+                "summary": "Batch Domain Dns",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "domain id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "operations in order",
+                        "name": "ops",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/modules.BatchOp"
+                            }
+                        }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "only preview the change, data is mw.DnsDryRun",
+                        "name": "dry_run",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/modules.Batch"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "208": {
+                        "description": "Already Reported",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/modules.FieldError"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/modules.FieldError"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/domain/{id}/dns/{dnsId}": {
+            "put": {
+                "description": "Update Domain Dns\nuser must have readwrite permission to domain or be admin\nfor now only owner can edit domain which ICP_reg is true\nrecords conflicting with the zone are refused: CNAME with other records at a name, or duplicates",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "domain"
+                ],
+                "summary": "Update Domain Dns",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "domain id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "dns id",
+                        "name": "dnsId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "dns info",
+                        "name": "dns",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dns.Record"
+                        }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "only preview the change, data is mw.DnsDryRun",
+                        "name": "dry_run",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dns.Record"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/modules.FieldError"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/modules.FieldError"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete Domain Dns\nuser must have readwrite permission to domain or be admin\nfor domain which ICP_reg is true, the delete of non-owner waits for owner approval",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "domain"
+                ],
+                "summary": "Delete Domain Dns",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "domain id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "dns id",
+                        "name": "dnsId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "only preview the change, data is mw.DnsDryRun",
+                        "name": "dry_run",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "208": {
+                        "description": "Already Reported",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/domain/{id}/dns/{dnsId}/ddns": {
+            "get": {
+                "description": "List ddns tokens of a dns record, tokens themselves are not responded\nuser must have readwrite permission to domain or be admin",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "domain"
+                ],
+                "summary": "List DDNS Tokens",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "domain id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "dns record id",
+                        "name": "dnsId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.DdnsToken"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a token to update the address of an A or AAAA record by /api/v1/ddns/update,\nthe token is only responded here, keep it safe\nuser must have readwrite permission to domain or be admin,\nfor ICP domain user must be owner, as updates by token are not reviewed",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "domain"
+                ],
+                "summary": "Create DDNS Token",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "domain id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "dns record id",
+                        "name": "dnsId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "token info",
+                        "name": "token",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/web.DdnsTokenCreate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/web.DdnsToken"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/domain/{id}/snapshots": {
+            "get": {
+                "description": "List the versions of domain records kept before each change, newest first, records are omitted\nuser must have read permission to domain or be admin",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "domain"
+                ],
+                "summary": "List Domain Dns Snapshots",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "domain id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/web.DnsSnapshot"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/domain/{id}/snapshots/{sid}": {
+            "get": {
+                "description": "Get a snapshot of domain records\nuser must have read permission to domain or be admin",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "domain"
+                ],
+                "summary": "Get Domain Dns Snapshot",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "domain id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "snapshot id",
+                        "name": "sid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/web.DnsSnapshot"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/domain/{id}/snapshots/{sid}/restore": {
+            "post": {
+                "description": "Compute and apply the changes to return domain records to the snapshot,\nrecords added after the snapshot are deleted, the state before restore is kept as a new version\nuser must have readwrite permission to domain or be admin\nfor ICP domain the restore of non-owner is sent to owner for approval as one domain change",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "domain"
+                ],
+                "summary": "Restore Domain Dns Snapshot",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "domain id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "snapshot id",
+                        "name": "sid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/modules.Plan"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "208": {
+                        "description": "Already Reported",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/modules.FieldError"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/domain/{id}/sync/apply": {
+            "post": {
+                "description": "Compute the plan of a yaml or json zone document like sync/plan and apply it\nuser must have readwrite permission to domain or be admin\nfor ICP domain the plan of non-owner is sent to owner for approval as one domain change",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "domain"
+                ],
+                "summary": "Apply Domain Dns Sync",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "domain id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "zone document",
+                        "name": "doc",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/modules.ZoneDocument"
+                        }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "only preview the change, data is mw.DnsDryRun",
+                        "name": "dry_run",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/modules.Plan"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "208": {
+                        "description": "Already Reported",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/modules.FieldError"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/modules.FieldError"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/domain/{id}/sync/plan": {
+            "post": {
+                "description": "Compare the desired records in a yaml or json zone document with the live records\nand list the creates, updates and deletes needed, nothing is changed\nuser must have read permission to domain or be admin",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "domain"
+                ],
+                "summary": "Plan Domain Dns Sync",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "domain id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "zone document",
+                        "name": "doc",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/modules.ZoneDocument"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/modules.Plan"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/modules.FieldError"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/domain/{id}/user": {
+            "get": {
+                "description": "List UserDomain Relation\nuser must have manager permission to domain or be admin",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "domain"
+                ],
+                "summary": "List UserDomain Relation",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "domain id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/web.DomainUserDetail"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create UserDomain Relation\nuser must have manager permission to domain or be admin\nuser cant create permission higher than himself",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "domain"
+                ],
+                "summary": "Create UserDomain Relation",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "domain id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "userRole",
+                        "name": "userRole",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/web.DomainUser"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.UserDomain"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/domain/{id}/user/{uid}": {
+            "delete": {
+                "description": "Delete UserDomain Relation **(no update, just delete and create)**\nuser must have manager permission to domain or be admin\nuser cant delete permission higher than himself",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "domain"
+                ],
+                "summary": "Delete UserDomain Relation",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "domain id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "user id",
+                        "name": "uid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/domain/{id}/zonefile": {
+            "get": {
+                "description": "Export all dns records of domain as a RFC 1035 zone file\nuser must have read permission to domain or be admin",
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "domain"
+                ],
+                "summary": "Export Domain Zone File",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "domain id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "zone file",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Parse a RFC 1035 zone file and create the records missing in domain\nthe zone file is read from multipart field \"file\", or the raw request body\nuser must have readwrite permission to domain or be admin\nfor ICP domain the records to create of non-owner are sent to owner for approval as one domain change",
+                "consumes": [
+                    "text/plain",
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "domain"
+                ],
+                "summary": "Import Domain Zone File",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "domain id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "zone file",
+                        "name": "file",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/modules.ZoneImportResult"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "208": {
+                        "description": "Already Reported",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/modules.FieldError"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/providers": {
+            "get": {
+                "description": "List all supported DNS vendors with their capabilities and credential schema\nthe name of provider is the value of vendor field in domain",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "provider"
+                ],
+                "summary": "List DNS providers",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Domain"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/dns.Provider"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/user": {
+            "get": {
+                "description": "Get user list\nOnly admin can get user list.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Get user list",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.User"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.User"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.User"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.User"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.User"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.User"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/user/callback": {
+            "get": {
+                "description": "oauth callback api\nuser can login with feishu for now",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "oauth callback",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "oauth code",
+                        "name": "code",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "oauth state",
+                        "name": "state",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/web.UserToken"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.User"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.User"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/user/feishu": {
+            "get": {
+                "description": "feishu auth redirect api",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "feishu auth redirect",
+                "responses": {
+                    "302": {
+                        "description": "Found"
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    }
+                }
+            }
+        },
+        "/api/v1/user/feishu/enable": {
+            "get": {
+                "summary": "feishu auth enable",
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/api/v1/user/login": {
+            "post": {
+                "description": "login api\nuser can login with email or stu_id(Not implemented)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "login",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "user email or stu_id",
+                        "name": "user",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "user password",
+                        "name": "pass",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/web.UserToken"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.User"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.User"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.User"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/user/logout": {
+            "post": {
+                "description": "revoke the session of current access token, its access tokens and refresh tokens\nare invalid at once, all sessions of current user are revoked if all is true",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "logout",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "logout all sessions",
+                        "name": "all",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.User"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.User"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/user/oidc": {
+            "get": {
+                "description": "OIDC auth redirect api",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "OIDC auth redirect",
+                "responses": {
+                    "302": {
+                        "description": "Found"
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    }
+                }
+            }
+        },
+        "/api/v1/user/oidc/enable": {
+            "get": {
+                "summary": "OIDC auth enable",
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/api/v1/user/refresh": {
+            "post": {
+                "description": "get a new access token by refresh token, the refresh token is rotated,\nthe old one must not be used again, or the whole session is revoked",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "refresh",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "refresh token",
+                        "name": "refresh_token",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/web.UserToken"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.User"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.User"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.User"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/user/register": {
+            "post": {
+                "description": "register api\nuser can register with email",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "register",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "user email",
+                        "name": "email",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "user password",
+                        "name": "pass",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/web.UserToken"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.User"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.User"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/user/token": {
+            "get": {
+                "description": "List personal access tokens of current user, tokens are not responded",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "List Access Tokens",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.User"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.AccessToken"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.User"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a personal access token of current user for API automation, it is used as\nbearer token like jwt, and acts as the user within its scopes:\nread for reads, dns:write for changes of records and zones, admin for everything\nelse, including ddns and acme credentials\ndomains limits the token to routes of these domains, user must have access to them\nthe token is only responded here, keep it safe",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Create Access Token",
+                "parameters": [
+                    {
+                        "description": "scopes, domains and expiry",
+                        "name": "token",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/web.AccessTokenCreate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.User"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/web.AccessToken"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.User"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.User"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.User"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/user/token/{tid}": {
+            "delete": {
+                "description": "Revoke a personal access token of current user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Delete Access Token",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "access token id",
+                        "name": "tid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.User"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.User"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.User"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/user/{id}": {
+            "get": {
+                "description": "Get user info by id\nif id is not the same as jwt sub, jwt role must be admin",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Get user info",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.User"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.User"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.User"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.User"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Update user info by id\nOnly admin can update other user info whoes role is lower than his.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Update user info",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "user info",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.User"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.User"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.User"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.User"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.User"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.User"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete user by id\nOnly admin can delete user, and the user must has none role.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Delete user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.User"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.User"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.User"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.User"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.User"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "dns.Capabilities": {
+            "type": "object",
+            "properties": {
+                "auto_ttl": {
+                    "description": "ttl 1 means automatic, e.g. cloudflare",
+                    "type": "boolean"
+                },
+                "comments": {
+                    "description": "records can carry a comment / remark",
+                    "type": "boolean"
+                },
+                "extensions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dns.ExtensionSpec"
+                    }
+                },
+                "lines": {
+                    "description": "records can be bound to resolve lines (ISP / region)",
+                    "type": "boolean"
+                },
+                "max_ttl": {
+                    "description": "0 for no bound",
+                    "type": "integer"
+                },
+                "min_ttl": {
+                    "description": "0 for no bound, the lowest of all plans of the vendor",
+                    "type": "integer"
+                },
+                "proxy": {
+                    "description": "records can be proxied, e.g. cloudflare orange cloud",
+                    "type": "boolean"
+                },
+                "record_types": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "split_txt": {
+                    "description": "TXT longer than 255 bytes is split into strings, or it is at most 255 bytes",
+                    "type": "boolean"
+                }
+            }
+        },
+        "dns.CredentialField": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "description": "field name in mw.DomainInfoUpdate, e.g. api_id",
+                    "type": "string"
+                },
+                "label": {
+                    "type": "string"
+                },
+                "secret": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "dns.ExtensionSpec": {
+            "type": "object",
+            "properties": {
+                "key": {
+                    "type": "string"
+                },
+                "type": {
+                    "$ref": "#/definitions/dns.ExtensionType"
+                }
+            }
+        },
+        "dns.ExtensionType": {
+            "type": "string",
+            "enum": [
+                "bool",
+                "string",
+                "object"
+            ],
+            "x-enum-varnames": [
+                "ExtBool",
+                "ExtString",
+                "ExtObject"
+            ]
+        },
+        "dns.Extensions": {
+            "type": "object",
+            "additionalProperties": true
+        },
+        "dns.Provider": {
+            "type": "object",
+            "properties": {
+                "capabilities": {
+                    "$ref": "#/definitions/dns.Capabilities"
+                },
+                "credentials": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dns.CredentialField"
+                    }
+                },
+                "display_name": {
+                    "type": "string"
+                },
+                "name": {
+                    "description": "value of models.Domain.Vendor",
+                    "type": "string"
+                }
+            }
+        },
+        "dns.Record": {
+            "type": "object",
+            "properties": {
+                "comment": {
+                    "type": "string"
+                },
+                "content": {
+                    "type": "string"
+                },
+                "extensions": {
+                    "$ref": "#/definitions/dns.Extensions"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "description": "relative to the domain, \"@\" for the apex",
+                    "type": "string"
+                },
+                "priority": {
+                    "description": "MX, SRV",
+                    "type": "integer"
+                },
+                "ttl": {
+                    "type": "integer"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "gorm.DeletedAt": {
+            "type": "object",
+            "properties": {
+                "time": {
+                    "type": "string"
+                },
+                "valid": {
+                    "description": "Valid is true if Time is not NULL",
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.AccessToken": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "deletedAt": {
+                    "$ref": "#/definitions/gorm.DeletedAt"
+                },
+                "domains": {
+                    "description": "comma separated domain ids the token is allowed to, empty for all",
+                    "type": "string"
+                },
+                "expires_at": {
+                    "description": "nil for never",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "description": "first characters of the token to recognize it",
+                    "type": "string"
+                },
+                "scopes": {
+                    "description": "space separated",
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.AcmeCredential": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "deletedAt": {
+                    "$ref": "#/definitions/gorm.DeletedAt"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "domain_id": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "description": "name the certificate is for, relative to the domain, \"@\" for the apex",
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                },
+                "username": {
+                    "description": "also the subdomain of acme-dns",
+                    "type": "string"
+                }
+            }
+        },
+        "models.ActionStatus": {
+            "type": "integer",
+            "enum": [
+                0,
+                1,
+                2,
+                3,
+                4,
+                5,
+                6
+            ],
+            "x-enum-comments": {
+                "Applying": "claimed by the approval applying it, back to reviewing if it fails with nothing changed",
+                "Cancelled": "withdrawn by the requester",
+                "Expired": "not reviewed in time, see config.ChangeConfig",
+                "Failed": "failed with records changed partly, it can't be approved again"
+            },
+            "x-enum-varnames": [
+                "Reviewing",
+                "Approved",
+                "Rejected",
+                "Cancelled",
+                "Expired",
+                "Applying",
+                "Failed"
+            ]
+        },
+        "models.ApprovalPolicy": {
+            "type": "object",
+            "properties": {
+                "admins": {
+                    "description": "approvals of admins, a vote counts for owners or admins, not both",
+                    "type": "integer"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "deletedAt": {
+                    "$ref": "#/definitions/gorm.DeletedAt"
+                },
+                "domain_id": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "owners": {
+                    "description": "approvals of domain owners, or managers for access changes they can grant",
+                    "type": "integer"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.AuditEvent": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "description": "method and route, e.g. \"PUT /api/v1/domain/:id/dns/:dnsId\"",
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "deletedAt": {
+                    "$ref": "#/definitions/gorm.DeletedAt"
+                },
+                "domain_id": {
+                    "description": "0 if not about a domain",
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "ip": {
+                    "type": "string"
+                },
+                "new_value": {
+                    "description": "json string",
+                    "type": "string"
+                },
+                "old_value": {
+                    "description": "json string",
+                    "type": "string"
+                },
+                "record_id": {
+                    "type": "string"
+                },
+                "result": {
+                    "type": "boolean"
+                },
+                "status": {
+                    "description": "http status of response",
+                    "type": "integer"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "user_agent": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "description": "0 if not logged in",
+                    "type": "integer"
+                },
+                "user_name": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.DdnsToken": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "deletedAt": {
+                    "$ref": "#/definitions/gorm.DeletedAt"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "domain_id": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_ip": {
+                    "type": "string"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "description": "relative to the domain, \"@\" for the apex",
+                    "type": "string"
+                },
+                "record_id": {
+                    "description": "follows the record if the vendor changes the id on update",
+                    "type": "string"
+                },
+                "type": {
+                    "description": "A or AAAA",
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Domain": {
+            "type": "object",
+            "properties": {
+                "ICP_reg": {
+                    "description": "0: no, 1: yes",
+                    "type": "integer"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "deletedAt": {
+                    "$ref": "#/definitions/gorm.DeletedAt"
+                },
+                "endpoint": {
+                    "description": "server of self-hosted vendors, e.g. host:port of rfc2136",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "privacy": {
+                    "description": "true: invisible to the admin, false: the admin can see this domain",
+                    "type": "boolean"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "users": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.User"
+                    }
+                },
+                "vendor": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.DomainAction": {
+            "type": "integer",
+            "enum": [
+                0,
+                1,
+                2,
+                3,
+                4,
+                5,
+                6,
+                7
+            ],
+            "x-enum-varnames": [
+                "Submit",
+                "EditDNS",
+                "EditOthers",
+                "GrantAccess",
+                "RevokeAccess",
+                "Delete",
+                "SyncPlan",
+                "Batch"
+            ]
+        },
+        "models.DomainChange": {
+            "type": "object",
+            "properties": {
+                "actionStatus": {
+                    "description": "0: reviewing, 1: approved, 2: rejected, 3: cancelled, 4: expired, 5: applying, 6: failed",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.ActionStatus"
+                        }
+                    ]
+                },
+                "actionType": {
+                    "description": "0: submit, 1: edit DNS, 2: edit others, 3: grant access, 4: revoke access, 5: delete DNS, 6: apply sync plan, 7: apply DNS batch",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.DomainAction"
+                        }
+                    ]
+                },
+                "comment": {
+                    "description": "why the change is rejected, cancelled or failed",
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "deletedAt": {
+                    "$ref": "#/definitions/gorm.DeletedAt"
+                },
+                "domain": {
+                    "$ref": "#/definitions/models.Domain"
+                },
+                "domainId": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "operation": {
+                    "description": "json string, describe the operation details",
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "user": {
+                    "$ref": "#/definitions/models.User"
+                },
+                "userId": {
+                    "type": "integer"
+                },
+                "votes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.DomainChangeVote"
+                    }
+                }
+            }
+        },
+        "models.DomainChangeVote": {
+            "type": "object",
+            "properties": {
+                "approve": {
+                    "type": "boolean"
+                },
+                "comment": {
                     "type": "string"
                 },
-                "name": {
+                "createdAt": {
                     "type": "string"
                 },
-                "priority": {
+                "deletedAt": {
+                    "$ref": "#/definitions/gorm.DeletedAt"
+                },
+                "domain_change_id": {
                     "type": "integer"
                 },
-                "proxied": {
-                    "type": "boolean"
+                "id": {
+                    "type": "integer"
                 },
-                "ttl": {
+                "reviewer": {
+                    "$ref": "#/definitions/models.User"
+                },
+                "reviewer_id": {
                     "type": "integer"
                 },
-                "type": {
+                "updatedAt": {
                     "type": "string"
                 }
             }
         },
-        "dns.CloudflareDNSList": {
+        "models.SnapshotSource": {
+            "type": "integer",
+            "enum": [
+                0,
+                1,
+                2,
+                3
+            ],
+            "x-enum-varnames": [
+                "SnapshotPolled",
+                "SnapshotManaged",
+                "SnapshotDrift",
+                "SnapshotVersion"
+            ]
+        },
+        "models.User": {
             "type": "object",
             "properties": {
-                "errors": {
-                    "type": "array",
-                    "items": {}
+                "createdAt": {
+                    "type": "string"
                 },
-                "messages": {
-                    "type": "array",
-                    "items": {}
+                "deletedAt": {
+                    "$ref": "#/definitions/gorm.DeletedAt"
                 },
-                "result": {
+                "domains": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/dns.CloudflareDNS"
+                        "$ref": "#/definitions/models.Domain"
                     }
                 },
-                "success": {
-                    "type": "boolean"
+                "email": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "role": {
+                    "$ref": "#/definitions/models.UserRole"
+                },
+                "stuId": {
+                    "$ref": "#/definitions/sql.NullString"
+                },
+                "updatedAt": {
+                    "type": "string"
                 }
             }
         },
-        "dns.TencentDNS": {
+        "models.UserDomain": {
             "type": "object",
             "properties": {
-                "comment": {
+                "createdAt": {
                     "type": "string"
                 },
-                "content": {
-                    "type": "string"
+                "domainId": {
+                    "type": "integer"
+                },
+                "role": {
+                    "description": "0: read only, 1: read write, 2: manager, 3: owner",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.UserDomainRole"
+                        }
+                    ]
                 },
-                "custom": {
-                    "$ref": "#/definitions/dns.TencentDNSCustom"
+                "updatedAt": {
+                    "type": "string"
                 },
-                "id": {
+                "userId": {
                     "type": "integer"
+                }
+            }
+        },
+        "models.UserDomainRole": {
+            "type": "integer",
+            "enum": [
+                0,
+                1,
+                2,
+                3
+            ],
+            "x-enum-comments": {
+                "Manager": "add R/W user, delete R/W user, R/W",
+                "ReadWrite": "manage records, but not others"
+            },
+            "x-enum-varnames": [
+                "ReadOnly",
+                "ReadWrite",
+                "Manager",
+                "Owner"
+            ]
+        },
+        "models.UserRole": {
+            "type": "integer",
+            "enum": [
+                0,
+                1,
+                2,
+                3
+            ],
+            "x-enum-comments": {
+                "Admin": "can submit new domain, access and delete all domains, promte/demote user to Contributor, grant/deny all domains access to other Normal user",
+                "Contributor": "can submit new domain, access and delete own domain, grant/deny own domain access to other Normal user",
+                "Normal": "only can access granted domains",
+                "SysAdmin": "same as Admin, promte/demote user to Admin"
+            },
+            "x-enum-varnames": [
+                "Normal",
+                "Contributor",
+                "Admin",
+                "SysAdmin"
+            ]
+        },
+        "modules.Batch": {
+            "type": "object",
+            "properties": {
+                "steps": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/modules.BatchStep"
+                    }
+                }
+            }
+        },
+        "modules.BatchOp": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "description": "record to update or delete",
+                    "type": "string"
                 },
-                "name": {
+                "op": {
+                    "description": "create, update or delete",
                     "type": "string"
                 },
-                "priority": {
-                    "description": "Data     string ` + "`" + `json:\"data\"` + "`" + `",
-                    "type": "integer"
+                "record": {
+                    "description": "record to create, or fields to update, absent fields are kept",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "modules.BatchStep": {
+            "type": "object",
+            "properties": {
+                "after": {
+                    "$ref": "#/definitions/dns.Record"
                 },
-                "ttl": {
-                    "type": "integer"
+                "before": {
+                    "$ref": "#/definitions/dns.Record"
                 },
-                "type": {
+                "op": {
                     "type": "string"
                 }
             }
         },
-        "dns.TencentDNSCustom": {
+        "modules.FieldDiff": {
             "type": "object",
             "properties": {
-                "enable": {
+                "base": {},
+                "field": {
                     "type": "string"
                 },
-                "record_line": {
+                "live": {},
+                "proposed": {}
+            }
+        },
+        "modules.FieldError": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string"
+                },
+                "message": {
                     "type": "string"
                 }
             }
         },
-        "dns.TencentDNSList": {
+        "modules.Plan": {
             "type": "object",
             "properties": {
-                "errors": {
+                "creates": {
                     "type": "array",
-                    "items": {}
+                    "items": {
+                        "$ref": "#/definitions/dns.Record"
+                    }
                 },
-                "messages": {
+                "deletes": {
                     "type": "array",
-                    "items": {}
+                    "items": {
+                        "$ref": "#/definitions/dns.Record"
+                    }
                 },
-                "result": {
+                "updates": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/dns.TencentDNS"
+                        "$ref": "#/definitions/modules.PlanUpdate"
                     }
+                }
+            }
+        },
+        "modules.PlanUpdate": {
+            "type": "object",
+            "properties": {
+                "after": {
+                    "$ref": "#/definitions/dns.Record"
+                },
+                "before": {
+                    "$ref": "#/definitions/dns.Record"
+                }
+            }
+        },
+        "modules.RecordDiff": {
+            "type": "object",
+            "properties": {
+                "base": {
+                    "$ref": "#/definitions/dns.Record"
                 },
-                "success": {
+                "conflict": {
+                    "description": "the record is changed or deleted since submitted",
                     "type": "boolean"
+                },
+                "fields": {
+                    "description": "fields not the same in all states",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/modules.FieldDiff"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "live": {
+                    "$ref": "#/definitions/dns.Record"
+                },
+                "proposed": {
+                    "$ref": "#/definitions/dns.Record"
                 }
             }
         },
-        "gorm.DeletedAt": {
+        "modules.ZoneDocument": {
             "type": "object",
             "properties": {
-                "time": {
+                "domain": {
+                    "description": "optional, must be the synced domain if set",
                     "type": "string"
                 },
-                "valid": {
-                    "description": "Valid is true if Time is not NULL",
+                "prune": {
+                    "description": "delete records absent in document, default true",
                     "type": "boolean"
+                },
+                "records": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dns.Record"
+                    }
                 }
             }
         },
-        "models.ActionStatus": {
-            "type": "integer",
-            "enum": [
-                0,
-                1,
-                2
-            ],
-            "x-enum-varnames": [
-                "Reviewing",
-                "Approved",
-                "Rejected"
-            ]
+        "modules.ZoneImportFailed": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "record": {
+                    "$ref": "#/definitions/dns.Record"
+                }
+            }
         },
-        "models.Domain": {
+        "modules.ZoneImportResult": {
             "type": "object",
             "properties": {
-                "ICP_reg": {
-                    "description": "0: no, 1: yes",
-                    "type": "integer"
+                "created": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dns.Record"
+                    }
+                },
+                "failed": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/modules.ZoneImportFailed"
+                    }
+                },
+                "skipped": {
+                    "description": "already exist, or managed by the vendor",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dns.Record"
+                    }
+                }
+            }
+        },
+        "sql.NullString": {
+            "type": "object",
+            "properties": {
+                "string": {
+                    "type": "string"
+                },
+                "valid": {
+                    "description": "Valid is true if String is not NULL",
+                    "type": "boolean"
+                }
+            }
+        },
+        "web.AccessRequest": {
+            "type": "object",
+            "properties": {
+                "reason": {
+                    "type": "string"
+                },
+                "role": {
+                    "description": "role to grant, ignored by revoke",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.UserDomainRole"
+                        }
+                    ]
                 },
+                "user_id": {
+                    "description": "user to revoke, ignored by grant which is for the requester",
+                    "type": "integer"
+                }
+            }
+        },
+        "web.AccessToken": {
+            "type": "object",
+            "properties": {
                 "createdAt": {
                     "type": "string"
                 },
-                "deletedAt": {
-                    "$ref": "#/definitions/gorm.DeletedAt"
+                "deletedAt": {
+                    "$ref": "#/definitions/gorm.DeletedAt"
+                },
+                "domains": {
+                    "description": "comma separated domain ids the token is allowed to, empty for all",
+                    "type": "string"
+                },
+                "expires_at": {
+                    "description": "nil for never",
+                    "type": "string"
                 },
                 "id": {
                     "type": "integer"
                 },
+                "last_used_at": {
+                    "type": "string"
+                },
                 "name": {
                     "type": "string"
                 },
+                "prefix": {
+                    "description": "first characters of the token to recognize it",
+                    "type": "string"
+                },
+                "scopes": {
+                    "description": "space separated",
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                },
                 "updatedAt": {
                     "type": "string"
                 },
-                "users": {
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "web.AccessTokenCreate": {
+            "type": "object",
+            "properties": {
+                "domains": {
+                    "description": "domain ids the token is allowed to, empty for all",
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/models.User"
+                        "type": "integer"
                     }
                 },
-                "vendor": {
+                "expires_in": {
+                    "description": "days until expiry, 0 for never",
+                    "type": "integer"
+                },
+                "name": {
                     "type": "string"
+                },
+                "scopes": {
+                    "description": "read, dns:write or admin",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 }
             }
         },
-        "models.DomainAction": {
-            "type": "integer",
-            "enum": [
-                0,
-                1,
-                2,
-                3,
-                4,
-                5
-            ],
-            "x-enum-varnames": [
-                "Submit",
-                "EditDNS",
-                "EditOthers",
-                "GrantAccess",
-                "RevokeAccess",
-                "Delete"
-            ]
-        },
-        "models.DomainChange": {
+        "web.AcmeCredential": {
             "type": "object",
             "properties": {
-                "actionStatus": {
-                    "description": "0: reviewing, 1: approved, 2: rejected",
-                    "allOf": [
-                        {
-                            "$ref": "#/definitions/models.ActionStatus"
-                        }
-                    ]
+                "allowfrom": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "actionType": {
-                    "description": "0: submit, 1: edit DNS, 2: edit others, 3: grant access, 4: revoke access, 5: delete",
-                    "allOf": [
-                        {
-                            "$ref": "#/definitions/models.DomainAction"
-                        }
-                    ]
+                "credential": {
+                    "$ref": "#/definitions/models.AcmeCredential"
                 },
-                "createdAt": {
+                "fulldomain": {
                     "type": "string"
                 },
-                "deletedAt": {
-                    "$ref": "#/definitions/gorm.DeletedAt"
-                },
-                "domain": {
-                    "$ref": "#/definitions/models.Domain"
-                },
-                "domainId": {
-                    "type": "integer"
-                },
-                "id": {
-                    "type": "integer"
+                "password": {
+                    "type": "string"
                 },
-                "operation": {
-                    "description": "json string, describe the operation details",
+                "subdomain": {
                     "type": "string"
                 },
-                "reason": {
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "web.AcmeCredentialCreate": {
+            "type": "object",
+            "properties": {
+                "description": {
                     "type": "string"
                 },
-                "updatedAt": {
+                "name": {
+                    "description": "relative to the domain, \"@\" for the apex",
                     "type": "string"
+                }
+            }
+        },
+        "web.AuditPage": {
+            "type": "object",
+            "properties": {
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.AuditEvent"
+                    }
                 },
-                "user": {
-                    "$ref": "#/definitions/models.User"
+                "page": {
+                    "type": "integer"
                 },
-                "userId": {
+                "page_size": {
+                    "type": "integer"
+                },
+                "total": {
                     "type": "integer"
                 }
             }
         },
-        "models.User": {
+        "web.DdnsToken": {
             "type": "object",
             "properties": {
                 "createdAt": {
@@ -2396,106 +6361,95 @@ const docTemplate = `{
                 "deletedAt": {
                     "$ref": "#/definitions/gorm.DeletedAt"
                 },
-                "domains": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/models.Domain"
-                    }
-                },
-                "email": {
+                "description": {
                     "type": "string"
                 },
+                "domain_id": {
+                    "type": "integer"
+                },
                 "id": {
                     "type": "integer"
                 },
+                "last_ip": {
+                    "type": "string"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
                 "name": {
+                    "description": "relative to the domain, \"@\" for the apex",
                     "type": "string"
                 },
-                "role": {
-                    "$ref": "#/definitions/models.UserRole"
+                "record_id": {
+                    "description": "follows the record if the vendor changes the id on update",
+                    "type": "string"
                 },
-                "stuId": {
-                    "$ref": "#/definitions/sql.NullString"
+                "token": {
+                    "type": "string"
+                },
+                "type": {
+                    "description": "A or AAAA",
+                    "type": "string"
                 },
                 "updatedAt": {
                     "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
                 }
             }
         },
-        "models.UserDomain": {
+        "web.DdnsTokenCreate": {
             "type": "object",
             "properties": {
-                "createdAt": {
+                "description": {
                     "type": "string"
-                },
-                "domainId": {
+                }
+            }
+        },
+        "web.DnsRecordPage": {
+            "type": "object",
+            "properties": {
+                "page": {
                     "type": "integer"
                 },
-                "role": {
-                    "description": "0: read only, 1: read write, 2: manager, 3: owner",
-                    "allOf": [
-                        {
-                            "$ref": "#/definitions/models.UserDomainRole"
-                        }
-                    ]
+                "page_size": {
+                    "type": "integer"
                 },
-                "updatedAt": {
-                    "type": "string"
+                "records": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dns.Record"
+                    }
                 },
-                "userId": {
+                "total": {
                     "type": "integer"
                 }
             }
         },
-        "models.UserDomainRole": {
-            "type": "integer",
-            "enum": [
-                0,
-                1,
-                2,
-                3
-            ],
-            "x-enum-comments": {
-                "Manager": "add R/W user, delete R/W user, R/W",
-                "ReadWrite": "manage records, but not others"
-            },
-            "x-enum-varnames": [
-                "ReadOnly",
-                "ReadWrite",
-                "Manager",
-                "Owner"
-            ]
-        },
-        "models.UserRole": {
-            "type": "integer",
-            "enum": [
-                0,
-                1,
-                2,
-                3
-            ],
-            "x-enum-comments": {
-                "Admin": "can submit new domain, access and delete all domains, promte/demote user to Contributor, grant/deny all domains access to other Normal user",
-                "Contributor": "can submit new domain, access and delete own domain, grant/deny own domain access to other Normal user",
-                "Normal": "only can access granted domains",
-                "SysAdmin": "same as Admin, promte/demote user to Admin"
-            },
-            "x-enum-varnames": [
-                "Normal",
-                "Contributor",
-                "Admin",
-                "SysAdmin"
-            ]
-        },
-        "sql.NullString": {
+        "web.DnsSnapshot": {
             "type": "object",
             "properties": {
-                "string": {
+                "created_at": {
                     "type": "string"
                 },
-                "valid": {
-                    "description": "Valid is true if String is not NULL",
-                    "type": "boolean"
+                "id": {
+                    "type": "integer"
+                },
+                "records": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dns.Record"
+                    }
+                },
+                "source": {
+                    "$ref": "#/definitions/models.SnapshotSource"
+                },
+                "user_id": {
+                    "type": "integer"
+                },
+                "version": {
+                    "type": "integer"
                 }
             }
         },
@@ -2523,9 +6477,16 @@ const docTemplate = `{
                 "api_secret": {
                     "type": "string"
                 },
+                "endpoint": {
+                    "description": "optional, see Provider.Credentials",
+                    "type": "string"
+                },
                 "name": {
                     "type": "string"
                 },
+                "privacy": {
+                    "type": "boolean"
+                },
                 "vendor": {
                     "type": "string"
                 }
@@ -2565,6 +6526,50 @@ const docTemplate = `{
                 }
             }
         },
+        "web.Jwk": {
+            "type": "object",
+            "properties": {
+                "alg": {
+                    "type": "string"
+                },
+                "crv": {
+                    "description": "OKP",
+                    "type": "string"
+                },
+                "e": {
+                    "description": "RSA",
+                    "type": "string"
+                },
+                "kid": {
+                    "type": "string"
+                },
+                "kty": {
+                    "type": "string"
+                },
+                "n": {
+                    "description": "RSA",
+                    "type": "string"
+                },
+                "use": {
+                    "type": "string"
+                },
+                "x": {
+                    "description": "OKP",
+                    "type": "string"
+                }
+            }
+        },
+        "web.Jwks": {
+            "type": "object",
+            "properties": {
+                "keys": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/web.Jwk"
+                    }
+                }
+            }
+        },
         "web.User": {
             "type": "object",
             "properties": {
@@ -2576,6 +6581,27 @@ const docTemplate = `{
                     "type": "integer"
                 }
             }
+        },
+        "web.UserToken": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "expires_in": {
+                    "description": "seconds the access token is valid",
+                    "type": "integer"
+                },
+                "refresh_token": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "integer"
+                }
+            }
         }
     }
 }`
@@ -5,6 +5,7 @@ import (
 	"domain0/database"
 	_ "domain0/docs"
 	"domain0/routers"
+	"domain0/services"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/swagger"
@@ -33,8 +34,26 @@ func main() {
 		logrus.Fatal(err)
 	}
 
+	// init jwt signing keys
+	if err := services.InitSigningKeys(); err != nil {
+		logrus.Error("Failed to init jwt signing keys")
+		logrus.Fatal(err)
+	}
+
+	// start background jobs
+	services.StartDriftDetector()
+	services.StartChangeExpirer()
+	services.StartLocalDNS()
+	services.StartAcmeCleaner()
+	services.StartKeyRotator()
+
 	f := fiber.New(fiber.Config{
 		// set fiber config
+		// client ip is taken from the header only when the request comes from a trusted proxy
+		ProxyHeader:             config.CONFIG.Proxy.Header,
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          config.CONFIG.Proxy.TrustedProxies,
+		EnableIPValidation:      true,
 	})
 
 	// init swagger
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// scopes of personal access tokens
+const (
+	ScopeRead     = "read"      // requests which change nothing
+	ScopeDnsWrite = "dns:write" // changes of records and zones of domains, implies read, not ddns or acme credentials
+	ScopeAdmin    = "admin"     // everything the user can do, including the admin privileges of the role
+)
+
+// AccessToken is a personal access token for API automation, it acts as the user within its scopes
+type AccessToken struct {
+	gorm.Model
+	UserId     uint       `json:"user_id" gorm:"index"`
+	Name       string     `json:"name"`
+	Scopes     string     `json:"scopes"`               // space separated
+	Domains    string     `json:"domains"`              // comma separated domain ids the token is allowed to, empty for all
+	Prefix     string     `json:"prefix"`               // first characters of the token to recognize it
+	TokenHash  string     `json:"-" gorm:"uniqueIndex"` // sha256 of the token, which is only shown on creation
+	ExpiresAt  *time.Time `json:"expires_at"`           // nil for never
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+func (t *AccessToken) HasScope(scope string) bool {
+	for _, s := range strings.Fields(t.Scopes) {
+		if s == scope || s == ScopeAdmin || (s == ScopeDnsWrite && scope == ScopeRead) {
+			return true
+		}
+	}
+	return false
+}
+
+// DomainAllowed reports whether the token is allowed to the domain by its allow-list
+func (t *AccessToken) DomainAllowed(domainId uint) bool {
+	if t.Domains == "" {
+		return true
+	}
+	for _, s := range strings.Split(t.Domains, ",") {
+		if id, err := strconv.ParseUint(s, 10, 64); err == nil && uint(id) == domainId {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *AccessToken) Expired() bool {
+	return t.ExpiresAt != nil && time.Now().After(*t.ExpiresAt)
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AcmeCredential lets an ACME client create and delete the DNS-01 challenge TXT records of one name,
+// by acme-dns or lego httpreq protocol. Changes are made as the user created the credential
+type AcmeCredential struct {
+	gorm.Model
+	DomainId    uint       `json:"domain_id" gorm:"index"`
+	Name        string     `json:"name"` // name the certificate is for, relative to the domain, "@" for the apex
+	UserId      uint       `json:"user_id"`
+	Username    string     `json:"username" gorm:"uniqueIndex"` // also the subdomain of acme-dns
+	KeyHash     string     `json:"-"`                           // sha256 of the key, which is only shown on creation
+	Description string     `json:"description"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+}
+
+// AcmeChallenge is a challenge TXT record created by an AcmeCredential, deleted after ExpiresAt
+// if the client does not clean it up
+type AcmeChallenge struct {
+	gorm.Model
+	CredentialId uint      `gorm:"index"`
+	DomainId     uint      `gorm:"index"`
+	RecordId     string    // id of the TXT record of the vendor
+	Value        string    // content of the TXT record
+	ExpiresAt    time.Time `gorm:"index"`
+}
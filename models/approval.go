@@ -0,0 +1,26 @@
+package models
+
+import "gorm.io/gorm"
+
+// ApprovalPolicy is the quorum for domain changes of a domain to be applied,
+// domain without policy uses DefaultApprovalPolicy
+type ApprovalPolicy struct {
+	gorm.Model
+	DomainId uint `json:"domain_id" gorm:"uniqueIndex"`
+	Owners   int  `json:"owners"` // approvals of domain owners, or managers for access changes they can grant
+	Admins   int  `json:"admins"` // approvals of admins, a vote counts for owners or admins, not both
+}
+
+// DomainChangeVote is the review of a domain change by a reviewer, one vote for each reviewer
+type DomainChangeVote struct {
+	gorm.Model
+	DomainChangeId uint   `json:"domain_change_id" gorm:"uniqueIndex:idx_change_reviewer"`
+	ReviewerId     uint   `json:"reviewer_id" gorm:"uniqueIndex:idx_change_reviewer"`
+	Reviewer       User   `json:"reviewer"`
+	Approve        bool   `json:"approve"`
+	Comment        string `json:"comment"`
+}
+
+func DefaultApprovalPolicy(domainId uint) ApprovalPolicy {
+	return ApprovalPolicy{DomainId: domainId, Owners: 1}
+}
@@ -0,0 +1,20 @@
+package models
+
+import "gorm.io/gorm"
+
+// AuditEvent is a mutating request to domain0, and its result
+type AuditEvent struct {
+	gorm.Model
+	UserId    uint   `json:"user_id" gorm:"index"` // 0 if not logged in
+	UserName  string `json:"user_name"`
+	IP        string `json:"ip"`
+	UserAgent string `json:"user_agent"`
+	DomainId  uint   `json:"domain_id" gorm:"index"` // 0 if not about a domain
+	RecordId  string `json:"record_id"`
+	Action    string `json:"action" gorm:"index"` // method and route, e.g. "PUT /api/v1/domain/:id/dns/:dnsId"
+	OldValue  string `json:"old_value"`           // json string
+	NewValue  string `json:"new_value"`           // json string
+	Status    int    `json:"status"`              // http status of response
+	Result    bool   `json:"result"`
+	Error     string `json:"error,omitempty"`
+}
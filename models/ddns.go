@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DdnsToken lets a client update the address of one A or AAAA record by dyndns2 protocol,
+// the update is made as the user created the token
+type DdnsToken struct {
+	gorm.Model
+	DomainId    uint       `json:"domain_id" gorm:"index"`
+	RecordId    string     `json:"record_id" gorm:"index"` // follows the record if the vendor changes the id on update
+	Name        string     `json:"name"`                   // relative to the domain, "@" for the apex
+	Type        string     `json:"type"`                   // A or AAAA
+	UserId      uint       `json:"user_id"`
+	Description string     `json:"description"`
+	TokenHash   string     `json:"-" gorm:"uniqueIndex"` // sha256 of the token, which is only shown on creation
+	LastIP      string     `json:"last_ip"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+}
@@ -17,6 +17,7 @@ type Domain struct {
 	ApiId     string  `json:"-"`
 	ApiSecret string  `json:"-"`
 	Vendor    string  `json:"vendor"`
+	Endpoint  string  `json:"endpoint"`                 // server of self-hosted vendors, e.g. host:port of rfc2136
 	ICPReg    uint    `json:"ICP_reg" gorm:"default:0"` // 0: no, 1: yes
 	Users     []*User `gorm:"many2many:user_domains;"`
 	Privacy   bool    `json:"privacy" gorm:"default:false"` // true: invisible to the admin, false: the admin can see this domain
@@ -28,10 +29,12 @@ type DomainChange struct {
 	Domain       Domain
 	UserId       uint
 	User         User
-	ActionType   DomainAction // 0: submit, 1: edit DNS, 2: edit others, 3: grant access, 4: revoke access, 5: delete
-	ActionStatus ActionStatus // 0: reviewing, 1: approved, 2: rejected
+	ActionType   DomainAction // 0: submit, 1: edit DNS, 2: edit others, 3: grant access, 4: revoke access, 5: delete DNS, 6: apply sync plan, 7: apply DNS batch
+	ActionStatus ActionStatus // 0: reviewing, 1: approved, 2: rejected, 3: cancelled, 4: expired, 5: applying, 6: failed
 	Reason       string
-	Operation    string // json string, describe the operation details
+	Comment      string             // why the change is rejected, cancelled or failed
+	Operation    string             // json string, describe the operation details
+	Votes        []DomainChangeVote `gorm:"foreignKey:DomainChangeId"`
 }
 
 type UserDomain struct {
@@ -49,12 +52,18 @@ const (
 	GrantAccess
 	RevokeAccess
 	Delete
+	SyncPlan
+	Batch
 )
 
 const (
 	Reviewing ActionStatus = iota
 	Approved
 	Rejected
+	Cancelled // withdrawn by the requester
+	Expired   // not reviewed in time, see config.ChangeConfig
+	Applying  // claimed by the approval applying it, back to reviewing if it fails with nothing changed
+	Failed    // failed with records changed partly, it can't be approved again
 )
 
 const (
@@ -64,10 +73,24 @@ const (
 	Owner
 )
 
+// ChangesDns reports whether the action changes dns records of the domain
+func (a DomainAction) ChangesDns() bool {
+	return a == Submit || a == EditDNS || a == Delete || a == SyncPlan || a == Batch
+}
+
+// ChangesAccess reports whether the action changes user roles of the domain
+func (a DomainAction) ChangesAccess() bool {
+	return a == GrantAccess || a == RevokeAccess
+}
+
 func (u *UserDomainRole) String() string {
 	return [...]string{"ReadOnly", "ReadWrite", "Manager", "Owner"}[*u]
 }
 
+func (s *ActionStatus) String() string {
+	return [...]string{"Reviewing", "Approved", "Rejected", "Cancelled", "Expired", "Applying", "Failed"}[*s]
+}
+
 func (d *Domain) ExtractAuth() (string, string, error) {
 	if len(d.ApiId) == 0 || len(d.ApiSecret) == 0 {
 		return "", "", errors.New("api id or secret is empty")
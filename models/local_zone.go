@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// LocalRecord is a record of a domain of vendor local, served by the built-in authoritative server
+type LocalRecord struct {
+	gorm.Model
+	DomainId uint   `gorm:"index"`
+	Name     string // relative to the domain, "@" for the apex
+	Type     string
+	Content  string
+	TTL      int
+	Priority uint16
+	Comment  string
+	Disabled bool // kept but not served
+}
+
+// LocalZone is the SOA serial of a domain of vendor local, it is increased on each change
+type LocalZone struct {
+	gorm.Model
+	DomainId uint `gorm:"uniqueIndex"`
+	Serial   uint
+}
+
+// LocalZoneChange is the journal of local zones for IXFR, a served record added or deleted by
+// the change to serial
+type LocalZoneChange struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	DomainId  uint `gorm:"index"`
+	Serial    uint
+	Deleted   bool   // the record is deleted, otherwise added
+	RR        string // resource record in presentation format
+}
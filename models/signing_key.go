@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SigningKey is an asymmetric key signing jwt, the latest active one signs, a new key is published
+// in jwks before it is active, and retired ones are kept in jwks until tokens signed by them expire
+type SigningKey struct {
+	gorm.Model
+	Kid        string     `gorm:"uniqueIndex"` // RFC 7638 thumbprint of the public key
+	Algorithm  string     // RS256 or EdDSA
+	PrivateKey string     `json:"-"` // PKCS #8 PEM
+	ActiveAt   time.Time  // when it starts signing
+	RetiredAt  *time.Time // when a newer key starts signing
+	Follows    *uint      `gorm:"uniqueIndex"` // id of the newest key when generated, 0 for the first one
+}
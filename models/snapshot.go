@@ -0,0 +1,22 @@
+package models
+
+import "gorm.io/gorm"
+
+type SnapshotSource int
+
+// DnsSnapshot is the records of a domain at some time, vendor managed records are not kept
+type DnsSnapshot struct {
+	gorm.Model
+	DomainId uint           `gorm:"index"`
+	Source   SnapshotSource // 0: polled from vendor, 1: changed through domain0, 2: drift found by polling, 3: version before a change
+	Version  uint           // starts from 1 for each domain, only for source 3
+	UserId   uint           // who made the change, only for source 3
+	Records  string         // json string of []dns.Record
+}
+
+const (
+	SnapshotPolled SnapshotSource = iota
+	SnapshotManaged
+	SnapshotDrift
+	SnapshotVersion
+)
@@ -2,6 +2,7 @@ package models
 
 import (
 	"database/sql"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -16,6 +17,22 @@ type User struct {
 	Name     string
 	Role     UserRole  `gorm:"default:0"`
 	Domains  []*Domain `gorm:"many2many:user_domains;"`
+	// jwt issued before it are invalid, set on security relevant changes, e.g. password or role
+	TokenValidAfter *time.Time `json:"-"`
+}
+
+// RefreshToken gets a new access token of its session, it is rotated on every refresh,
+// a rotated one being used again means it is stolen, then the whole session is revoked
+type RefreshToken struct {
+	gorm.Model
+	UserId    uint   `gorm:"index"`
+	Session   string `gorm:"index"`       // sid claim of the access tokens
+	TokenHash string `gorm:"uniqueIndex"` // sha256 of the token
+	ExpiresAt time.Time
+	RevokedAt *time.Time
+	Rotated   bool // revoked by rotation, being used again means it is stolen
+	IP        string
+	UserAgent string
 }
 
 func (u *User) GetStuId() *string {
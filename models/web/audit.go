@@ -0,0 +1,22 @@
+package web
+
+import "domain0/models"
+
+type AuditQuery struct {
+	DomainId uint   `query:"domain_id"`
+	UserId   uint   `query:"user_id"`
+	RecordId string `query:"record_id"`
+	Action   string `query:"action"` // substring match
+	Result   *bool  `query:"result"`
+	From     string `query:"from"` // RFC 3339
+	To       string `query:"to"`   // RFC 3339
+	Page     int    `query:"page"` // start from 1
+	PageSize int    `query:"page_size"`
+}
+
+type AuditPage struct {
+	Total    int64               `json:"total"`
+	Page     int                 `json:"page"`
+	PageSize int                 `json:"page_size"`
+	Events   []models.AuditEvent `json:"events"`
+}
@@ -1,6 +1,11 @@
 package web
 
-import "domain0/models"
+import (
+	"domain0/models"
+	"domain0/modules"
+	md "domain0/modules/dns"
+	"time"
+)
 
 type Domain struct {
 	Status int         `json:"status"`
@@ -13,6 +18,7 @@ type DomainInfoUpdate struct {
 	ApiId     *string `json:"api_id"`
 	ApiSecret *string `json:"api_secret"`
 	Vendor    *string `json:"vendor"`
+	Endpoint  *string `json:"endpoint"` // optional, see Provider.Credentials
 	ICPReg    *uint   `json:"ICP_reg"`
 	Privacy   *bool   `json:"privacy"`
 }
@@ -30,3 +36,58 @@ type DomainUserDetail struct {
 	DomainId   int                   `json:"domain_id"`
 	DomainName string                `json:"domain_name"`
 }
+
+type DnsRecordPage struct {
+	Total    int         `json:"total"`
+	Page     int         `json:"page"`
+	PageSize int         `json:"page_size"`
+	Records  []md.Record `json:"records"`
+}
+
+type DnsSnapshot struct {
+	Id        uint                  `json:"id"`
+	Source    models.SnapshotSource `json:"source"`
+	Version   uint                  `json:"version"`
+	UserId    uint                  `json:"user_id"`
+	CreatedAt time.Time             `json:"created_at"`
+	Records   []md.Record           `json:"records,omitempty"`
+}
+
+type AccessRequest struct {
+	UserId int                   `json:"user_id"` // user to revoke, ignored by grant which is for the requester
+	Role   models.UserDomainRole `json:"role"`    // role to grant, ignored by revoke
+	Reason string                `json:"reason"`
+}
+
+// DnsDryRun is what a dns mutation would do, responded instead of changing records if dry_run is set
+type DnsDryRun struct {
+	DryRun   bool                 `json:"dry_run"`
+	Approval bool                 `json:"approval"` // the change would be sent to owner for approval
+	Changes  []modules.RecordDiff `json:"changes"`
+}
+
+// DdnsToken is a ddns token of a record, the token is only responded on creation
+type DdnsToken struct {
+	Token string `json:"token,omitempty"`
+	models.DdnsToken
+}
+
+type DdnsTokenCreate struct {
+	Description string `json:"description"`
+}
+
+type AcmeCredentialCreate struct {
+	Name        string `json:"name"` // relative to the domain, "@" for the apex
+	Description string `json:"description"`
+}
+
+// AcmeCredential is the account of acme-dns register response, it is also the basic auth of lego
+// httpreq, password is only responded on creation
+type AcmeCredential struct {
+	Username   string                `json:"username"`
+	Password   string                `json:"password"`
+	FullDomain string                `json:"fulldomain"`
+	Subdomain  string                `json:"subdomain"`
+	AllowFrom  []string              `json:"allowfrom"`
+	Credential models.AcmeCredential `json:"credential"`
+}
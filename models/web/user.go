@@ -15,3 +15,41 @@ type UserInfoUpdate struct {
 	Name     *string          `json:"name,omitempty"`
 	Role     *models.UserRole `json:"role,omitempty"`
 }
+
+type AccessTokenCreate struct {
+	Name      string   `json:"name"`
+	Scopes    []string `json:"scopes"`     // read, dns:write or admin
+	Domains   []uint   `json:"domains"`    // domain ids the token is allowed to, empty for all
+	ExpiresIn int      `json:"expires_in"` // days until expiry, 0 for never
+}
+
+// AccessToken is the created personal access token, the token is only responded on creation
+type AccessToken struct {
+	Token string `json:"token"`
+	models.AccessToken
+}
+
+// UserToken is the response of login, data is the access token for compatibility
+type UserToken struct {
+	Status       int    `json:"status,omitempty"`
+	Errors       string `json:"error,omitempty"`
+	Data         string `json:"data,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	ExpiresIn    int    `json:"expires_in,omitempty"` // seconds the access token is valid
+}
+
+// Jwk is a public key of RFC 7517 to verify jwt signed by domain0
+type Jwk struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	Crv string `json:"crv,omitempty"` // OKP
+	X   string `json:"x,omitempty"`   // OKP
+	N   string `json:"n,omitempty"`   // RSA
+	E   string `json:"e,omitempty"`   // RSA
+}
+
+type Jwks struct {
+	Keys []Jwk `json:"keys"`
+}
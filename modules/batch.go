@@ -0,0 +1,267 @@
+package modules
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"domain0/models"
+	md "domain0/modules/dns"
+)
+
+const (
+	BatchCreate = "create"
+	BatchUpdate = "update"
+	BatchDelete = "delete"
+)
+
+// BatchOp is an operation of a batch request
+type BatchOp struct {
+	Op     string          `json:"op"`     // create, update or delete
+	Id     string          `json:"id"`     // record to update or delete
+	Record json.RawMessage `json:"record"` // record to create, or fields to update, absent fields are kept
+}
+
+// BatchStep is an operation resolved against the live records, Before is nil for create
+// and After is nil for delete
+type BatchStep struct {
+	Op     string     `json:"op"`
+	Before *md.Record `json:"before,omitempty"`
+	After  *md.Record `json:"after,omitempty"`
+}
+
+// Batch is the steps applied in order, and rolled back all if any of them fails
+type Batch struct {
+	Steps []BatchStep `json:"steps"`
+}
+
+// BatchChangeStruct is the operation of a DomainChange applying a batch
+type BatchChangeStruct struct {
+	Batch  Batch         `json:"batch"`
+	Domain models.Domain `json:"domain"`
+}
+
+// BatchError tells which step of the batch failed and how the applied steps are rolled back
+type BatchError struct {
+	Step           int      // index of the failed step
+	Err            error    // error of the failed step
+	RollbackErrors []string // steps failed to roll back, the zone is left half changed if not empty
+}
+
+func (e *BatchError) Error() string {
+	msg := fmt.Sprintf("operation %d: %v", e.Step, e.Err)
+	if len(e.RollbackErrors) == 0 {
+		return msg + ", applied operations are rolled back"
+	}
+	return msg + ", rollback failed: " + strings.Join(e.RollbackErrors, "; ")
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// PrepareBatch validates all operations, resolves them against the live records of domain d
+// and checks the conflicts with the zone, nothing is changed
+func PrepareBatch(d *models.Domain, ops []BatchOp) (*Batch, error) {
+	if len(ops) == 0 {
+		return nil, errors.New("no operation")
+	}
+	batch := &Batch{}
+	ids := make(map[string]int, len(ops))
+	for i, op := range ops {
+		// a record changed twice would be addressed by a stale id after the first change
+		if op.Op != BatchCreate && op.Id != "" {
+			if j, ok := ids[op.Id]; ok {
+				return nil, fmt.Errorf("operation %d: record %s is already changed by operation %d", i, op.Id, j)
+			}
+			ids[op.Id] = i
+		}
+		step, err := prepareStep(d, op)
+		if err != nil {
+			return nil, PrefixFieldErrors(err, fmt.Sprintf("%d.record.", i))
+		}
+		batch.Steps = append(batch.Steps, step)
+	}
+
+	// records left by the batch, deleted ones are not in the zone any more
+	var records []md.Record
+	var index []int
+	for i, step := range batch.Steps {
+		if step.After != nil {
+			records = append(records, *step.After)
+			index = append(index, i)
+		}
+	}
+	verr := &ValidationError{}
+	for _, i := range nonExclusiveCNAMEs(d, records) {
+		verr.add(fmt.Sprintf("%d.record.type", index[i]), "CNAME must be the only record at %s", records[i].Name)
+	}
+	if err := verr.orNil(); err != nil {
+		return nil, err
+	}
+	if err := CheckZoneConflicts(d, batch); err != nil {
+		return nil, err
+	}
+	return batch, nil
+}
+
+func prepareStep(d *models.Domain, op BatchOp) (BatchStep, error) {
+	step := BatchStep{Op: op.Op}
+	if op.Op == BatchCreate {
+		var r md.Record
+		if err := json.Unmarshal(op.Record, &r); err != nil {
+			return step, err
+		}
+		r.Id = ""
+		r.Name = md.RelativeName(r.Name, d.Name)
+		if _, err := DnsObjFromRecord(d, r); err != nil {
+			return step, err
+		}
+		step.After = &r
+		return step, nil
+	}
+	if op.Op != BatchUpdate && op.Op != BatchDelete {
+		return step, fmt.Errorf("unknown op %q", op.Op)
+	}
+
+	if op.Id == "" {
+		return step, errors.New("record id is required")
+	}
+	dnsObj, err := DnsObjGen(d)
+	if err != nil {
+		return step, err
+	}
+	if err := dnsObj.Get(op.Id); err != nil {
+		return step, fmt.Errorf("get record %s: %w", op.Id, err)
+	}
+	before := dnsObj.ToRecord()
+	step.Before = &before
+	if op.Op == BatchDelete {
+		return step, nil
+	}
+
+	after := before
+	if err := json.Unmarshal(op.Record, &after); err != nil {
+		return step, err
+	}
+	after.Id = op.Id
+	after.Name = md.RelativeName(after.Name, d.Name)
+	if _, err := DnsObjFromRecord(d, after); err != nil {
+		return step, err
+	}
+	step.After = &after
+	return step, nil
+}
+
+// ApplyBatch applies the steps of batch to domain d in order and returns them with ids of created
+// records, if a step fails the applied ones are rolled back in reverse order with their prior state,
+// and a *BatchError is returned
+func ApplyBatch(d *models.Domain, batch *Batch) (*Batch, error) {
+	applied := make([]BatchStep, 0, len(batch.Steps))
+	for i, step := range batch.Steps {
+		done, err := applyStep(d, step)
+		if err == nil {
+			applied = append(applied, done)
+			continue
+		}
+
+		batchErr := &BatchError{Step: i, Err: err}
+		for j := len(applied) - 1; j >= 0; j-- {
+			if _, err := applyStep(d, applied[j].reverse()); err != nil {
+				batchErr.RollbackErrors = append(batchErr.RollbackErrors, fmt.Sprintf("operation %d: %v", j, err))
+			}
+		}
+		return nil, batchErr
+	}
+	return &Batch{Steps: applied}, nil
+}
+
+// applyStep applies the step, the returned one has the id of created or updated record
+func applyStep(d *models.Domain, step BatchStep) (BatchStep, error) {
+	switch step.Op {
+	case BatchCreate:
+		dnsObj, err := DnsObjFromRecord(d, *step.After)
+		if err != nil {
+			return step, err
+		}
+		if err := dnsObj.Create(); err != nil {
+			return step, fmt.Errorf("create %s %s: %w", step.After.Name, step.After.Type, err)
+		}
+		created := dnsObj.ToRecord()
+		step.After = &created
+	case BatchUpdate:
+		dnsObj, err := DnsObjFromRecord(d, *step.After)
+		if err != nil {
+			return step, err
+		}
+		if err := dnsObj.Update(); err != nil {
+			return step, fmt.Errorf("update %s %s: %w", step.After.Name, step.After.Type, err)
+		}
+		// some vendors derive the id from the content, so it changes with the update
+		updated := dnsObj.ToRecord()
+		step.After = &updated
+	case BatchDelete:
+		dnsObj, err := DnsObjGen(d)
+		if err != nil {
+			return step, err
+		}
+		if err := dnsObj.Get(step.Before.Id); err != nil {
+			return step, fmt.Errorf("delete %s %s: %w", step.Before.Name, step.Before.Type, err)
+		}
+		if err := dnsObj.Delete(); err != nil {
+			return step, fmt.Errorf("delete %s %s: %w", step.Before.Name, step.Before.Type, err)
+		}
+	default:
+		return step, fmt.Errorf("unknown op %q", step.Op)
+	}
+	return step, nil
+}
+
+// reverse is the step undoing an applied one, a deleted record is created again with a new id,
+// and an updated one is restored under the id it has after the update
+func (s BatchStep) reverse() BatchStep {
+	switch s.Op {
+	case BatchCreate:
+		return BatchStep{Op: BatchDelete, Before: s.After}
+	case BatchDelete:
+		before := *s.Before
+		before.Id = ""
+		return BatchStep{Op: BatchCreate, After: &before}
+	}
+	before := *s.Before
+	before.Id = s.After.Id
+	return BatchStep{Op: BatchUpdate, Before: s.After, After: &before}
+}
+
+// Plan lists the steps of batch as a plan, for diff and review
+func (b *Batch) Plan() Plan {
+	var plan Plan
+	for _, step := range b.Steps {
+		switch step.Op {
+		case BatchCreate:
+			plan.Creates = append(plan.Creates, *step.After)
+		case BatchUpdate:
+			plan.Updates = append(plan.Updates, PlanUpdate{Before: *step.Before, After: *step.After})
+		case BatchDelete:
+			plan.Deletes = append(plan.Deletes, *step.Before)
+		}
+	}
+	return plan
+}
+
+// Batch lists the changes of plan as batch steps in the order ApplyPlan applies them,
+// so the plan can be checked against the zone like a batch
+func (p *Plan) Batch() *Batch {
+	batch := &Batch{}
+	for i := range p.Deletes {
+		batch.Steps = append(batch.Steps, BatchStep{Op: BatchDelete, Before: &p.Deletes[i]})
+	}
+	for i := range p.Updates {
+		batch.Steps = append(batch.Steps, BatchStep{Op: BatchUpdate, Before: &p.Updates[i].Before, After: &p.Updates[i].After})
+	}
+	for i := range p.Creates {
+		batch.Steps = append(batch.Steps, BatchStep{Op: BatchCreate, After: &p.Creates[i]})
+	}
+	return batch
+}
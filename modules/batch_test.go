@@ -0,0 +1,80 @@
+package modules
+
+import (
+	"reflect"
+	"testing"
+
+	md "domain0/modules/dns"
+)
+
+func TestBatchStepReverse(t *testing.T) {
+	old := md.Record{Id: "a1", Name: "www", Type: "A", Content: "192.0.2.1", TTL: 600}
+	updated := md.Record{Id: "a2", Name: "www", Type: "A", Content: "192.0.2.2", TTL: 600}
+	restored := old
+	restored.Id = "a2"
+	recreated := old
+	recreated.Id = ""
+
+	tests := []struct {
+		name string
+		step BatchStep
+		want BatchStep
+	}{
+		{
+			"create is deleted",
+			BatchStep{Op: BatchCreate, After: &old},
+			BatchStep{Op: BatchDelete, Before: &old},
+		},
+		{
+			"delete is created again without id",
+			BatchStep{Op: BatchDelete, Before: &old},
+			BatchStep{Op: BatchCreate, After: &recreated},
+		},
+		{
+			"update is restored under the id after update",
+			BatchStep{Op: BatchUpdate, Before: &old, After: &updated},
+			BatchStep{Op: BatchUpdate, Before: &updated, After: &restored},
+		},
+		{
+			"update keeping the id",
+			BatchStep{Op: BatchUpdate, Before: &old, After: &md.Record{Id: "a1", Name: "www", Type: "A", Content: "192.0.2.2"}},
+			BatchStep{Op: BatchUpdate, Before: &md.Record{Id: "a1", Name: "www", Type: "A", Content: "192.0.2.2"}, After: &old},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.step.reverse(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("reverse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBatchStepReverseKeepsStep(t *testing.T) {
+	old := md.Record{Id: "a1", Name: "www", Type: "A", Content: "192.0.2.1"}
+	updated := md.Record{Id: "a2", Name: "www", Type: "A", Content: "192.0.2.2"}
+	step := BatchStep{Op: BatchUpdate, Before: &old, After: &updated}
+	step.reverse()
+	if old.Id != "a1" || updated.Id != "a2" {
+		t.Errorf("reverse() changed the step, before %s, after %s", old.Id, updated.Id)
+	}
+}
+
+func TestPlanBatch(t *testing.T) {
+	a := md.Record{Id: "a1", Name: "www", Type: "A", Content: "192.0.2.1"}
+	b := md.Record{Id: "a2", Name: "www", Type: "A", Content: "192.0.2.2"}
+	c := md.Record{Name: "www", Type: "CNAME", Content: "example.net"}
+	plan := Plan{
+		Creates: []md.Record{c},
+		Updates: []PlanUpdate{{Before: a, After: b}},
+		Deletes: []md.Record{a},
+	}
+	want := []BatchStep{
+		{Op: BatchDelete, Before: &a},
+		{Op: BatchUpdate, Before: &a, After: &b},
+		{Op: BatchCreate, After: &c},
+	}
+	if got := plan.Batch().Steps; !reflect.DeepEqual(got, want) {
+		t.Errorf("Batch() = %+v, want %+v", got, want)
+	}
+}
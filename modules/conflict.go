@@ -0,0 +1,319 @@
+package modules
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"domain0/models"
+	md "domain0/modules/dns"
+)
+
+// FieldDiff is a field of a record in the three states of a domain change, nil if the record is absent
+type FieldDiff struct {
+	Field    string      `json:"field"`
+	Base     interface{} `json:"base"`
+	Live     interface{} `json:"live"`
+	Proposed interface{} `json:"proposed"`
+}
+
+// RecordDiff is the three-way diff of a record touched by a domain change: the state when the change
+// is submitted, the live state and the proposed state, nil for absent
+type RecordDiff struct {
+	Id       string      `json:"id"`
+	Base     *md.Record  `json:"base"`
+	Live     *md.Record  `json:"live"`
+	Proposed *md.Record  `json:"proposed"`
+	Fields   []FieldDiff `json:"fields"`   // fields not the same in all states
+	Conflict bool        `json:"conflict"` // the record is changed or deleted since submitted
+}
+
+const extFieldPrefix = "extensions."
+
+var diffFields = []string{"name", "type", "content", "ttl", "priority", "comment"}
+
+// NewRecordDiff compares the states of a record, base is nil for a new record
+// and the conflict is not checked
+func NewRecordDiff(base, live, proposed *md.Record) (RecordDiff, error) {
+	diff := RecordDiff{Base: base, Live: live, Proposed: proposed}
+	for _, r := range []*md.Record{proposed, live, base} {
+		if r != nil {
+			diff.Id = r.Id
+		}
+	}
+
+	states := make([]map[string]interface{}, 3)
+	for i, r := range []*md.Record{base, live, proposed} {
+		fields, err := recordFields(r)
+		if err != nil {
+			return diff, err
+		}
+		states[i] = fields
+	}
+
+	keys := append([]string{}, diffFields...)
+	var extKeys []string
+	for _, fields := range states {
+		for key := range fields {
+			if strings.HasPrefix(key, extFieldPrefix) {
+				extKeys = append(extKeys, key)
+			}
+		}
+	}
+	sort.Strings(extKeys)
+	for i, key := range extKeys {
+		if i == 0 || key != extKeys[i-1] {
+			keys = append(keys, key)
+		}
+	}
+
+	for _, key := range keys {
+		d := FieldDiff{Field: key, Base: states[0][key], Live: states[1][key], Proposed: states[2][key]}
+		if !reflect.DeepEqual(d.Base, d.Live) || !reflect.DeepEqual(d.Live, d.Proposed) {
+			diff.Fields = append(diff.Fields, d)
+		}
+	}
+	if base != nil {
+		diff.Conflict = live == nil || !reflect.DeepEqual(states[0], states[1])
+	}
+	return diff, nil
+}
+
+// recordFields flattens the record the same way it's stored in json, so a record read from vendor
+// and one read from database are comparable
+func recordFields(r *md.Record) (map[string]interface{}, error) {
+	if r == nil {
+		return map[string]interface{}{}, nil
+	}
+	b, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return nil, err
+	}
+	delete(fields, "id")
+	if ext, ok := fields["extensions"].(map[string]interface{}); ok {
+		for key, val := range ext {
+			fields[extFieldPrefix+key] = val
+		}
+	}
+	delete(fields, "extensions")
+	return fields, nil
+}
+
+// liveRecord gets the record by id from vendor, nil if it's deleted
+func liveRecord(d *models.Domain, id string) (*md.Record, error) {
+	dnsObj, err := DnsObjGen(d)
+	if err != nil {
+		return nil, err
+	}
+	err = dnsObj.Get(id)
+	if err == nil {
+		r := dnsObj.ToRecord()
+		return &r, nil
+	}
+
+	// vendors don't tell not found from other errors, find it in the list
+	live, listErr := DnsRecordList(d)
+	if listErr != nil {
+		return nil, fmt.Errorf("get record %s error: %v", id, err)
+	}
+	for i := range live {
+		if live[i].Id == id {
+			return &live[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// Diff compares the record of the change with the live one, proposed is nil if the record is deleted
+func (dcs *DnsChangeStruct) Diff(d *models.Domain, deleting bool) ([]RecordDiff, error) {
+	var live *md.Record
+	if dcs.Dns.Id != "" {
+		var err error
+		if live, err = liveRecord(d, dcs.Dns.Id); err != nil {
+			return nil, err
+		}
+	}
+	proposed := &dcs.Dns
+	if deleting {
+		proposed = nil
+	}
+	diff, err := NewRecordDiff(dcs.Base, live, proposed)
+	if err != nil {
+		return nil, err
+	}
+	return []RecordDiff{diff}, nil
+}
+
+// Diff compares the records the plan updates and deletes with the live ones, creates have no base
+func (scs *SyncChangeStruct) Diff(d *models.Domain) ([]RecordDiff, error) {
+	live, err := DnsRecordList(d)
+	if err != nil {
+		return nil, err
+	}
+	byId := map[string]*md.Record{}
+	for i := range live {
+		byId[live[i].Id] = &live[i]
+	}
+	return scs.Plan.diff(byId)
+}
+
+// Preview lists the changes of a plan just computed against the live records, the records it
+// updates and deletes are taken as live ones without reading them from vendor again
+func (p *Plan) Preview() ([]RecordDiff, error) {
+	byId := map[string]*md.Record{}
+	for i := range p.Updates {
+		byId[p.Updates[i].Before.Id] = &p.Updates[i].Before
+	}
+	for i := range p.Deletes {
+		byId[p.Deletes[i].Id] = &p.Deletes[i]
+	}
+	return p.diff(byId)
+}
+
+// diff compares the records the plan touches with live ones indexed by id
+func (p *Plan) diff(live map[string]*md.Record) ([]RecordDiff, error) {
+	var diffs []RecordDiff
+	add := func(base, proposed *md.Record) error {
+		var l *md.Record
+		if base != nil {
+			l = live[base.Id]
+		}
+		diff, err := NewRecordDiff(base, l, proposed)
+		if err != nil {
+			return err
+		}
+		diffs = append(diffs, diff)
+		return nil
+	}
+	for i := range p.Creates {
+		if err := add(nil, &p.Creates[i]); err != nil {
+			return nil, err
+		}
+	}
+	for i := range p.Updates {
+		if err := add(&p.Updates[i].Before, &p.Updates[i].After); err != nil {
+			return nil, err
+		}
+	}
+	for i := range p.Deletes {
+		if err := add(&p.Deletes[i], nil); err != nil {
+			return nil, err
+		}
+	}
+	return diffs, nil
+}
+
+// Diff compares the records the batch updates and deletes with the live ones, like a plan
+func (bcs *BatchChangeStruct) Diff(d *models.Domain) ([]RecordDiff, error) {
+	scs := SyncChangeStruct{Plan: bcs.Batch.Plan(), Domain: bcs.Domain}
+	return scs.Diff(d)
+}
+
+// HasConflict reports whether any record of the diffs is changed since submitted
+func HasConflict(diffs []RecordDiff) bool {
+	for i := range diffs {
+		if diffs[i].Conflict {
+			return true
+		}
+	}
+	return false
+}
+
+// ZoneConflictError is the records conflicting with the live zone, found before the vendor api is called
+type ZoneConflictError struct {
+	Fields []FieldError
+}
+
+func (e *ZoneConflictError) Error() string {
+	msgs := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		msgs = append(msgs, f.Field+": "+f.Message)
+	}
+	return "record conflicts with the zone, " + strings.Join(msgs, "; ")
+}
+
+// CheckZoneConflicts loads the live zone of domain d and replays the steps of batch on it in order,
+// a create or update is rejected if it puts a CNAME at a name with other records, other records at
+// a name with CNAME, or duplicates a record, so all vendors behave the same
+func CheckZoneConflicts(d *models.Domain, batch *Batch) error {
+	live, err := DnsRecordList(d)
+	if err != nil {
+		return err
+	}
+	zone := UserRecords(live)
+
+	conflict := &ZoneConflictError{}
+	prefix := func(i int) string {
+		if len(batch.Steps) == 1 {
+			return ""
+		}
+		return fmt.Sprintf("%d.record.", i)
+	}
+	for i, step := range batch.Steps {
+		// the record being updated or deleted leaves the zone first
+		if step.Op != BatchCreate {
+			id := step.After
+			if id == nil {
+				id = step.Before
+			}
+			zone = withoutRecord(zone, id.Id)
+		}
+		if step.After == nil {
+			continue
+		}
+		if field, msg := zoneConflict(d, zone, step.After); field != "" {
+			conflict.Fields = append(conflict.Fields, FieldError{Field: prefix(i) + field, Message: msg})
+		}
+		zone = append(zone, *step.After)
+	}
+	if len(conflict.Fields) > 0 {
+		return conflict
+	}
+	return nil
+}
+
+// CheckRecordConflicts checks a record to create, or to update if it has id, like CheckZoneConflicts
+func CheckRecordConflicts(d *models.Domain, r *md.Record) error {
+	step := BatchStep{Op: BatchCreate, After: r}
+	if r.Id != "" {
+		step.Op = BatchUpdate
+	}
+	return CheckZoneConflicts(d, &Batch{Steps: []BatchStep{step}})
+}
+
+func withoutRecord(zone []md.Record, id string) []md.Record {
+	res := make([]md.Record, 0, len(zone))
+	for i := range zone {
+		if zone[i].Id != id {
+			res = append(res, zone[i])
+		}
+	}
+	return res
+}
+
+// zoneConflict returns the conflicting field of record r and why, empty if there is no conflict
+func zoneConflict(d *models.Domain, zone []md.Record, r *md.Record) (string, string) {
+	name := strings.ToLower(md.RelativeName(r.Name, d.Name))
+	isCNAME := strings.EqualFold(r.Type, "CNAME")
+	for i := range zone {
+		z := &zone[i]
+		if strings.ToLower(md.RelativeName(z.Name, d.Name)) != name {
+			continue
+		}
+		switch {
+		case z.SameAs(r):
+			return "content", fmt.Sprintf("the same %s record exists at %s already", z.Type, r.Name)
+		case isCNAME:
+			return "type", fmt.Sprintf("CNAME must be the only record at %s, there is %s record", r.Name, z.Type)
+		case strings.EqualFold(z.Type, "CNAME"):
+			return "type", fmt.Sprintf("%s has CNAME record, no other record is allowed", r.Name)
+		}
+	}
+	return "", ""
+}
@@ -2,74 +2,117 @@ package modules
 
 import (
 	"domain0/models"
-	. "domain0/modules/dns"
+	md "domain0/modules/dns"
+	"encoding/json"
+	"errors"
 )
 
-type DnsObj interface {
-	Create() error
-	Get(id string) error
-	Update() error
-	Delete() error
-}
+type DnsObj = md.DnsObj
 
-type DnsObjList interface {
-	GetDNSList(d *models.Domain) error
-	MultipleSelectWithIds(ids []string, r *[]interface{}) error
-}
+type DnsObjList = md.DnsObjList
+
+var ErrUnknownVendor = errors.New("unknown dns vendor")
 
+// DnsChangeStruct is the operation of a DomainChange on dns records
 type DnsChangeStruct struct {
-	Dns    DnsObj        `json:"dns"`
+	Dns    md.Record     `json:"dns"`
+	Base   *md.Record    `json:"base,omitempty"` // the record when the change is submitted, nil for create
 	Domain models.Domain `json:"domain"`
 }
 
-func DnsObjGen(d *models.Domain) DnsObj {
-	if d.Vendor == "cloudflare" {
-		return &CloudflareDNS{Domain: *d}
-	}
+// dnsChangeFormat marks operations with vendor neutral dns, the ones stored without it have the
+// vendor object as dns
+const dnsChangeFormat = 1
 
-	if d.Vendor == "dnspod" {
-		return &TencentDNS{Domain: *d}
-	}
+type dnsChangeJson struct {
+	Format int             `json:"format"`
+	Dns    json.RawMessage `json:"dns"`
+	Base   *md.Record      `json:"base,omitempty"`
+	Domain models.Domain   `json:"domain"`
+}
 
-	if d.Vendor == "aliyun" {
-		return &AliDNS{Domain: *d}
+func (dcs DnsChangeStruct) MarshalJSON() ([]byte, error) {
+	dns, err := json.Marshal(dcs.Dns)
+	if err != nil {
+		return nil, err
 	}
+	return json.Marshal(dnsChangeJson{Format: dnsChangeFormat, Dns: dns, Base: dcs.Base, Domain: dcs.Domain})
+}
 
-	if d.Vendor == "huawei" {
-		return &HuaweiDNS{Domain: *d}
+// UnmarshalJSON decodes operations of both formats, so changes submitted before the upgrade can
+// still be reviewed
+func (dcs *DnsChangeStruct) UnmarshalJSON(b []byte) error {
+	var raw dnsChangeJson
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	dcs.Base, dcs.Domain = raw.Base, raw.Domain
+	if raw.Format >= dnsChangeFormat {
+		return json.Unmarshal(raw.Dns, &dcs.Dns)
 	}
 
+	p, ok := md.LookupProvider(raw.Domain.Vendor)
+	if !ok {
+		return ErrUnknownVendor
+	}
+	obj := p.NewObj(raw.Domain)
+	if err := json.Unmarshal(raw.Dns, obj); err != nil {
+		return err
+	}
+	dcs.Dns = obj.ToRecord()
 	return nil
 }
 
-func DnsListObjGen(d *models.Domain) DnsObjList {
-	if d.Vendor == "cloudflare" {
-		return &CloudflareDNSList{}
+func DnsObjGen(d *models.Domain) (DnsObj, error) {
+	p, ok := md.LookupProvider(d.Vendor)
+	if !ok {
+		return nil, ErrUnknownVendor
 	}
+	return &trackedObj{DnsObj: p.NewObj(*d), domain: *d}, nil
+}
 
-	if d.Vendor == "dnspod" {
-		return &TencentDNSList{}
+func DnsListObjGen(d *models.Domain) (DnsObjList, error) {
+	p, ok := md.LookupProvider(d.Vendor)
+	if !ok {
+		return nil, ErrUnknownVendor
 	}
+	return p.NewObjList(), nil
+}
 
-	if d.Vendor == "aliyun" {
-		return &AliDNSList{}
+// DnsObjFromRecord validates record r and generates the vendor dns object of domain d filled with it
+func DnsObjFromRecord(d *models.Domain, r md.Record) (DnsObj, error) {
+	p, ok := md.LookupProvider(d.Vendor)
+	if !ok {
+		return nil, ErrUnknownVendor
 	}
-
-	if d.Vendor == "huawei" {
-		return &HuaweiDNSList{}
+	if err := ValidateRecord(d, &r); err != nil {
+		return nil, err
 	}
-	return nil
+	obj := p.NewObj(*d)
+	obj.FromRecord(r)
+	return &trackedObj{DnsObj: obj, domain: *d}, nil
 }
 
-func (dcs *DnsChangeStruct) DnsChangeRestore() error {
-	if dcs.Domain.Vendor == "cloudflare" {
-		dcs.Dns.(*CloudflareDNS).Domain = dcs.Domain
-	} else if dcs.Domain.Vendor == "dnspod" {
-		dcs.Dns.(*TencentDNS).Domain = dcs.Domain
-	} else if dcs.Domain.Vendor == "aliyun" {
-		dcs.Dns.(*AliDNS).Domain = dcs.Domain
-	} else if dcs.Domain.Vendor == "huawei" {
-		dcs.Dns.(*HuaweiDNS).Domain = dcs.Domain
+// DnsRecordList gets all dns records of domain d in vendor neutral shape
+func DnsRecordList(d *models.Domain) ([]md.Record, error) {
+	dnsList, err := DnsListObjGen(d)
+	if err != nil {
+		return nil, err
 	}
-	return nil
+	if err := dnsList.GetDNSList(d); err != nil {
+		return nil, err
+	}
+	return dnsList.Records()
+}
+
+// DnsChangeRestore rebuilds the dns object of the change, d must be loaded from database
+// because api secrets are not serialized into the change
+func (dcs *DnsChangeStruct) DnsChangeRestore(d *models.Domain) (DnsObj, error) {
+	return DnsObjFromRecord(d, dcs.Dns)
+}
+
+// VendorSupported reports whether there is a provider registered for the vendor
+func VendorSupported(vendor string) bool {
+	_, ok := md.LookupProvider(vendor)
+	return ok
 }
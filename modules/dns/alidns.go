@@ -4,6 +4,7 @@ import (
 	"domain0/models"
 	"domain0/utils"
 	"errors"
+	"strings"
 
 	aliErrors "github.com/aliyun/alibaba-cloud-sdk-go/sdk/errors"
 	"github.com/aliyun/alibaba-cloud-sdk-go/sdk/requests"
@@ -11,6 +12,9 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// max page size of DescribeDomainRecords
+const aliListPageSize = 500
+
 type AliDNSCustom struct {
 	Status string `json:"status"`
 	Line   string `json:"line"`
@@ -36,6 +40,98 @@ type AliDNSList struct {
 	Result   []AliDNS      `json:"result"`
 }
 
+func init() {
+	RegisterProvider(Provider{
+		Name:        "aliyun",
+		DisplayName: "Aliyun DNS",
+		Capabilities: Capabilities{
+			RecordTypes: []string{"A", "AAAA", "CNAME", "MX", "TXT", "NS", "SRV", "CAA"},
+			Proxy:       false,
+			Lines:       true,
+			Comments:    true,
+			MinTTL:      1,
+			MaxTTL:      86400,
+			Extensions: []ExtensionSpec{
+				{Key: ExtLine, Type: ExtString},
+				{Key: ExtEnabled, Type: ExtBool},
+			},
+		},
+		Credentials: []CredentialField{
+			{Field: "api_id", Label: "AccessKey ID", Secret: false},
+			{Field: "api_secret", Label: "AccessKey Secret", Secret: true},
+		},
+		NewObj:     func(d models.Domain) DnsObj { return &AliDNS{Domain: d} },
+		NewObjList: func() DnsObjList { return &AliDNSList{} },
+	})
+}
+
+func (a *AliDNS) ToRecord() Record {
+	ext := Extensions{ExtEnabled: a.enabled()}
+	if a.Custom.Line != "" {
+		ext[ExtLine] = a.Custom.Line
+	}
+	return Record{
+		Id:         a.Id,
+		Name:       RelativeName(a.Name, a.Domain.Name),
+		Type:       a.Type,
+		Content:    a.Content,
+		TTL:        int(a.TTL),
+		Priority:   uint16(a.Priority),
+		Comment:    a.Commnet,
+		Extensions: ext,
+	}
+}
+
+func (a *AliDNS) FromRecord(r Record) {
+	a.Id = r.Id
+	a.Name = RelativeName(r.Name, a.Domain.Name)
+	a.Type = r.Type
+	a.Content = r.Content
+	a.TTL = int64(r.TTL)
+	a.Priority = int64(r.Priority)
+	a.Commnet = r.Comment
+	a.Custom = AliDNSCustom{
+		Status: utils.IfThen(r.Extensions.Bool(ExtEnabled, true), "ENABLE", "DISABLE"),
+		Line:   r.Extensions.String(ExtLine, ""),
+	}
+}
+
+// enabled reports whether the record is resolved, new records are enabled
+func (a *AliDNS) enabled() bool {
+	return !strings.EqualFold(a.Custom.Status, "disable")
+}
+
+// setStatus enables or disables the record, AddDomainRecord and UpdateDomainRecord don't take the status
+func (a *AliDNS) setStatus(client *alidns.Client) error {
+	request := alidns.CreateSetDomainRecordStatusRequest()
+	request.Scheme = "https"
+	request.RecordId = a.Id
+	request.Status = utils.IfThen(a.enabled(), "Enable", "Disable")
+	_, err := client.SetDomainRecordStatus(request)
+	return err
+}
+
+// setRemark sets the comment of the record, an empty one clears it
+func (a *AliDNS) setRemark(client *alidns.Client) error {
+	request := alidns.CreateUpdateDomainRecordRemarkRequest()
+	request.Scheme = "https"
+	request.RecordId = a.Id
+	request.Remark = a.Commnet
+	_, err := client.UpdateDomainRecordRemark(request)
+	return err
+}
+
+// undoCreate deletes the record just added when it can't be set up as requested
+func (a *AliDNS) undoCreate(client *alidns.Client) {
+	request := alidns.CreateDeleteDomainRecordRequest()
+	request.Scheme = "https"
+	request.RecordId = a.Id
+	if _, err := client.DeleteDomainRecord(request); err != nil {
+		logrus.Warnf("delete record %s of domain %s error:%v", a.Id, a.Domain.Name, err)
+	}
+	a.Id = ""
+}
+
 func (a *AliDNS) Create() error {
 	// extract auth info
 	accessKeyId, accessKeySecret, err := a.Domain.ExtractAuth()
@@ -44,8 +140,7 @@ func (a *AliDNS) Create() error {
 	}
 
 	// logging info
-	logrus.Info("Create DNS record: ", a)
-	logrus.Debug("Auth with AccessKeyId: %s, AccessKeySecret: %s", accessKeyId, accessKeySecret)
+	logrus.Info("Create DNS record: ", a.ToRecord(), " of domain: ", a.Domain.Name)
 
 	// create dns record
 	client, err := alidns.NewClientWithAccessKey("cn-hangzhou", accessKeyId, accessKeySecret)
@@ -59,6 +154,7 @@ func (a *AliDNS) Create() error {
 	request.Type = a.Type
 	request.Value = a.Content
 	request.TTL = requests.NewInteger64(a.TTL)
+	request.Line = utils.IfThen(a.Custom.Line == "", "default", a.Custom.Line)
 	request.Priority = requests.NewInteger64(a.Priority)
 	res, err := client.AddDomainRecord(request)
 	if err != nil {
@@ -66,6 +162,18 @@ func (a *AliDNS) Create() error {
 	}
 
 	a.Id = res.RecordId
+	if !a.enabled() {
+		if err := a.setStatus(client); err != nil {
+			a.undoCreate(client)
+			return err
+		}
+	}
+	if a.Commnet != "" {
+		if err := a.setRemark(client); err != nil {
+			a.undoCreate(client)
+			return err
+		}
+	}
 	return nil
 }
 
@@ -95,13 +203,12 @@ func (a *AliDNS) Get(id string) error {
 	a.Name = res.RR
 	a.Type = res.Type
 	a.Content = res.Value
-	a.Commnet = "interface not support yet"
 	a.TTL = res.TTL
 	a.Priority = res.Priority
+	a.Custom = AliDNSCustom{Status: res.Status, Line: res.Line}
 
 	// logging info
-	logrus.Info("Get DNS record: ", a)
-	logrus.Debug("Auth with AccessKeyId: %s, AccessKeySecret: %s", accessKeyId, accessKeySecret)
+	logrus.Info("Get DNS record: ", a.ToRecord(), " of domain: ", a.Domain.Name)
 
 	return nil
 }
@@ -114,8 +221,7 @@ func (a *AliDNS) Delete() error {
 	}
 
 	// logging info
-	logrus.Info("Delete DNS record: ", a)
-	logrus.Debug("Auth with AccessKeyId: %s, AccessKeySecret: %s", accessKeyId, accessKeySecret)
+	logrus.Info("Delete DNS record: ", a.ToRecord(), " of domain: ", a.Domain.Name)
 
 	// delete dns record
 	client, err := alidns.NewClientWithAccessKey("cn-hangzhou", accessKeyId, accessKeySecret)
@@ -141,8 +247,7 @@ func (a *AliDNS) Update() error {
 	}
 
 	// logging info
-	logrus.Info("Update DNS record: ", a)
-	logrus.Debug("Auth with AccessKeyId: %s, AccessKeySecret: %s", accessKeyId, accessKeySecret)
+	logrus.Info("Update DNS record: ", a.ToRecord(), " of domain: ", a.Domain.Name)
 
 	// update dns record
 	client, err := alidns.NewClientWithAccessKey("cn-hangzhou", accessKeyId, accessKeySecret)
@@ -171,16 +276,11 @@ func (a *AliDNS) Update() error {
 		}
 	}
 
-	reamarkRequest := alidns.CreateUpdateDomainRecordRemarkRequest()
-
-	if a.Commnet == "" {
-		reamarkRequest.Scheme = "https"
-		reamarkRequest.RecordId = a.Id
-		reamarkRequest.Remark = a.Commnet
-		client.UpdateDomainRecordRemark(reamarkRequest) // ignore error
+	if err := a.setStatus(client); err != nil {
+		return err
 	}
 
-	return nil
+	return a.setRemark(client)
 }
 
 func (a *AliDNSList) MultipleSelectWithIds(ids []string, r *[]interface{}) error {
@@ -199,6 +299,17 @@ func (a *AliDNSList) MultipleSelectWithIds(ids []string, r *[]interface{}) error
 	return nil
 }
 
+func (a *AliDNSList) Records() ([]Record, error) {
+	if !a.Success {
+		return nil, listError(a.Errors)
+	}
+	res := make([]Record, 0, len(a.Result))
+	for i := range a.Result {
+		res = append(res, a.Result[i].ToRecord())
+	}
+	return res, nil
+}
+
 func (c *AliDNSList) GetDNSList(d *models.Domain) error {
 	// extract auth info
 	accessKeyId, accessKeySecret, err := d.ExtractAuth()
@@ -207,39 +318,44 @@ func (c *AliDNSList) GetDNSList(d *models.Domain) error {
 		return nil
 	}
 
-	// logging info
-	logrus.Debug("Auth with AccessKeyId: %s, AccessKeySecret: %s", accessKeyId, accessKeySecret)
-
 	// get dns list
 	client, err := alidns.NewClientWithAccessKey("cn-hangzhou", accessKeyId, accessKeySecret)
 	if err != nil {
 		c.Errors = []interface{}{err.Error()}
 		return nil
 	}
-	request := alidns.CreateDescribeDomainRecordsRequest()
-	request.Scheme = "https"
-	request.DomainName = d.Name
-	request.PageSize = requests.NewInteger(500)
-	response, err := client.DescribeDomainRecords(request)
-	if err != nil {
-		c.Errors = []interface{}{err.Error()}
-		return nil
-	}
+	for page := 1; ; page++ {
+		request := alidns.CreateDescribeDomainRecordsRequest()
+		request.Scheme = "https"
+		request.DomainName = d.Name
+		request.PageNumber = requests.NewInteger(page)
+		request.PageSize = requests.NewInteger(aliListPageSize)
+		response, err := client.DescribeDomainRecords(request)
+		if err != nil {
+			c.Errors = []interface{}{err.Error()}
+			c.Result = nil
+			return nil
+		}
 
-	// convert to AliDNSList
-	for _, record := range response.DomainRecords.Record {
-		c.Result = append(c.Result, AliDNS{
-			Id:      record.RecordId,
-			Type:    record.Type,
-			Name:    record.RR,
-			Content: record.Value,
-			TTL:     record.TTL,
-			// Data:     record.Data,
-			Priority: record.Priority,
-			Commnet:  record.Remark,
-			Custom:   AliDNSCustom{Status: record.Status, Line: record.Line},
-			Domain:   *d,
-		})
+		// convert to AliDNSList
+		for _, record := range response.DomainRecords.Record {
+			c.Result = append(c.Result, AliDNS{
+				Id:      record.RecordId,
+				Type:    record.Type,
+				Name:    record.RR,
+				Content: record.Value,
+				TTL:     record.TTL,
+				// Data:     record.Data,
+				Priority: record.Priority,
+				Commnet:  record.Remark,
+				Custom:   AliDNSCustom{Status: record.Status, Line: record.Line},
+				Domain:   *d,
+			})
+		}
+
+		if len(response.DomainRecords.Record) == 0 || int64(len(c.Result)) >= response.TotalCount {
+			break
+		}
 	}
 
 	c.Success = true
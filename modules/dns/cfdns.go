@@ -23,6 +23,9 @@ type CloudflareDNS struct {
 	Domain      models.Domain  `json:"-"`
 }
 
+// page size of ListDNSRecords, all pages are fetched
+const cfListPageSize = 500
+
 type CloudflareDNSList struct {
 	Success  bool            `json:"success"`
 	Errors   []interface{}   `json:"errors"`
@@ -30,6 +33,61 @@ type CloudflareDNSList struct {
 	Result   []CloudflareDNS `json:"result"`
 }
 
+func init() {
+	RegisterProvider(Provider{
+		Name:        "cloudflare",
+		DisplayName: "Cloudflare",
+		Capabilities: Capabilities{
+			RecordTypes: []string{"A", "AAAA", "CNAME", "MX", "TXT", "NS", "SRV", "CAA", "PTR"},
+			Proxy:       true,
+			Lines:       false,
+			Comments:    true,
+			MinTTL:      60,
+			MaxTTL:      86400,
+			AutoTTL:     true,
+			Extensions: []ExtensionSpec{
+				{Key: ExtProxied, Type: ExtBool},
+				{Key: ExtData, Type: ExtObject},
+			},
+		},
+		Credentials: []CredentialField{
+			{Field: "api_id", Label: "Zone ID", Secret: false},
+			{Field: "api_secret", Label: "API Token", Secret: true},
+		},
+		NewObj:     func(d models.Domain) DnsObj { return &CloudflareDNS{Domain: d} },
+		NewObjList: func() DnsObjList { return &CloudflareDNSList{} },
+	})
+}
+
+func (c *CloudflareDNS) ToRecord() Record {
+	ext := Extensions{ExtProxied: c.ProxyStatus}
+	if c.Data != nil {
+		ext[ExtData] = c.Data
+	}
+	return Record{
+		Id:         c.Id,
+		Name:       RelativeName(c.Name, c.Domain.Name),
+		Type:       c.Type,
+		Content:    c.Content,
+		TTL:        c.TTL,
+		Priority:   c.Priority,
+		Comment:    c.Commnet,
+		Extensions: ext,
+	}
+}
+
+func (c *CloudflareDNS) FromRecord(r Record) {
+	c.Id = r.Id
+	c.Name = FQDN(r.Name, c.Domain.Name)
+	c.Type = r.Type
+	c.Content = r.Content
+	c.TTL = r.TTL
+	c.Priority = r.Priority
+	c.Commnet = r.Comment
+	c.ProxyStatus = r.Extensions.Bool(ExtProxied, false)
+	c.Data = r.Extensions.Value(ExtData)
+}
+
 func (c *CloudflareDNS) Create() error {
 	// extract auth info
 	zoneId, apiToken, err := c.Domain.ExtractAuth()
@@ -38,8 +96,7 @@ func (c *CloudflareDNS) Create() error {
 	}
 
 	// logging info
-	logrus.Info("Create DNS record: ", c)
-	logrus.Debug("Auth with ZoneId: %s, ApiToken: %s", zoneId, apiToken)
+	logrus.Info("Create DNS record: ", c.ToRecord(), " of domain: ", c.Domain.Name)
 
 	// create dns record
 	api, err := cf.NewWithAPIToken(apiToken)
@@ -94,12 +151,11 @@ func (c *CloudflareDNS) Get(id string) error {
 	c.ProxyStatus = lutils.IfThenPtr(res.Proxied, false)
 	c.TTL = res.TTL
 	c.Commnet = res.Comment
-	c.Data = lutils.IfThenPtr(res.Data, "")
+	c.Data = res.Data
 	c.Priority = lutils.IfThenPtr(res.Priority, uint16(0))
 
 	// logging info
-	logrus.Info("Get DNS record: ", c)
-	logrus.Debug("Auth with ZoneId: %s, ApiToken: %s", zoneId, apiToken)
+	logrus.Info("Get DNS record: ", c.ToRecord(), " of domain: ", c.Domain.Name)
 
 	return nil
 }
@@ -112,8 +168,7 @@ func (c *CloudflareDNS) Delete() error {
 	}
 
 	// logging info
-	logrus.Info("Delete DNS record: ", c)
-	logrus.Debug("Auth with ZoneId: %s, ApiToken: %s", zoneId, apiToken)
+	logrus.Info("Delete DNS record: ", c.ToRecord(), " of domain: ", c.Domain.Name)
 
 	// delete dns record
 	api, err := cf.NewWithAPIToken(apiToken)
@@ -135,8 +190,7 @@ func (c *CloudflareDNS) Update() error {
 	}
 
 	// logging info
-	logrus.Info("Update DNS record: ", c)
-	logrus.Debug("Auth with ZoneId: %s, ApiToken: %s", zoneId, apiToken)
+	logrus.Info("Update DNS record: ", c.ToRecord(), " of domain: ", c.Domain.Name)
 
 	// update dns record
 	api, err := cf.NewWithAPIToken(apiToken)
@@ -177,6 +231,17 @@ func (c *CloudflareDNSList) MultipleSelectWithIds(ids []string, r *[]interface{}
 	return nil
 }
 
+func (c *CloudflareDNSList) Records() ([]Record, error) {
+	if !c.Success {
+		return nil, listError(c.Errors)
+	}
+	res := make([]Record, 0, len(c.Result))
+	for i := range c.Result {
+		res = append(res, c.Result[i].ToRecord())
+	}
+	return res, nil
+}
+
 func (c *CloudflareDNSList) GetDNSList(d *models.Domain) error {
 	// extract auth info
 	zoneId, apiToken, err := d.ExtractAuth()
@@ -186,8 +251,7 @@ func (c *CloudflareDNSList) GetDNSList(d *models.Domain) error {
 	}
 
 	// logging info
-	logrus.Info("Get DNS records of domain: %s", d.Name)
-	logrus.Debug("Details: %s", d)
+	logrus.Infof("Get DNS records of domain: %s", d.Name)
 
 	// get dns record list
 	api, err := cf.NewWithAPIToken(apiToken)
@@ -196,28 +260,35 @@ func (c *CloudflareDNSList) GetDNSList(d *models.Domain) error {
 		return nil
 	}
 	ctx := context.Background()
-	dnsRecords, _, err := api.ListDNSRecords(ctx,
-		cf.ZoneIdentifier(zoneId),
-		cf.ListDNSRecordsParams{
-			ResultInfo: cf.ResultInfo{PerPage: 500},
-		})
-	if err != nil {
-		c.Errors = []interface{}{err.Error()}
-		return nil
+	params := cf.ListDNSRecordsParams{
+		ResultInfo: cf.ResultInfo{Page: 1, PerPage: cfListPageSize},
 	}
-	for _, dnsRecord := range dnsRecords {
-		c.Result = append(c.Result, CloudflareDNS{
-			Id:          dnsRecord.ID,
-			Type:        dnsRecord.Type,
-			Name:        dnsRecord.Name,
-			Content:     dnsRecord.Content,
-			ProxyStatus: lutils.IfThenPtr(dnsRecord.Proxied, false),
-			TTL:         dnsRecord.TTL,
-			Commnet:     dnsRecord.Comment,
-			Data:        dnsRecord.Data,
-			Priority:    lutils.IfThenPtr(dnsRecord.Priority, uint16(0)),
-			Domain:      *d,
-		})
+	for {
+		dnsRecords, info, err := api.ListDNSRecords(ctx, cf.ZoneIdentifier(zoneId), params)
+		if err != nil {
+			c.Errors = []interface{}{err.Error()}
+			c.Result = nil
+			return nil
+		}
+		for _, dnsRecord := range dnsRecords {
+			c.Result = append(c.Result, CloudflareDNS{
+				Id:          dnsRecord.ID,
+				Type:        dnsRecord.Type,
+				Name:        dnsRecord.Name,
+				Content:     dnsRecord.Content,
+				ProxyStatus: lutils.IfThenPtr(dnsRecord.Proxied, false),
+				TTL:         dnsRecord.TTL,
+				Commnet:     dnsRecord.Comment,
+				Data:        dnsRecord.Data,
+				Priority:    lutils.IfThenPtr(dnsRecord.Priority, uint16(0)),
+				Domain:      *d,
+			})
+		}
+		// the page size given explicitly, sdk won't paginate for us
+		if info == nil || len(dnsRecords) == 0 || info.Page >= info.TotalPages {
+			break
+		}
+		params.Page++
 	}
 	c.Success = true
 	return nil
@@ -5,6 +5,7 @@ import (
 	"domain0/utils"
 	"errors"
 	"strconv"
+	"strings"
 
 	"github.com/sirupsen/logrus"
 	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
@@ -14,6 +15,9 @@ import (
 
 var dnsProfile = profile.NewClientProfile()
 
+// max page size of DescribeRecordList
+const tencentListPageSize = 3000
+
 type TencentDNSCustom struct {
 	RecordLine string `json:"record_line"`
 	Enable     string `json:"enable"`
@@ -39,6 +43,62 @@ type TencentDNSList struct {
 	Result   []TencentDNS  `json:"result"`
 }
 
+func init() {
+	RegisterProvider(Provider{
+		Name:        "dnspod",
+		DisplayName: "DNSPod (Tencent Cloud)",
+		Capabilities: Capabilities{
+			RecordTypes: []string{"A", "AAAA", "CNAME", "MX", "TXT", "NS", "SRV", "CAA"},
+			Proxy:       false,
+			Lines:       true,
+			Comments:    true,
+			MinTTL:      1,
+			MaxTTL:      604800,
+			Extensions: []ExtensionSpec{
+				{Key: ExtLine, Type: ExtString},
+				{Key: ExtEnabled, Type: ExtBool},
+			},
+		},
+		Credentials: []CredentialField{
+			{Field: "api_id", Label: "SecretId", Secret: false},
+			{Field: "api_secret", Label: "SecretKey", Secret: true},
+		},
+		NewObj:     func(d models.Domain) DnsObj { return &TencentDNS{Domain: d} },
+		NewObjList: func() DnsObjList { return &TencentDNSList{} },
+	})
+}
+
+func (t *TencentDNS) ToRecord() Record {
+	ext := Extensions{ExtEnabled: !strings.EqualFold(t.Custom.Enable, "disable")}
+	if t.Custom.RecordLine != "" {
+		ext[ExtLine] = t.Custom.RecordLine
+	}
+	return Record{
+		Id:         utils.IfThen(t.Id == 0, "", strconv.FormatUint(t.Id, 10)),
+		Name:       RelativeName(t.Name, t.Domain.Name),
+		Type:       t.Type,
+		Content:    t.Content,
+		TTL:        int(t.TTL),
+		Priority:   uint16(t.Priority),
+		Comment:    utils.IfThenPtr(t.Commnet, ""),
+		Extensions: ext,
+	}
+}
+
+func (t *TencentDNS) FromRecord(r Record) {
+	t.Id, _ = strconv.ParseUint(r.Id, 10, 64)
+	t.Name = RelativeName(r.Name, t.Domain.Name)
+	t.Type = r.Type
+	t.Content = r.Content
+	t.TTL = uint64(r.TTL)
+	t.Priority = uint64(r.Priority)
+	t.Commnet = common.StringPtr(r.Comment)
+	t.Custom = TencentDNSCustom{
+		RecordLine: r.Extensions.String(ExtLine, ""),
+		Enable:     utils.IfThen(r.Extensions.Bool(ExtEnabled, true), "enable", "disable"),
+	}
+}
+
 func (t *TencentDNS) Create() error {
 	// extract auth info
 	secretId, secretKey, err := t.Domain.ExtractAuth()
@@ -47,8 +107,7 @@ func (t *TencentDNS) Create() error {
 	}
 
 	// logging info
-	logrus.Info("Create DNS record: ", t)
-	logrus.Debug("Auth with Secret_Id: %s, Secret_Key: %s", secretId, secretKey)
+	logrus.Info("Create DNS record: ", t.ToRecord(), " of domain: ", t.Domain.Name)
 
 	// create dns record
 	client, err := dnspod.NewClient(common.NewCredential(secretId, secretKey), "ap-guangzhou", dnsProfile)
@@ -105,11 +164,11 @@ func (t *TencentDNS) Get(id string) error {
 	t.Type = *res.Response.RecordInfo.RecordType
 	t.Content = *res.Response.RecordInfo.Value
 	t.TTL = *res.Response.RecordInfo.TTL
-	t.Priority = *res.Response.RecordInfo.MX
+	t.Priority = utils.IfThenPtr(res.Response.RecordInfo.MX, uint64(0))
 	t.Commnet = res.Response.RecordInfo.Remark
 	t.Custom = TencentDNSCustom{
 		RecordLine: *res.Response.RecordInfo.RecordLine,
-		Enable:     utils.IfThen(*res.Response.RecordInfo.Enabled == 0, "enable", "disable"),
+		Enable:     utils.IfThen(utils.IfThenPtr(res.Response.RecordInfo.Enabled, uint64(1)) == 1, "enable", "disable"),
 	}
 	return nil
 }
@@ -122,8 +181,7 @@ func (t *TencentDNS) Delete() error {
 	}
 
 	// logging info
-	logrus.Info("Delete DNS record: ", t)
-	logrus.Debug("Auth with Secret_Id: %s, Secret_Key: %s", secretId, secretKey)
+	logrus.Info("Delete DNS record: ", t.ToRecord(), " of domain: ", t.Domain.Name)
 
 	// delete dns record
 	client, err := dnspod.NewClient(common.NewCredential(secretId, secretKey), "ap-guangzhou", dnsProfile)
@@ -149,8 +207,7 @@ func (t *TencentDNS) Update() error {
 	}
 
 	// logging info
-	logrus.Info("Update DNS record: ", t)
-	logrus.Debug("Auth with Secret_Id: %s, Secret_Key: %s", secretId, secretKey)
+	logrus.Info("Update DNS record: ", t.ToRecord(), " of domain: ", t.Domain.Name)
 
 	// update dns record
 	client, err := dnspod.NewClient(common.NewCredential(secretId, secretKey), "ap-guangzhou", dnsProfile)
@@ -202,6 +259,17 @@ func (t *TencentDNSList) MultipleSelectWithIds(ids []string, r *[]interface{}) e
 	return nil
 }
 
+func (t *TencentDNSList) Records() ([]Record, error) {
+	if !t.Success {
+		return nil, listError(t.Errors)
+	}
+	res := make([]Record, 0, len(t.Result))
+	for i := range t.Result {
+		res = append(res, t.Result[i].ToRecord())
+	}
+	return res, nil
+}
+
 func (c *TencentDNSList) GetDNSList(d *models.Domain) error {
 	// extract auth info
 	secretId, secretKey, err := d.ExtractAuth()
@@ -211,8 +279,7 @@ func (c *TencentDNSList) GetDNSList(d *models.Domain) error {
 	}
 
 	// logging info
-	logrus.Info("Get DNS record list: ", d)
-	logrus.Debug("Auth with Secret_Id: %s, Secret_Key: %s", secretId, secretKey)
+	logrus.Infof("Get DNS records of domain: %s", d.Name)
 
 	// get dns record list
 	api, err := dnspod.NewClient(common.NewCredential(secretId, secretKey), "ap-guangzhou", dnsProfile)
@@ -221,27 +288,41 @@ func (c *TencentDNSList) GetDNSList(d *models.Domain) error {
 		return nil
 	}
 
-	request := dnspod.NewDescribeRecordListRequest()
-	request.Domain = &d.Name
-	request.Limit = common.Uint64Ptr(500)
-	response, err := api.DescribeRecordList(request)
-	if err != nil {
-		c.Errors = []interface{}{err.Error()}
-		return nil
-	}
+	var offset uint64 = 0
+	for {
+		request := dnspod.NewDescribeRecordListRequest()
+		request.Domain = &d.Name
+		request.Offset = common.Uint64Ptr(offset)
+		request.Limit = common.Uint64Ptr(tencentListPageSize)
+		response, err := api.DescribeRecordList(request)
+		if err != nil {
+			c.Errors = []interface{}{err.Error()}
+			c.Result = nil
+			return nil
+		}
+
+		for _, record := range response.Response.RecordList {
+			c.Result = append(c.Result, TencentDNS{
+				Id:       *record.RecordId,
+				Type:     *record.Type,
+				Name:     *record.Name,
+				Content:  *record.Value,
+				TTL:      *record.TTL,
+				Commnet:  record.Remark,
+				Priority: utils.IfThenPtr(record.MX, uint64(0)),
+				Custom:   TencentDNSCustom{Enable: *record.Status, RecordLine: *record.Line},
+				Domain:   *d,
+			})
+		}
 
-	for _, record := range response.Response.RecordList {
-		c.Result = append(c.Result, TencentDNS{
-			Id:       *record.RecordId,
-			Type:     *record.Type,
-			Name:     *record.Name,
-			Content:  *record.Value,
-			TTL:      *record.TTL,
-			Commnet:  record.Remark,
-			Priority: utils.IfThenPtr(record.MX, uint64(0)),
-			Custom:   TencentDNSCustom{Enable: *record.Status, RecordLine: *record.Line},
-			Domain:   *d,
-		})
+		offset += uint64(len(response.Response.RecordList))
+		total := uint64(0)
+		if info := response.Response.RecordCountInfo; info != nil {
+			total = utils.IfThenPtr(info.TotalCount, uint64(0))
+		}
+		if len(response.Response.RecordList) == 0 || offset >= total {
+			break
+		}
 	}
 
 	c.Success = true
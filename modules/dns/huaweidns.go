@@ -19,6 +19,9 @@ import (
 
 // type HuaweiDNSCustom struct{}
 
+// max page size of ListRecordSets
+const huaweiListPageSize = 500
+
 // TODO: It would be best to use reflection to reconstruct this logic
 type sourceRecord struct {
 	list   *model.ListRecordSetsWithTags
@@ -108,6 +111,51 @@ type HuaweiDNSList struct {
 	Result   []HuaweiDNS   `json:"result"`
 }
 
+func init() {
+	RegisterProvider(Provider{
+		Name:        "huawei",
+		DisplayName: "Huawei Cloud DNS",
+		Capabilities: Capabilities{
+			RecordTypes: []string{"A", "AAAA", "CNAME", "MX", "TXT", "NS", "SRV", "CAA"},
+			Proxy:       false,
+			Lines:       false,
+			Comments:    true,
+			MinTTL:      1,
+			MaxTTL:      2147483647,
+		},
+		Credentials: []CredentialField{
+			{Field: "api_id", Label: "Access Key (AK)", Secret: false},
+			{Field: "api_secret", Label: "Secret Key (SK)", Secret: true},
+		},
+		NewObj:     func(d models.Domain) DnsObj { return &HuaweiDNS{Domain: d} },
+		NewObjList: func() DnsObjList { return &HuaweiDNSList{} },
+	})
+}
+
+func (h *HuaweiDNS) ToRecord() Record {
+	return Record{
+		Id:       h.Id,
+		Name:     RelativeName(h.Name, h.Domain.Name),
+		Type:     h.Type,
+		Content:  h.Content,
+		TTL:      h.TTL,
+		Priority: h.Priority,
+		Comment:  utils.IfThenPtr(h.Commnet, ""),
+	}
+}
+
+func (h *HuaweiDNS) FromRecord(r Record) {
+	h.Id = r.Id
+	h.Name = FQDN(r.Name, h.Domain.Name) + "."
+	h.Type = r.Type
+	h.Content = r.Content
+	h.TTL = r.TTL
+	h.Priority = r.Priority
+	h.Commnet = utils.IfThen(r.Comment == "", nil, &r.Comment)
+	// name or type may be changed, search the source record again
+	h.sourceRecord = nil
+}
+
 func hashRecord(records *[]string, index int) string {
 	record := (*records)[index]
 	md5Ctx := md5.New()
@@ -245,9 +293,6 @@ func (h *HuaweiDNS) getClient() (*dns.DnsClient, error) {
 		return nil, err
 	}
 
-	// logging info
-	logrus.Debug("Auth with Secret_Id: %s, Secret_Key: %s", ak, sk)
-
 	// auth
 	auth := basic.NewCredentialsBuilder().
 		WithAk(ak).
@@ -328,7 +373,7 @@ func (h *HuaweiDNS) getRecord() string {
 }
 
 func (h *HuaweiDNS) Create() error {
-	logrus.Info("Create DNS record: ", h)
+	logrus.Info("Create DNS record: ", h.ToRecord(), " of domain: ", h.Domain.Name)
 	// create dns record
 	name := h.formatName()
 	ttlRecord := int32(h.TTL)
@@ -397,7 +442,7 @@ func (h *HuaweiDNS) Create() error {
 func (h *HuaweiDNS) Get(id string) error {
 	// set id
 	h.Id = id
-	logrus.Info("Get DNS record: ", h)
+	logrus.Info("Get DNS record: ", h.ToRecord(), " of domain: ", h.Domain.Name)
 
 	_, recordHash, err := decodeId(h.Id)
 	if err != nil {
@@ -426,7 +471,7 @@ func (h *HuaweiDNS) Get(id string) error {
 }
 
 func (h *HuaweiDNS) Delete() error {
-	logrus.Info("Delete DNS record: ", h)
+	logrus.Info("Delete DNS record: ", h.ToRecord(), " of domain: ", h.Domain.Name)
 	recordIndex, err := h.getRecordIndex()
 	if err != nil {
 		return err
@@ -469,7 +514,7 @@ func (h *HuaweiDNS) Delete() error {
 }
 
 func (h *HuaweiDNS) Update() error {
-	logrus.Info("Update DNS record: ", h)
+	logrus.Info("Update DNS record: ", h.ToRecord(), " of domain: ", h.Domain.Name)
 	name := h.formatName()
 	recordIndex, err := h.getRecordIndex()
 	if err != nil {
@@ -549,6 +594,17 @@ func (h *HuaweiDNSList) MultipleSelectWithIds(ids []string, r *[]interface{}) er
 	return nil
 }
 
+func (h *HuaweiDNSList) Records() ([]Record, error) {
+	if !h.Success {
+		return nil, listError(h.Errors)
+	}
+	res := make([]Record, 0, len(h.Result))
+	for i := range h.Result {
+		res = append(res, h.Result[i].ToRecord())
+	}
+	return res, nil
+}
+
 func (h *HuaweiDNSList) GetDNSList(d *models.Domain) error {
 	// extract auth info
 	ak, sk, err := d.ExtractAuth()
@@ -557,8 +613,7 @@ func (h *HuaweiDNSList) GetDNSList(d *models.Domain) error {
 	}
 
 	// logging info
-	logrus.Info("Get DNS record list: ", d)
-	logrus.Debug("Auth with Secret_Id: %s, Secret_Key: %s", ak, sk)
+	logrus.Infof("Get DNS records of domain: %s", d.Name)
 
 	// auth
 	auth := basic.NewCredentialsBuilder().
@@ -573,27 +628,43 @@ func (h *HuaweiDNSList) GetDNSList(d *models.Domain) error {
 			Build())
 
 	// get dns record list
-	request := &model.ListRecordSetsRequest{}
-	request.Name = &d.Name
-	response, err := client.ListRecordSets(request)
-	if err != nil {
-		h.Errors = []interface{}{err.Error()}
-		return nil
-	}
-	for _, record := range *response.Recordsets {
-		for index, recordItem := range *record.Records {
-			recordHash := hashRecord(record.Records, index)
-			// TODO: need to add "Line type"
-			dnsItem := HuaweiDNS{
-				Id:      encodeId(*record.Id, recordHash),
-				Type:    *record.Type,
-				Name:    *record.Name,
-				TTL:     int(*record.Ttl),
-				Domain:  *d,
-				Commnet: record.Description,
+	var offset int32 = 0
+	limit := int32(huaweiListPageSize)
+	for {
+		request := &model.ListRecordSetsRequest{}
+		request.Name = &d.Name
+		request.Offset = &offset
+		request.Limit = &limit
+		response, err := client.ListRecordSets(request)
+		if err != nil {
+			h.Errors = []interface{}{err.Error()}
+			h.Result = nil
+			return nil
+		}
+		for _, record := range *response.Recordsets {
+			for index, recordItem := range *record.Records {
+				recordHash := hashRecord(record.Records, index)
+				// TODO: need to add "Line type"
+				dnsItem := HuaweiDNS{
+					Id:      encodeId(*record.Id, recordHash),
+					Type:    *record.Type,
+					Name:    *record.Name,
+					TTL:     int(*record.Ttl),
+					Domain:  *d,
+					Commnet: record.Description,
+				}
+				dnsItem.setRecord(recordItem)
+				h.Result = append(h.Result, dnsItem)
 			}
-			dnsItem.setRecord(recordItem)
-			h.Result = append(h.Result, dnsItem)
+		}
+
+		offset += int32(len(*response.Recordsets))
+		total := int32(0)
+		if response.Metadata != nil {
+			total = utils.IfThenPtr(response.Metadata.TotalCount, int32(0))
+		}
+		if len(*response.Recordsets) == 0 || offset >= total {
+			break
 		}
 	}
 	h.Success = true
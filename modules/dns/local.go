@@ -0,0 +1,390 @@
+package dns
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	miekg "github.com/miekg/dns"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	"domain0/config"
+	"domain0/models"
+)
+
+// LocalVendor is the vendor of domains served by domain0 itself
+const LocalVendor = "local"
+
+const (
+	// changes of the last serials kept in journal for IXFR
+	localJournalSerials = 1000
+
+	localSOATTL  = 3600
+	localRefresh = 3600
+	localRetry   = 600
+	localExpire  = 604800
+	localMinTTL  = 300 // ttl of negative answers
+)
+
+// LocalDNS is a record of a domain of vendor local, it is stored in database and served by the
+// built-in authoritative server, see ServeLocal
+type LocalDNS struct {
+	Record Record
+	Domain models.Domain `json:"-"`
+}
+
+type LocalDNSList struct {
+	Success bool          `json:"success"`
+	Errors  []interface{} `json:"errors"`
+	Result  []LocalDNS    `json:"result"`
+}
+
+// localMu serializes changes of local zones, so serials and journal are in order
+var localMu sync.Mutex
+
+// localStore is the database local zones are stored in, it is injected by SetLocalStore
+var localStore *gorm.DB
+
+// SetLocalStore sets the database of local zones, it must be called before domains of vendor
+// local are used or served
+func SetLocalStore(store *gorm.DB) {
+	localStore = store
+}
+
+func init() {
+	RegisterProvider(Provider{
+		Name:        LocalVendor,
+		DisplayName: "Domain0 (built-in authoritative server)",
+		Capabilities: Capabilities{
+			RecordTypes: []string{"A", "AAAA", "CNAME", "MX", "TXT", "NS", "SRV", "CAA", "PTR"},
+			Proxy:       false,
+			Lines:       false,
+			Comments:    true,
+			SplitTXT:    true,
+			MaxTTL:      2147483647,
+			Extensions: []ExtensionSpec{
+				{Key: ExtEnabled, Type: ExtBool},
+			},
+		},
+		Credentials: []CredentialField{},
+		NewObj:      func(d models.Domain) DnsObj { return &LocalDNS{Domain: d} },
+		NewObjList:  func() DnsObjList { return &LocalDNSList{} },
+	})
+}
+
+func localRecord(r *models.LocalRecord) Record {
+	return Record{
+		Id:         strconv.FormatUint(uint64(r.ID), 10),
+		Name:       r.Name,
+		Type:       r.Type,
+		Content:    r.Content,
+		TTL:        r.TTL,
+		Priority:   r.Priority,
+		Comment:    r.Comment,
+		Extensions: Extensions{ExtEnabled: !r.Disabled},
+	}
+}
+
+// localRR is the resource record served for the record, nil if it is disabled
+func localRR(zone string, r *models.LocalRecord) (miekg.RR, error) {
+	if r.Disabled {
+		return nil, nil
+	}
+	rec := localRecord(r)
+	return rec.ToRR(zone)
+}
+
+// fromRecord fills the stored record with rec, and checks it can be served
+func (l *LocalDNS) fromRecord(r *models.LocalRecord) error {
+	r.DomainId = l.Domain.ID
+	r.Name = RelativeName(l.Record.Name, l.Domain.Name)
+	r.Type = strings.ToUpper(l.Record.Type)
+	r.Content = l.Record.Content
+	r.TTL = l.Record.TTL
+	r.Priority = l.Record.Priority
+	r.Comment = l.Record.Comment
+	r.Disabled = !l.Record.Extensions.Bool(ExtEnabled, true)
+	if r.TTL <= 1 {
+		r.TTL = DefaultTTL
+	}
+	if r.Name == "" {
+		r.Name = "@"
+	}
+	_, err := l.Record.ToRR(l.Domain.Name)
+	return err
+}
+
+// changeLocalZone runs fn in a transaction, the served records it deletes and adds are written to
+// journal with the next serial of the zone, and secondaries are notified after commit
+func changeLocalZone(d *models.Domain, fn func(tx *gorm.DB) (deleted, added []miekg.RR, err error)) error {
+	localMu.Lock()
+	defer localMu.Unlock()
+
+	changed := false
+	err := localStore.Transaction(func(tx *gorm.DB) error {
+		deleted, added, err := fn(tx)
+		if err != nil {
+			return err
+		}
+		if len(deleted) == 0 && len(added) == 0 {
+			return nil
+		}
+		serial, err := localSerial(tx, d.ID)
+		if err != nil {
+			return err
+		}
+		serial = uint(uint32(serial + 1))
+		if err := tx.Model(&models.LocalZone{}).Where("domain_id = ?", d.ID).Update("serial", serial).Error; err != nil {
+			return err
+		}
+
+		var journal []models.LocalZoneChange
+		for _, rr := range deleted {
+			journal = append(journal, models.LocalZoneChange{DomainId: d.ID, Serial: serial, Deleted: true, RR: rr.String()})
+		}
+		for _, rr := range added {
+			journal = append(journal, models.LocalZoneChange{DomainId: d.ID, Serial: serial, RR: rr.String()})
+		}
+		if err := tx.Create(&journal).Error; err != nil {
+			return err
+		}
+		if serial > localJournalSerials {
+			if err := tx.Where("domain_id = ? AND serial <= ?", d.ID, serial-localJournalSerials).
+				Delete(&models.LocalZoneChange{}).Error; err != nil {
+				return err
+			}
+		}
+		changed = true
+		return nil
+	})
+	if err == nil && changed {
+		go notifyLocal(d.Name)
+	}
+	return err
+}
+
+// localSerial returns the serial of zone, a zone without change starts from the current unix time
+func localSerial(tx *gorm.DB, domainId uint) (uint, error) {
+	zone := models.LocalZone{}
+	err := tx.Where(models.LocalZone{DomainId: domainId}).
+		Attrs(models.LocalZone{Serial: uint(uint32(time.Now().Unix()))}).
+		FirstOrCreate(&zone).Error
+	return zone.Serial, err
+}
+
+func (l *LocalDNS) ToRecord() Record {
+	return l.Record
+}
+
+func (l *LocalDNS) FromRecord(r Record) {
+	l.Record = r
+}
+
+func (l *LocalDNS) Create() error {
+	var rec models.LocalRecord
+	if err := l.fromRecord(&rec); err != nil {
+		return err
+	}
+
+	logrus.Info("Create DNS record: ", l.Record)
+	err := changeLocalZone(&l.Domain, func(tx *gorm.DB) ([]miekg.RR, []miekg.RR, error) {
+		if err := tx.Create(&rec).Error; err != nil {
+			return nil, nil, err
+		}
+		rr, err := localRR(l.Domain.Name, &rec)
+		if rr == nil {
+			return nil, nil, err
+		}
+		return nil, []miekg.RR{rr}, err
+	})
+	if err != nil {
+		return err
+	}
+	l.Record = localRecord(&rec)
+	return nil
+}
+
+func (l *LocalDNS) Get(id string) error {
+	var rec models.LocalRecord
+	if err := localStore.Where("id = ? AND domain_id = ?", id, l.Domain.ID).First(&rec).Error; err != nil {
+		return fmt.Errorf("record %s is not found: %w", id, err)
+	}
+	l.Record = localRecord(&rec)
+	return nil
+}
+
+func (l *LocalDNS) Update() error {
+	if l.Record.Id == "" {
+		return errors.New("update without record id")
+	}
+	var rec models.LocalRecord
+	if err := l.fromRecord(&rec); err != nil {
+		return err
+	}
+
+	logrus.Info("Update DNS record: ", l.Record)
+	err := changeLocalZone(&l.Domain, func(tx *gorm.DB) ([]miekg.RR, []miekg.RR, error) {
+		var old models.LocalRecord
+		if err := tx.Where("id = ? AND domain_id = ?", l.Record.Id, l.Domain.ID).First(&old).Error; err != nil {
+			return nil, nil, fmt.Errorf("record %s is not found: %w", l.Record.Id, err)
+		}
+		rec.Model = old.Model
+		if err := tx.Save(&rec).Error; err != nil {
+			return nil, nil, err
+		}
+
+		var deleted, added []miekg.RR
+		if rr, err := localRR(l.Domain.Name, &old); err == nil && rr != nil {
+			deleted = append(deleted, rr)
+		}
+		rr, err := localRR(l.Domain.Name, &rec)
+		if rr != nil {
+			added = append(added, rr)
+		}
+		return deleted, added, err
+	})
+	if err != nil {
+		return err
+	}
+	l.Record = localRecord(&rec)
+	return nil
+}
+
+func (l *LocalDNS) Delete() error {
+	logrus.Info("Delete DNS record: ", l.Record)
+	return changeLocalZone(&l.Domain, func(tx *gorm.DB) ([]miekg.RR, []miekg.RR, error) {
+		var old models.LocalRecord
+		if err := tx.Where("id = ? AND domain_id = ?", l.Record.Id, l.Domain.ID).First(&old).Error; err != nil {
+			return nil, nil, fmt.Errorf("record %s is not found: %w", l.Record.Id, err)
+		}
+		if err := tx.Delete(&old).Error; err != nil {
+			return nil, nil, err
+		}
+		if rr, err := localRR(l.Domain.Name, &old); err == nil && rr != nil {
+			return []miekg.RR{rr}, nil, nil
+		}
+		return nil, nil, nil
+	})
+}
+
+func (l *LocalDNSList) GetDNSList(d *models.Domain) error {
+	logrus.Infof("Get DNS records of domain: %s", d.Name)
+
+	var recs []models.LocalRecord
+	if err := localStore.Where("domain_id = ?", d.ID).Order("id").Find(&recs).Error; err != nil {
+		l.Errors = []interface{}{err.Error()}
+		return nil
+	}
+	l.Result = make([]LocalDNS, 0, len(recs))
+	for i := range recs {
+		l.Result = append(l.Result, LocalDNS{Record: localRecord(&recs[i]), Domain: *d})
+	}
+	l.Success = true
+	return nil
+}
+
+func (l *LocalDNSList) MultipleSelectWithIds(ids []string, r *[]interface{}) error {
+	for i := range l.Result {
+		for _, id := range ids {
+			if l.Result[i].Record.Id == id {
+				*r = append(*r, &l.Result[i])
+			}
+		}
+	}
+	if len(ids) != len(*r) {
+		return errors.New("some DNS records are not found")
+	}
+	return nil
+}
+
+func (l *LocalDNSList) Records() ([]Record, error) {
+	if !l.Success {
+		return nil, listError(l.Errors)
+	}
+	res := make([]Record, 0, len(l.Result))
+	for i := range l.Result {
+		res = append(res, l.Result[i].ToRecord())
+	}
+	return res, nil
+}
+
+// localZone is the served content of a domain of vendor local
+type localZone struct {
+	domain models.Domain
+	origin string // lower case fqdn
+	soa    *miekg.SOA
+	rrs    []miekg.RR // all served records except SOA
+}
+
+// loadLocalZone reads the records of the domain, NS of the nameserver is added if the apex has none
+func loadLocalZone(d *models.Domain) (*localZone, error) {
+	serial, err := localSerial(localStore, d.ID)
+	if err != nil {
+		return nil, err
+	}
+	var recs []models.LocalRecord
+	if err := localStore.Where("domain_id = ? AND disabled = ?", d.ID, false).Order("id").Find(&recs).Error; err != nil {
+		return nil, err
+	}
+
+	z := &localZone{domain: *d, origin: miekg.Fqdn(strings.ToLower(d.Name))}
+	z.soa = z.newSOA(uint32(serial))
+	apexNS := false
+	for i := range recs {
+		rr, err := localRR(d.Name, &recs[i])
+		if err != nil {
+			logrus.Warnf("Skip record %d of domain %s: %v", recs[i].ID, d.Name, err)
+			continue
+		}
+		rr.Header().Name = strings.ToLower(rr.Header().Name)
+		apexNS = apexNS || (rr.Header().Rrtype == miekg.TypeNS && rr.Header().Name == z.origin)
+		z.rrs = append(z.rrs, rr)
+	}
+	if !apexNS {
+		z.rrs = append(z.rrs, &miekg.NS{
+			Hdr: miekg.RR_Header{Name: z.origin, Rrtype: miekg.TypeNS, Class: miekg.ClassINET, Ttl: localSOATTL},
+			Ns:  z.soa.Ns,
+		})
+	}
+	return z, nil
+}
+
+func (z *localZone) newSOA(serial uint32) *miekg.SOA {
+	ns := config.CONFIG.LocalDNS.Nameserver
+	if ns == "" {
+		ns = "ns." + z.origin
+	}
+	mbox := config.CONFIG.LocalDNS.Hostmaster
+	if mbox == "" {
+		mbox = "hostmaster." + z.origin
+	}
+	return &miekg.SOA{
+		Hdr:     miekg.RR_Header{Name: z.origin, Rrtype: miekg.TypeSOA, Class: miekg.ClassINET, Ttl: localSOATTL},
+		Ns:      miekg.Fqdn(ns),
+		Mbox:    miekg.Fqdn(strings.Replace(mbox, "@", ".", 1)),
+		Serial:  serial,
+		Refresh: localRefresh,
+		Retry:   localRetry,
+		Expire:  localExpire,
+		Minttl:  localMinTTL,
+	}
+}
+
+// journal returns the changes after serial in order of serial, false if some are not in journal
+func (z *localZone) journal(serial uint32) ([]models.LocalZoneChange, bool) {
+	var changes []models.LocalZoneChange
+	if err := localStore.Where("domain_id = ? AND serial > ?", z.domain.ID, serial).
+		Order("serial, deleted desc, id").Find(&changes).Error; err != nil {
+		logrus.Errorf("read journal of %s error:%v", z.origin, err)
+		return nil, false
+	}
+	// serials are continuous, the first change must be the next of serial
+	if len(changes) == 0 || uint32(changes[0].Serial) != serial+1 || uint32(changes[len(changes)-1].Serial) != z.soa.Serial {
+		return nil, false
+	}
+	return changes, true
+}
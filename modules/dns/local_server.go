@@ -0,0 +1,392 @@
+package dns
+
+import (
+	"net"
+	"strings"
+	"time"
+
+	miekg "github.com/miekg/dns"
+	"github.com/sirupsen/logrus"
+
+	"domain0/config"
+	"domain0/models"
+)
+
+const (
+	localUDPSize      = 1232 // edns buffer size of responses, to avoid fragmentation
+	localXfrChunk     = 100  // records in one message of zone transfer
+	localCNAMEHops    = 8    // in zone CNAME chain followed in one answer
+	localNotifyTries  = 3
+	localNotifyWait   = 5 * time.Second
+	localQueryTimeout = 5 * time.Second
+)
+
+// ServeLocal answers queries of domains of vendor local on addr over udp and tcp, it blocks until
+// one of the servers fails
+func ServeLocal(addr string) error {
+	errCh := make(chan error, 2)
+	for _, network := range []string{"udp", "tcp"} {
+		srv := &miekg.Server{Addr: addr, Net: network, Handler: miekg.HandlerFunc(serveLocal)}
+		go func() { errCh <- srv.ListenAndServe() }()
+	}
+	return <-errCh
+}
+
+func serveLocal(w miekg.ResponseWriter, req *miekg.Msg) {
+	m := new(miekg.Msg)
+	m.SetReply(req)
+	if req.Opcode != miekg.OpcodeQuery || len(req.Question) != 1 {
+		m.SetRcode(req, miekg.RcodeNotImplemented)
+		w.WriteMsg(m)
+		return
+	}
+	q := req.Question[0]
+	z, err := localZoneOf(q.Name)
+	switch {
+	case err != nil:
+		logrus.Errorf("local dns: load zone of %s error:%v", q.Name, err)
+		m.SetRcode(req, miekg.RcodeServerFailure)
+	case z == nil:
+		m.SetRcode(req, miekg.RcodeRefused)
+	case q.Qtype == miekg.TypeAXFR || q.Qtype == miekg.TypeIXFR:
+		z.serveTransfer(w, req)
+		return
+	default:
+		z.answer(m, q)
+	}
+
+	udp := isUDP(w.RemoteAddr())
+	if opt := req.IsEdns0(); opt != nil {
+		size := localUDPSize
+		if int(opt.UDPSize()) < size {
+			size = int(opt.UDPSize())
+		}
+		m.SetEdns0(localUDPSize, false)
+		if udp {
+			m.Truncate(size)
+		}
+	} else if udp {
+		m.Truncate(miekg.MinMsgSize)
+	}
+	w.WriteMsg(m)
+}
+
+func isUDP(addr net.Addr) bool {
+	_, ok := addr.(*net.UDPAddr)
+	return ok
+}
+
+// localZoneOf finds the domain of vendor local the name belongs to, the longest one if nested
+func localZoneOf(name string) (*localZone, error) {
+	var domains []models.Domain
+	if err := localStore.Where("vendor = ?", LocalVendor).Find(&domains).Error; err != nil {
+		return nil, err
+	}
+	name = strings.ToLower(name)
+	var found *models.Domain
+	for i := range domains {
+		origin := miekg.Fqdn(strings.ToLower(domains[i].Name))
+		if miekg.IsSubDomain(origin, name) && (found == nil || len(origin) > len(miekg.Fqdn(found.Name))) {
+			found = &domains[i]
+		}
+	}
+	if found == nil {
+		return nil, nil
+	}
+	return loadLocalZone(found)
+}
+
+func parentName(name string) string {
+	if i := strings.Index(name, "."); i >= 0 && i < len(name)-1 {
+		return name[i+1:]
+	}
+	return "."
+}
+
+// at returns the records of owner name, name must be lower case
+func (z *localZone) at(name string) []miekg.RR {
+	var res []miekg.RR
+	if name == z.origin {
+		res = append(res, z.soa)
+	}
+	for _, rr := range z.rrs {
+		if rr.Header().Name == name {
+			res = append(res, rr)
+		}
+	}
+	return res
+}
+
+// exists reports whether name has records, or is an empty non-terminal
+func (z *localZone) exists(name string) bool {
+	if name == z.origin {
+		return true
+	}
+	for _, rr := range z.rrs {
+		if owner := rr.Header().Name; owner == name || strings.HasSuffix(owner, "."+name) {
+			return true
+		}
+	}
+	return false
+}
+
+// delegation returns NS records of the zone cut at or above name, nil if name is not delegated
+func (z *localZone) delegation(name string) []miekg.RR {
+	for n := name; n != z.origin && miekg.IsSubDomain(z.origin, n); n = parentName(n) {
+		if ns := filterType(z.at(n), miekg.TypeNS); len(ns) > 0 {
+			return ns
+		}
+	}
+	return nil
+}
+
+// wildcard returns the records of the wildcard at the closest encloser of name, owned by name
+func (z *localZone) wildcard(name string) []miekg.RR {
+	for n := parentName(name); miekg.IsSubDomain(z.origin, n); n = parentName(n) {
+		if !z.exists(n) {
+			continue
+		}
+		var res []miekg.RR
+		for _, rr := range z.at("*." + n) {
+			rr = miekg.Copy(rr)
+			rr.Header().Name = name
+			res = append(res, rr)
+		}
+		return res
+	}
+	return nil
+}
+
+func filterType(rrs []miekg.RR, qtype uint16) []miekg.RR {
+	var res []miekg.RR
+	for _, rr := range rrs {
+		if qtype == miekg.TypeANY || rr.Header().Rrtype == qtype {
+			res = append(res, rr)
+		}
+	}
+	return res
+}
+
+// negativeSOA is the SOA in authority section of NXDOMAIN and NODATA answers
+func (z *localZone) negativeSOA() miekg.RR {
+	soa := miekg.Copy(z.soa)
+	soa.Header().Ttl = localMinTTL
+	return soa
+}
+
+// glue returns A and AAAA records in zone of the name servers
+func (z *localZone) glue(nss []miekg.RR) []miekg.RR {
+	var res []miekg.RR
+	for _, rr := range nss {
+		if ns, ok := rr.(*miekg.NS); ok {
+			target := strings.ToLower(ns.Ns)
+			res = append(res, filterType(z.at(target), miekg.TypeA)...)
+			res = append(res, filterType(z.at(target), miekg.TypeAAAA)...)
+		}
+	}
+	return res
+}
+
+// answer fills the response to a query of the zone as rfc 1034 4.3.2
+func (z *localZone) answer(m *miekg.Msg, q miekg.Question) {
+	name := strings.ToLower(q.Name)
+	for hop := 0; hop < localCNAMEHops; hop++ {
+		if !miekg.IsSubDomain(z.origin, name) {
+			// CNAME out of zone, resolver follows it
+			return
+		}
+		if ns := z.delegation(name); ns != nil {
+			// referral is not authoritative, except CNAME before it
+			m.Authoritative = len(m.Answer) > 0
+			m.Ns = ns
+			m.Extra = z.glue(ns)
+			return
+		}
+		m.Authoritative = true
+
+		rrs := z.at(name)
+		if len(rrs) == 0 && !z.exists(name) {
+			rrs = z.wildcard(name)
+		}
+		if len(rrs) == 0 && !z.exists(name) {
+			if len(m.Answer) == 0 {
+				m.Rcode = miekg.RcodeNameError
+			}
+			m.Ns = []miekg.RR{z.negativeSOA()}
+			return
+		}
+
+		if res := filterType(rrs, q.Qtype); len(res) > 0 {
+			m.Answer = append(m.Answer, res...)
+			if q.Qtype == miekg.TypeNS && name == z.origin {
+				m.Extra = z.glue(res)
+			}
+			return
+		}
+		cname := filterType(rrs, miekg.TypeCNAME)
+		if len(cname) == 0 {
+			m.Ns = []miekg.RR{z.negativeSOA()}
+			return
+		}
+		m.Answer = append(m.Answer, cname[0])
+		name = strings.ToLower(cname[0].(*miekg.CNAME).Target)
+	}
+}
+
+// transferAllowed checks the client by allow_transfer of local_dns config
+func transferAllowed(addr net.Addr) bool {
+	var ip net.IP
+	switch a := addr.(type) {
+	case *net.TCPAddr:
+		ip = a.IP
+	case *net.UDPAddr:
+		ip = a.IP
+	default:
+		return false
+	}
+	for _, allowed := range config.CONFIG.LocalDNS.AllowTransfer {
+		if _, cidr, err := net.ParseCIDR(allowed); err == nil {
+			if cidr.Contains(ip) {
+				return true
+			}
+		} else if a := net.ParseIP(allowed); a != nil && a.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// serveTransfer answers AXFR, and IXFR from journal, IXFR falls back to AXFR if the journal
+// does not cover the serial of client
+func (z *localZone) serveTransfer(w miekg.ResponseWriter, req *miekg.Msg) {
+	q := req.Question[0]
+	m := new(miekg.Msg)
+	m.SetReply(req)
+	if !transferAllowed(w.RemoteAddr()) {
+		logrus.Warnf("local dns: refuse transfer of %s to %s", z.origin, w.RemoteAddr())
+		m.SetRcode(req, miekg.RcodeRefused)
+		w.WriteMsg(m)
+		return
+	}
+	if strings.ToLower(q.Name) != z.origin {
+		m.SetRcode(req, miekg.RcodeNotAuth)
+		w.WriteMsg(m)
+		return
+	}
+
+	var rrs []miekg.RR
+	if q.Qtype == miekg.TypeIXFR {
+		rrs = z.ixfr(req)
+	}
+	if rrs == nil {
+		if isUDP(w.RemoteAddr()) {
+			m.SetRcode(req, miekg.RcodeRefused)
+			w.WriteMsg(m)
+			return
+		}
+		rrs = append(append([]miekg.RR{z.soa}, z.rrs...), z.soa)
+	}
+	// a large IXFR over udp is answered by SOA only, client retries over tcp, rfc 1995 2
+	if isUDP(w.RemoteAddr()) && len(rrs) > 1 {
+		m.Authoritative = true
+		m.Answer = []miekg.RR{z.soa}
+		w.WriteMsg(m)
+		return
+	}
+
+	logrus.Infof("local dns: transfer %s serial %d to %s, %d records", z.origin, z.soa.Serial, w.RemoteAddr(), len(rrs))
+	ch := make(chan *miekg.Envelope)
+	go func() {
+		for len(rrs) > 0 {
+			n := localXfrChunk
+			if n > len(rrs) {
+				n = len(rrs)
+			}
+			ch <- &miekg.Envelope{RR: rrs[:n]}
+			rrs = rrs[n:]
+		}
+		close(ch)
+	}()
+	tr := new(miekg.Transfer)
+	if err := tr.Out(w, req, ch); err != nil {
+		logrus.Warnf("local dns: transfer %s to %s error:%v", z.origin, w.RemoteAddr(), err)
+		// drain the channel so the sender exits
+		for range ch {
+		}
+	}
+}
+
+// ixfr returns the incremental transfer since the serial of the client as rfc 1995 4, nil if the
+// journal does not cover it
+func (z *localZone) ixfr(req *miekg.Msg) []miekg.RR {
+	var client *miekg.SOA
+	for _, rr := range req.Ns {
+		if soa, ok := rr.(*miekg.SOA); ok {
+			client = soa
+		}
+	}
+	if client == nil {
+		return nil
+	}
+	if client.Serial == z.soa.Serial {
+		return []miekg.RR{z.soa}
+	}
+	changes, ok := z.journal(client.Serial)
+	if !ok {
+		return nil
+	}
+
+	// each serial is a sequence of SOA(old), deleted records, SOA(new), added records
+	res := []miekg.RR{z.soa}
+	for i := 0; i < len(changes); {
+		serial := changes[i].Serial
+		var deleted, added []miekg.RR
+		for ; i < len(changes) && changes[i].Serial == serial; i++ {
+			rr, err := miekg.NewRR(changes[i].RR)
+			if err != nil {
+				logrus.Errorf("local dns: journal %d of %s error:%v", changes[i].ID, z.origin, err)
+				return nil
+			}
+			if changes[i].Deleted {
+				deleted = append(deleted, rr)
+			} else {
+				added = append(added, rr)
+			}
+		}
+		res = append(res, z.serialSOA(uint32(serial)-1))
+		res = append(res, deleted...)
+		res = append(res, z.serialSOA(uint32(serial)))
+		res = append(res, added...)
+	}
+	return append(res, z.soa)
+}
+
+func (z *localZone) serialSOA(serial uint32) miekg.RR {
+	soa := miekg.Copy(z.soa).(*miekg.SOA)
+	soa.Serial = serial
+	return soa
+}
+
+// notifyLocal tells the secondaries in notify of local_dns config that zone is changed, rfc 1996
+func notifyLocal(zone string) {
+	for _, addr := range config.CONFIG.LocalDNS.Notify {
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			addr = net.JoinHostPort(addr, "53")
+		}
+		m := new(miekg.Msg)
+		m.SetNotify(miekg.Fqdn(strings.ToLower(zone)))
+		c := &miekg.Client{Timeout: localQueryTimeout}
+		for try := 1; ; try++ {
+			res, _, err := c.Exchange(m, addr)
+			if err == nil && res.Rcode == miekg.RcodeSuccess {
+				logrus.Infof("local dns: notified %s of zone %s", addr, zone)
+				break
+			}
+			if try >= localNotifyTries {
+				logrus.Warnf("local dns: notify %s of zone %s error:%v %v", addr, zone, err, res)
+				break
+			}
+			time.Sleep(localNotifyWait)
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package dns
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"testing"
+
+	miekg "github.com/miekg/dns"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"domain0/config"
+	"domain0/models"
+)
+
+// testLocalZone builds the served zone example.com. in memory from records in presentation format
+func testLocalZone(t *testing.T, serial uint32, records ...string) *localZone {
+	t.Helper()
+	z := &localZone{domain: models.Domain{Name: "example.com"}, origin: "example.com."}
+	z.domain.ID = 1
+	z.soa = z.newSOA(serial)
+	for _, s := range records {
+		rr, err := miekg.NewRR(s)
+		if err != nil {
+			t.Fatalf("NewRR(%q) error: %v", s, err)
+		}
+		z.rrs = append(z.rrs, rr)
+	}
+	return z
+}
+
+// rrSummary lists owner and type of the records, and the serial of SOA, to compare sections
+func rrSummary(rrs []miekg.RR) []string {
+	res := []string{}
+	for _, rr := range rrs {
+		s := rr.Header().Name + " " + miekg.TypeToString[rr.Header().Rrtype]
+		if soa, ok := rr.(*miekg.SOA); ok {
+			s += fmt.Sprintf(" %d", soa.Serial)
+		}
+		res = append(res, s)
+	}
+	return res
+}
+
+func TestLocalZoneAnswer(t *testing.T) {
+	z := testLocalZone(t, 100,
+		"example.com. 600 IN NS ns.example.com.",
+		"example.com. 600 IN A 192.0.2.1",
+		"ns.example.com. 600 IN A 192.0.2.10",
+		"www.example.com. 600 IN CNAME web.example.com.",
+		"web.example.com. 600 IN A 192.0.2.2",
+		"out.example.com. 600 IN CNAME example.net.",
+		"dangling.example.com. 600 IN CNAME nope.example.com.",
+		"loop1.example.com. 600 IN CNAME loop2.example.com.",
+		"loop2.example.com. 600 IN CNAME loop1.example.com.",
+		"a.b.example.com. 600 IN A 192.0.2.3",
+		"*.wild.example.com. 600 IN TXT \"wild\"",
+		"sub.example.com. 600 IN NS ns1.sub.example.com.",
+		"ns1.sub.example.com. 600 IN A 192.0.2.53",
+		"deleg.example.com. 600 IN CNAME host.sub.example.com.",
+	)
+
+	loop := make([]string, localCNAMEHops)
+	for i := range loop {
+		loop[i] = fmt.Sprintf("loop%d.example.com. CNAME", i%2+1)
+	}
+	tests := []struct {
+		name   string
+		qname  string
+		qtype  uint16
+		rcode  int
+		aa     bool
+		answer []string
+		ns     []string
+		extra  []string
+	}{
+		{"apex", "example.com.", miekg.TypeA, miekg.RcodeSuccess, true,
+			[]string{"example.com. A"}, []string{}, []string{}},
+		{"case insensitive", "EXAMPLE.com.", miekg.TypeA, miekg.RcodeSuccess, true,
+			[]string{"example.com. A"}, []string{}, []string{}},
+		{"apex NS with glue", "example.com.", miekg.TypeNS, miekg.RcodeSuccess, true,
+			[]string{"example.com. NS"}, []string{}, []string{"ns.example.com. A"}},
+		{"NODATA", "web.example.com.", miekg.TypeAAAA, miekg.RcodeSuccess, true,
+			[]string{}, []string{"example.com. SOA 100"}, []string{}},
+		{"NXDOMAIN", "nope.example.com.", miekg.TypeA, miekg.RcodeNameError, true,
+			[]string{}, []string{"example.com. SOA 100"}, []string{}},
+		{"empty non-terminal is NODATA", "b.example.com.", miekg.TypeA, miekg.RcodeSuccess, true,
+			[]string{}, []string{"example.com. SOA 100"}, []string{}},
+		{"wildcard", "x.wild.example.com.", miekg.TypeTXT, miekg.RcodeSuccess, true,
+			[]string{"x.wild.example.com. TXT"}, []string{}, []string{}},
+		{"wildcard below wildcard", "y.x.wild.example.com.", miekg.TypeTXT, miekg.RcodeSuccess, true,
+			[]string{"y.x.wild.example.com. TXT"}, []string{}, []string{}},
+		{"wildcard NODATA", "x.wild.example.com.", miekg.TypeA, miekg.RcodeSuccess, true,
+			[]string{}, []string{"example.com. SOA 100"}, []string{}},
+		{"wildcard not for its encloser", "wild.example.com.", miekg.TypeTXT, miekg.RcodeSuccess, true,
+			[]string{}, []string{"example.com. SOA 100"}, []string{}},
+		{"CNAME chain", "www.example.com.", miekg.TypeA, miekg.RcodeSuccess, true,
+			[]string{"www.example.com. CNAME", "web.example.com. A"}, []string{}, []string{}},
+		{"CNAME itself", "www.example.com.", miekg.TypeCNAME, miekg.RcodeSuccess, true,
+			[]string{"www.example.com. CNAME"}, []string{}, []string{}},
+		{"CNAME out of zone", "out.example.com.", miekg.TypeA, miekg.RcodeSuccess, true,
+			[]string{"out.example.com. CNAME"}, []string{}, []string{}},
+		{"CNAME to NXDOMAIN", "dangling.example.com.", miekg.TypeA, miekg.RcodeSuccess, true,
+			[]string{"dangling.example.com. CNAME"}, []string{"example.com. SOA 100"}, []string{}},
+		{"CNAME loop", "loop1.example.com.", miekg.TypeA, miekg.RcodeSuccess, true,
+			loop, []string{}, []string{}},
+		{"referral", "host.sub.example.com.", miekg.TypeA, miekg.RcodeSuccess, false,
+			[]string{}, []string{"sub.example.com. NS"}, []string{"ns1.sub.example.com. A"}},
+		{"referral at zone cut", "sub.example.com.", miekg.TypeNS, miekg.RcodeSuccess, false,
+			[]string{}, []string{"sub.example.com. NS"}, []string{"ns1.sub.example.com. A"}},
+		{"CNAME to delegation", "deleg.example.com.", miekg.TypeA, miekg.RcodeSuccess, true,
+			[]string{"deleg.example.com. CNAME"}, []string{"sub.example.com. NS"}, []string{"ns1.sub.example.com. A"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := new(miekg.Msg)
+			req.SetQuestion(tt.qname, tt.qtype)
+			m := new(miekg.Msg)
+			m.SetReply(req)
+			z.answer(m, req.Question[0])
+
+			if m.Rcode != tt.rcode {
+				t.Errorf("rcode = %s, want %s", miekg.RcodeToString[m.Rcode], miekg.RcodeToString[tt.rcode])
+			}
+			if m.Authoritative != tt.aa {
+				t.Errorf("authoritative = %v, want %v", m.Authoritative, tt.aa)
+			}
+			if got := rrSummary(m.Answer); !reflect.DeepEqual(got, tt.answer) {
+				t.Errorf("answer = %q, want %q", got, tt.answer)
+			}
+			if got := rrSummary(m.Ns); !reflect.DeepEqual(got, tt.ns) {
+				t.Errorf("authority = %q, want %q", got, tt.ns)
+			}
+			if got := rrSummary(m.Extra); !reflect.DeepEqual(got, tt.extra) {
+				t.Errorf("additional = %q, want %q", got, tt.extra)
+			}
+		})
+	}
+}
+
+// useTestLocalStore stores local zones in an in-memory database for the test
+func useTestLocalStore(t *testing.T) {
+	t.Helper()
+	store, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open database error: %v", err)
+	}
+	// every connection has its own in-memory database
+	if db, err := store.DB(); err == nil {
+		db.SetMaxOpenConns(1)
+	}
+	if err := store.AutoMigrate(&models.LocalZoneChange{}); err != nil {
+		t.Fatalf("migrate error: %v", err)
+	}
+	old := localStore
+	localStore = store
+	t.Cleanup(func() {
+		localStore = old
+		if db, err := store.DB(); err == nil {
+			db.Close()
+		}
+	})
+}
+
+func TestLocalZoneIXFR(t *testing.T) {
+	useTestLocalStore(t)
+	journal := []models.LocalZoneChange{
+		{DomainId: 1, Serial: 101, Deleted: true, RR: "example.com.\t600\tIN\tA\t192.0.2.1"},
+		{DomainId: 1, Serial: 101, RR: "example.com.\t600\tIN\tA\t192.0.2.9"},
+		{DomainId: 1, Serial: 102, RR: "www.example.com.\t600\tIN\tCNAME\texample.com."},
+		{DomainId: 2, Serial: 101, RR: "other.example.org.\t600\tIN\tA\t192.0.2.1"},
+	}
+	if err := localStore.Create(&journal).Error; err != nil {
+		t.Fatalf("create journal error: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		zone   uint32 // serial of zone
+		client uint32 // serial of client, 0 for no SOA in request
+		want   []string
+	}{
+		{"up to date", 102, 102, []string{"example.com. SOA 102"}},
+		{"two serials", 102, 100, []string{
+			"example.com. SOA 102",
+			"example.com. SOA 100", "example.com. A",
+			"example.com. SOA 101", "example.com. A",
+			"example.com. SOA 101",
+			"example.com. SOA 102", "www.example.com. CNAME",
+			"example.com. SOA 102",
+		}},
+		{"last serial", 102, 101, []string{
+			"example.com. SOA 102",
+			"example.com. SOA 101",
+			"example.com. SOA 102", "www.example.com. CNAME",
+			"example.com. SOA 102",
+		}},
+		{"older than journal", 102, 99, nil},
+		{"journal behind zone", 103, 100, nil},
+		{"no SOA of client", 102, 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			z := testLocalZone(t, tt.zone)
+			req := new(miekg.Msg)
+			req.SetIxfr(z.origin, tt.client, "", "")
+			if tt.client == 0 {
+				req.Ns = nil
+			}
+			got := z.ixfr(req)
+			if tt.want == nil {
+				if got != nil {
+					t.Errorf("ixfr() = %q, want AXFR fallback", rrSummary(got))
+				}
+				return
+			}
+			if !reflect.DeepEqual(rrSummary(got), tt.want) {
+				t.Errorf("ixfr() = %q, want %q", rrSummary(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestTransferAllowed(t *testing.T) {
+	old := config.CONFIG.LocalDNS.AllowTransfer
+	config.CONFIG.LocalDNS.AllowTransfer = []string{"192.0.2.53", "198.51.100.0/24", "2001:db8::/32"}
+	t.Cleanup(func() { config.CONFIG.LocalDNS.AllowTransfer = old })
+
+	tests := []struct {
+		name string
+		addr net.Addr
+		want bool
+	}{
+		{"listed ip over tcp", &net.TCPAddr{IP: net.ParseIP("192.0.2.53"), Port: 53}, true},
+		{"listed ip over udp", &net.UDPAddr{IP: net.ParseIP("192.0.2.53"), Port: 53}, true},
+		{"in cidr", &net.TCPAddr{IP: net.ParseIP("198.51.100.7")}, true},
+		{"in ipv6 cidr", &net.TCPAddr{IP: net.ParseIP("2001:db8::1")}, true},
+		{"not listed", &net.TCPAddr{IP: net.ParseIP("192.0.2.54")}, false},
+		{"unknown address", &net.UnixAddr{Name: "/tmp/dns.sock"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := transferAllowed(tt.addr); got != tt.want {
+				t.Errorf("transferAllowed(%v) = %v, want %v", tt.addr, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,404 @@
+package dns
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	miekg "github.com/miekg/dns"
+	"github.com/sirupsen/logrus"
+
+	"domain0/models"
+)
+
+// PowerDNS is a record of a zone on a PowerDNS Authoritative server, managed by its http api.
+// The api works on RRsets, a record is changed by replacing the whole RRset it belongs to,
+// so the ttl is shared by the records of the same name and type. Records have no id, see rrId
+type PowerDNS struct {
+	Record Record
+	Domain models.Domain `json:"-"`
+}
+
+type PowerDNSList struct {
+	Success bool          `json:"success"`
+	Errors  []interface{} `json:"errors"`
+	Result  []PowerDNS    `json:"result"`
+}
+
+// pdnsZone and pdnsRRset are the zone and RRset of PowerDNS api
+type pdnsZone struct {
+	Name   string      `json:"name"`
+	RRsets []pdnsRRset `json:"rrsets"`
+}
+
+type pdnsRRset struct {
+	Name       string       `json:"name"`
+	Type       string       `json:"type"`
+	TTL        int          `json:"ttl,omitempty"`
+	ChangeType string       `json:"changetype,omitempty"` // REPLACE or DELETE
+	Records    []pdnsRecord `json:"records"`
+}
+
+type pdnsRecord struct {
+	Content  string `json:"content"`
+	Disabled bool   `json:"disabled"`
+}
+
+var pdnsClient *http.Client
+
+func init() {
+	// do not use default http client, it has no timeout
+	pdnsClient = &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			Dial:                (&net.Dialer{Timeout: 5 * time.Second}).Dial,
+			TLSHandshakeTimeout: 5 * time.Second,
+		},
+	}
+
+	RegisterProvider(Provider{
+		Name:        "powerdns",
+		DisplayName: "PowerDNS Authoritative",
+		Capabilities: Capabilities{
+			RecordTypes: []string{"A", "AAAA", "CNAME", "MX", "TXT", "NS", "SRV", "CAA", "PTR"},
+			Proxy:       false,
+			Lines:       false,
+			Comments:    false,
+			SplitTXT:    true,
+			MaxTTL:      2147483647,
+			Extensions: []ExtensionSpec{
+				{Key: ExtEnabled, Type: ExtBool},
+			},
+		},
+		Credentials: []CredentialField{
+			{Field: "api_id", Label: "Server ID (usually localhost)", Secret: false},
+			{Field: "api_secret", Label: "API Key", Secret: true},
+			{Field: "endpoint", Label: "API URL, e.g. http://127.0.0.1:8081", Secret: false},
+		},
+		NewObj:     func(d models.Domain) DnsObj { return &PowerDNS{Domain: d} },
+		NewObjList: func() DnsObjList { return &PowerDNSList{} },
+	})
+}
+
+// pdnsConn is the zone url of PowerDNS api and the key to call it
+type pdnsConn struct {
+	zone    string
+	zoneUrl string
+	apiKey  string
+}
+
+func newPdnsConn(d *models.Domain) (*pdnsConn, error) {
+	serverId, apiKey, err := d.ExtractAuth()
+	if err != nil {
+		return nil, err
+	}
+	if d.Endpoint == "" {
+		return nil, errors.New("api url of powerdns is empty")
+	}
+	base := strings.TrimSuffix(strings.TrimSuffix(d.Endpoint, "/"), "/api/v1")
+	zone := miekg.Fqdn(strings.ToLower(d.Name))
+	return &pdnsConn{
+		zone:    zone,
+		zoneUrl: fmt.Sprintf("%s/api/v1/servers/%s/zones/%s", base, url.PathEscape(serverId), url.PathEscape(zone)),
+		apiKey:  apiKey,
+	}, nil
+}
+
+func (c *pdnsConn) do(method string, body interface{}, res interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, c.zoneUrl, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-API-Key", c.apiKey)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := pdnsClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode/100 != 2 {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Error != "" {
+			return fmt.Errorf("powerdns %s zone %s: %s", method, c.zone, apiErr.Error)
+		}
+		return fmt.Errorf("powerdns %s zone %s: %s", method, c.zone, resp.Status)
+	}
+	if res == nil || len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, res)
+}
+
+// rrsets gets the RRsets of zone, SOA and dnssec records maintained by the server are dropped
+func (c *pdnsConn) rrsets() ([]pdnsRRset, error) {
+	var zone pdnsZone
+	if err := c.do(http.MethodGet, nil, &zone); err != nil {
+		return nil, err
+	}
+	res := make([]pdnsRRset, 0, len(zone.RRsets))
+	for _, set := range zone.RRsets {
+		switch set.Type {
+		case "SOA", "RRSIG", "NSEC", "NSEC3", "NSEC3PARAM", "DNSKEY", "CDS", "CDNSKEY":
+			continue
+		}
+		res = append(res, set)
+	}
+	return res, nil
+}
+
+// patch replaces the RRsets in one request, RRsets without records are deleted
+func (c *pdnsConn) patch(sets ...pdnsRRset) error {
+	for i := range sets {
+		sets[i].ChangeType = "REPLACE"
+		if len(sets[i].Records) == 0 {
+			sets[i].ChangeType = "DELETE"
+			sets[i].TTL = 0
+			sets[i].Records = []pdnsRecord{}
+		}
+	}
+	return c.do(http.MethodPatch, struct {
+		RRsets []pdnsRRset `json:"rrsets"`
+	}{sets}, nil)
+}
+
+// pdnsRR parses a record of the RRset, the content of PowerDNS api is the rdata in presentation format
+func pdnsRR(set *pdnsRRset, rec *pdnsRecord) (miekg.RR, error) {
+	return miekg.NewRR(fmt.Sprintf("%s %d IN %s %s", set.Name, set.TTL, set.Type, rec.Content))
+}
+
+func pdnsRecordOf(zone string, set *pdnsRRset, rec *pdnsRecord) (Record, error) {
+	rr, err := pdnsRR(set, rec)
+	if err != nil {
+		return Record{}, err
+	}
+	r := RecordFromRR(zone, rr)
+	r.Id = rrId(rr)
+	r.Extensions = Extensions{ExtEnabled: !rec.Disabled}
+	return r, nil
+}
+
+// find returns the RRset and the index of the record with the id in it
+func (c *pdnsConn) find(sets []pdnsRRset, id string) (*pdnsRRset, int, error) {
+	for i := range sets {
+		for j := range sets[i].Records {
+			if rr, err := pdnsRR(&sets[i], &sets[i].Records[j]); err == nil && rrId(rr) == id {
+				return &sets[i], j, nil
+			}
+		}
+	}
+	return nil, 0, fmt.Errorf("record %s is not found in zone %s", id, c.zone)
+}
+
+// rrsetOf returns a copy of the RRset of the name and type, a new empty one if there is none
+func rrsetOf(sets []pdnsRRset, name, typ string) pdnsRRset {
+	for _, set := range sets {
+		if strings.EqualFold(set.Name, name) && set.Type == typ {
+			set.Records = append([]pdnsRecord(nil), set.Records...)
+			return set
+		}
+	}
+	return pdnsRRset{Name: name, Type: typ}
+}
+
+// pdnsContent converts the record to a record of PowerDNS api and the owner name and type of its RRset
+func (p *PowerDNS) pdnsContent() (miekg.RR, pdnsRecord, error) {
+	rr, err := p.Record.ToRR(p.Domain.Name)
+	if err != nil {
+		return nil, pdnsRecord{}, err
+	}
+	rec := pdnsRecord{
+		Content:  strings.TrimSpace(strings.TrimPrefix(rr.String(), rr.Header().String())),
+		Disabled: !p.Record.Extensions.Bool(ExtEnabled, true),
+	}
+	return rr, rec, nil
+}
+
+func (p *PowerDNS) ToRecord() Record {
+	return p.Record
+}
+
+func (p *PowerDNS) FromRecord(r Record) {
+	p.Record = r
+}
+
+func (p *PowerDNS) Create() error {
+	conn, err := newPdnsConn(&p.Domain)
+	if err != nil {
+		return err
+	}
+	rr, rec, err := p.pdnsContent()
+	if err != nil {
+		return err
+	}
+	sets, err := conn.rrsets()
+	if err != nil {
+		return err
+	}
+
+	logrus.Info("Create DNS record: ", rr)
+	set := rrsetOf(sets, rr.Header().Name, miekg.TypeToString[rr.Header().Rrtype])
+	set.TTL = int(rr.Header().Ttl)
+	set.Records = append(set.Records, rec)
+	if err := conn.patch(set); err != nil {
+		return err
+	}
+	p.Record.Id = rrId(rr)
+	return nil
+}
+
+func (p *PowerDNS) Get(id string) error {
+	conn, err := newPdnsConn(&p.Domain)
+	if err != nil {
+		return err
+	}
+	sets, err := conn.rrsets()
+	if err != nil {
+		return err
+	}
+	set, i, err := conn.find(sets, id)
+	if err != nil {
+		return err
+	}
+	p.Record, err = pdnsRecordOf(p.Domain.Name, set, &set.Records[i])
+	return err
+}
+
+// Update replaces the record of the id, the RRsets of the old and the new record are patched together
+func (p *PowerDNS) Update() error {
+	if p.Record.Id == "" {
+		return errors.New("update without record id")
+	}
+	conn, err := newPdnsConn(&p.Domain)
+	if err != nil {
+		return err
+	}
+	rr, rec, err := p.pdnsContent()
+	if err != nil {
+		return err
+	}
+	sets, err := conn.rrsets()
+	if err != nil {
+		return err
+	}
+	old, i, err := conn.find(sets, p.Record.Id)
+	if err != nil {
+		return err
+	}
+
+	logrus.Info("Update DNS record: ", p.Record.Id, " => ", rr)
+	oldSet := *old
+	oldSet.Records = append(append([]pdnsRecord(nil), old.Records[:i]...), old.Records[i+1:]...)
+	name, typ := rr.Header().Name, miekg.TypeToString[rr.Header().Rrtype]
+	if strings.EqualFold(old.Name, name) && old.Type == typ {
+		oldSet.TTL = int(rr.Header().Ttl)
+		oldSet.Records = append(oldSet.Records, rec)
+		err = conn.patch(oldSet)
+	} else {
+		set := rrsetOf(sets, name, typ)
+		set.TTL = int(rr.Header().Ttl)
+		set.Records = append(set.Records, rec)
+		err = conn.patch(oldSet, set)
+	}
+	if err != nil {
+		return err
+	}
+	p.Record.Id = rrId(rr)
+	return nil
+}
+
+func (p *PowerDNS) Delete() error {
+	conn, err := newPdnsConn(&p.Domain)
+	if err != nil {
+		return err
+	}
+	sets, err := conn.rrsets()
+	if err != nil {
+		return err
+	}
+	old, i, err := conn.find(sets, p.Record.Id)
+	if err != nil {
+		return err
+	}
+
+	logrus.Info("Delete DNS record: ", old.Name, " ", old.Type, " ", old.Records[i].Content)
+	set := *old
+	set.Records = append(append([]pdnsRecord(nil), old.Records[:i]...), old.Records[i+1:]...)
+	return conn.patch(set)
+}
+
+func (l *PowerDNSList) GetDNSList(d *models.Domain) error {
+	logrus.Infof("Get DNS records of domain: %s", d.Name)
+
+	conn, err := newPdnsConn(d)
+	if err != nil {
+		l.Errors = []interface{}{err.Error()}
+		return nil
+	}
+	sets, err := conn.rrsets()
+	if err != nil {
+		l.Errors = []interface{}{err.Error()}
+		return nil
+	}
+	for i := range sets {
+		for j := range sets[i].Records {
+			r, err := pdnsRecordOf(d.Name, &sets[i], &sets[i].Records[j])
+			if err != nil {
+				logrus.Warnf("Skip record %s %s of domain %s: %v", sets[i].Name, sets[i].Type, d.Name, err)
+				continue
+			}
+			l.Result = append(l.Result, PowerDNS{Record: r, Domain: *d})
+		}
+	}
+	l.Success = true
+	return nil
+}
+
+func (l *PowerDNSList) MultipleSelectWithIds(ids []string, r *[]interface{}) error {
+	for i := range l.Result {
+		for _, id := range ids {
+			if l.Result[i].Record.Id == id {
+				*r = append(*r, &l.Result[i])
+			}
+		}
+	}
+	if len(ids) != len(*r) {
+		return errors.New("some DNS records are not found")
+	}
+	return nil
+}
+
+func (l *PowerDNSList) Records() ([]Record, error) {
+	if !l.Success {
+		return nil, listError(l.Errors)
+	}
+	res := make([]Record, 0, len(l.Result))
+	for i := range l.Result {
+		res = append(res, l.Result[i].ToRecord())
+	}
+	return res, nil
+}
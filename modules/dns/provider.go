@@ -0,0 +1,95 @@
+package dns
+
+import (
+	"domain0/models"
+	"sort"
+	"sync"
+)
+
+// DnsObj is a single record of a vendor, it knows how to sync itself with the vendor api
+type DnsObj interface {
+	Create() error
+	Get(id string) error
+	Update() error
+	Delete() error
+	ToRecord() Record
+	FromRecord(r Record)
+}
+
+// DnsObjList is the record list of a domain from a vendor
+type DnsObjList interface {
+	GetDNSList(d *models.Domain) error
+	MultipleSelectWithIds(ids []string, r *[]interface{}) error
+	Records() ([]Record, error)
+}
+
+// Capabilities describes what a vendor supports, used by frontend to render forms
+type Capabilities struct {
+	RecordTypes []string `json:"record_types"`
+	Proxy       bool     `json:"proxy"`    // records can be proxied, e.g. cloudflare orange cloud
+	Lines       bool     `json:"lines"`    // records can be bound to resolve lines (ISP / region)
+	Comments    bool     `json:"comments"` // records can carry a comment / remark
+	MinTTL      int      `json:"min_ttl"`  // 0 for no bound, the lowest of all plans of the vendor
+	MaxTTL      int      `json:"max_ttl"`  // 0 for no bound
+	AutoTTL     bool     `json:"auto_ttl"` // ttl 1 means automatic, e.g. cloudflare
+
+	SplitTXT bool `json:"split_txt"` // TXT longer than 255 bytes is split into strings, or it is at most 255 bytes
+
+	Extensions []ExtensionSpec `json:"extensions"`
+}
+
+// CredentialField describes how a domain field is used by the vendor
+type CredentialField struct {
+	Field  string `json:"field"` // field name in mw.DomainInfoUpdate, e.g. api_id
+	Label  string `json:"label"`
+	Secret bool   `json:"secret"`
+}
+
+type Provider struct {
+	Name         string            `json:"name"` // value of models.Domain.Vendor
+	DisplayName  string            `json:"display_name"`
+	Capabilities Capabilities      `json:"capabilities"`
+	Credentials  []CredentialField `json:"credentials"`
+
+	NewObj     func(d models.Domain) DnsObj `json:"-"`
+	NewObjList func() DnsObjList            `json:"-"`
+}
+
+var (
+	providers   = map[string]*Provider{}
+	providersMu sync.RWMutex
+)
+
+// RegisterProvider makes a vendor available by its name, it is called in init() of each vendor
+func RegisterProvider(p Provider) {
+	if p.Name == "" || p.NewObj == nil || p.NewObjList == nil {
+		panic("dns: invalid provider " + p.Name)
+	}
+
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	if _, ok := providers[p.Name]; ok {
+		panic("dns: provider registered twice " + p.Name)
+	}
+	providers[p.Name] = &p
+}
+
+// LookupProvider returns the provider registered with the vendor name
+func LookupProvider(vendor string) (*Provider, bool) {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	p, ok := providers[vendor]
+	return p, ok
+}
+
+// Providers returns all registered providers sorted by name
+func Providers() []Provider {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	res := make([]Provider, 0, len(providers))
+	for _, p := range providers {
+		res = append(res, *p)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Name < res[j].Name })
+	return res
+}
@@ -0,0 +1,168 @@
+package dns
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Record is the vendor neutral shape of a dns record.
+// All /domain/:id/dns apis accept and return it, vendors convert it from and to their sdk types.
+type Record struct {
+	Id         string     `json:"id"`
+	Name       string     `json:"name"` // relative to the domain, "@" for the apex
+	Type       string     `json:"type"`
+	Content    string     `json:"content"`
+	TTL        int        `json:"ttl"`
+	Priority   uint16     `json:"priority"` // MX, SRV
+	Comment    string     `json:"comment"`
+	Extensions Extensions `json:"extensions,omitempty"`
+}
+
+// Extensions carries vendor specific fields of a record, keys and value types are declared by
+// Capabilities.Extensions of the provider
+type Extensions map[string]interface{}
+
+type ExtensionType string
+
+const (
+	ExtBool   ExtensionType = "bool"
+	ExtString ExtensionType = "string"
+	ExtObject ExtensionType = "object"
+)
+
+type ExtensionSpec struct {
+	Key  string        `json:"key"`
+	Type ExtensionType `json:"type"`
+}
+
+const (
+	ExtProxied = "proxied" // bool, proxy the record, cloudflare
+	ExtData    = "data"    // object, structured data of the record, cloudflare
+	ExtLine    = "line"    // string, resolve line, dnspod & aliyun
+	ExtEnabled = "enabled" // bool, pause the record without deleting it, dnspod, aliyun & powerdns
+)
+
+func (e Extensions) Bool(key string, defaultVal bool) bool {
+	if v, ok := e[key].(bool); ok {
+		return v
+	}
+	return defaultVal
+}
+
+func (e Extensions) String(key string, defaultVal string) string {
+	if v, ok := e[key].(string); ok {
+		return v
+	}
+	return defaultVal
+}
+
+func (e Extensions) Value(key string) interface{} {
+	return e[key]
+}
+
+// Check returns error if there is an extension not declared in specs or with the wrong type
+func (e Extensions) Check(specs []ExtensionSpec) error {
+	for key, val := range e {
+		if val == nil {
+			continue
+		}
+		var spec *ExtensionSpec
+		for i := range specs {
+			if specs[i].Key == key {
+				spec = &specs[i]
+				break
+			}
+		}
+		if spec == nil {
+			return fmt.Errorf("extension %s is not supported by the vendor", key)
+		}
+		ok := false
+		switch spec.Type {
+		case ExtBool:
+			_, ok = val.(bool)
+		case ExtString:
+			_, ok = val.(string)
+		case ExtObject:
+			_, ok = val.(map[string]interface{})
+		}
+		if !ok {
+			return fmt.Errorf("extension %s must be %s", key, spec.Type)
+		}
+	}
+	return nil
+}
+
+// RelativeName converts name to be relative to zone, the apex of zone becomes "@"
+func RelativeName(name, zone string) string {
+	name = strings.TrimSuffix(strings.ToLower(name), ".")
+	zone = strings.TrimSuffix(strings.ToLower(zone), ".")
+	if name == "" || name == "@" || name == zone {
+		return "@"
+	}
+	if strings.HasSuffix(name, "."+zone) {
+		return strings.TrimSuffix(name, "."+zone)
+	}
+	return name
+}
+
+// FQDN converts a relative name to the full name without the trailing dot
+func FQDN(name, zone string) string {
+	zone = strings.TrimSuffix(strings.ToLower(zone), ".")
+	rel := RelativeName(name, zone)
+	if rel == "@" {
+		return zone
+	}
+	return rel + "." + zone
+}
+
+// listError converts the errors of a failed GetDNSList to error
+func listError(errs []interface{}) error {
+	if len(errs) == 0 {
+		return errors.New("failed to get dns record list")
+	}
+	msgs := make([]string, 0, len(errs))
+	for _, e := range errs {
+		msgs = append(msgs, fmt.Sprint(e))
+	}
+	return errors.New(strings.Join(msgs, "; "))
+}
+
+// hostContent reports whether the content of record type is a host name
+func hostContent(typ string) bool {
+	switch strings.ToUpper(typ) {
+	case "CNAME", "NS", "PTR", "DNAME", "MX":
+		return true
+	}
+	return false
+}
+
+// NormalizedContent returns the content for comparing, host names are case insensitive
+// and may or may not be written with the trailing dot
+func (r *Record) NormalizedContent() string {
+	content := strings.TrimSpace(r.Content)
+	if hostContent(r.Type) {
+		return strings.TrimSuffix(strings.ToLower(content), ".")
+	}
+	if strings.EqualFold(r.Type, "SRV") {
+		fields := strings.Fields(strings.ToLower(content))
+		if len(fields) > 0 {
+			fields[len(fields)-1] = strings.TrimSuffix(fields[len(fields)-1], ".")
+		}
+		return strings.Join(fields, " ")
+	}
+	return content
+}
+
+// RRSetKey identifies the rrset of the record, records with the same name and type share it
+func (r *Record) RRSetKey() string {
+	return strings.ToLower(RelativeName(r.Name, "")) + " " + strings.ToUpper(r.Type)
+}
+
+// SameAs reports whether r and o are the same resource record, id, ttl, comment and
+// extensions are ignored
+func (r *Record) SameAs(o *Record) bool {
+	return r.RRSetKey() == o.RRSetKey() &&
+		r.Priority == o.Priority &&
+		r.NormalizedContent() == o.NormalizedContent()
+}
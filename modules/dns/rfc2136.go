@@ -0,0 +1,320 @@
+package dns
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	miekg "github.com/miekg/dns"
+	"github.com/sirupsen/logrus"
+
+	"domain0/models"
+)
+
+const (
+	rfc2136Timeout = 10 * time.Second
+	rfc2136Fudge   = 300
+)
+
+// RFC2136DNS is a record of a zone on an authoritative server, changed by rfc 2136 UPDATE
+// and listed by AXFR, both signed with TSIG. Records have no id on the server, see rrId
+type RFC2136DNS struct {
+	Record Record
+	Domain models.Domain `json:"-"`
+}
+
+type RFC2136DNSList struct {
+	Success bool          `json:"success"`
+	Errors  []interface{} `json:"errors"`
+	Result  []RFC2136DNS  `json:"result"`
+}
+
+func init() {
+	RegisterProvider(Provider{
+		Name:        "rfc2136",
+		DisplayName: "RFC 2136 (BIND, Knot, PowerDNS)",
+		Capabilities: Capabilities{
+			RecordTypes: []string{"A", "AAAA", "CNAME", "MX", "TXT", "NS", "SRV", "CAA", "PTR"},
+			Proxy:       false,
+			Lines:       false,
+			Comments:    false,
+			SplitTXT:    true,
+			MaxTTL:      2147483647,
+		},
+		Credentials: []CredentialField{
+			{Field: "api_id", Label: "TSIG Key Name", Secret: false},
+			{Field: "api_secret", Label: "TSIG Secret (base64, or algorithm:base64)", Secret: true},
+			{Field: "endpoint", Label: "Primary Server (host:port, SOA MNAME if empty)", Secret: false},
+		},
+		NewObj:     func(d models.Domain) DnsObj { return &RFC2136DNS{Domain: d} },
+		NewObjList: func() DnsObjList { return &RFC2136DNSList{} },
+	})
+}
+
+// rfc2136Conn is the primary server of a zone and the TSIG key to talk to it
+type rfc2136Conn struct {
+	zone      string
+	server    string
+	keyName   string
+	algorithm string
+	secret    string
+}
+
+func newRFC2136Conn(d *models.Domain) (*rfc2136Conn, error) {
+	keyName, secret, err := d.ExtractAuth()
+	if err != nil {
+		return nil, err
+	}
+	conn := &rfc2136Conn{
+		zone:      miekg.Fqdn(strings.ToLower(d.Name)),
+		keyName:   miekg.Fqdn(strings.ToLower(keyName)),
+		algorithm: miekg.HmacSHA256,
+		secret:    secret,
+	}
+	// secret may be prefixed with the algorithm, e.g. hmac-sha512:c2VjcmV0
+	if alg, s, ok := strings.Cut(secret, ":"); ok {
+		conn.algorithm = miekg.Fqdn(strings.ToLower(alg))
+		conn.secret = s
+	}
+
+	conn.server = d.Endpoint
+	if conn.server == "" {
+		if conn.server, err = primaryServer(conn.zone); err != nil {
+			return nil, err
+		}
+	}
+	if _, _, err := net.SplitHostPort(conn.server); err != nil {
+		conn.server = net.JoinHostPort(conn.server, "53")
+	}
+	return conn, nil
+}
+
+// primaryServer finds the primary server of zone by the MNAME of its SOA, as rfc 2136 suggests
+func primaryServer(zone string) (string, error) {
+	conf, err := miekg.ClientConfigFromFile("/etc/resolv.conf")
+	if err != nil {
+		return "", fmt.Errorf("no endpoint, and no resolver to find the primary server: %w", err)
+	}
+	m := new(miekg.Msg)
+	m.SetQuestion(zone, miekg.TypeSOA)
+	c := &miekg.Client{Timeout: rfc2136Timeout}
+	for _, s := range conf.Servers {
+		res, _, err := c.Exchange(m, net.JoinHostPort(s, conf.Port))
+		if err != nil || res.Rcode != miekg.RcodeSuccess {
+			continue
+		}
+		for _, rr := range res.Answer {
+			if soa, ok := rr.(*miekg.SOA); ok {
+				return strings.TrimSuffix(soa.Ns, "."), nil
+			}
+		}
+	}
+	return "", fmt.Errorf("no endpoint, and the SOA of %s is not found", zone)
+}
+
+func (c *rfc2136Conn) tsig() map[string]string {
+	return map[string]string{c.keyName: c.secret}
+}
+
+// update sends the UPDATE message signed with TSIG
+func (c *rfc2136Conn) update(remove, insert []miekg.RR) error {
+	m := new(miekg.Msg)
+	m.SetUpdate(c.zone)
+	if len(remove) > 0 {
+		m.Remove(remove)
+	}
+	if len(insert) > 0 {
+		m.Insert(insert)
+	}
+	m.SetTsig(c.keyName, c.algorithm, rfc2136Fudge, time.Now().Unix())
+
+	client := &miekg.Client{Net: "tcp", Timeout: rfc2136Timeout, TsigSecret: c.tsig()}
+	res, _, err := client.Exchange(m, c.server)
+	if err != nil {
+		return err
+	}
+	if res.Rcode != miekg.RcodeSuccess {
+		return fmt.Errorf("update zone %s: %s", c.zone, miekg.RcodeToString[res.Rcode])
+	}
+	return nil
+}
+
+// transfer lists the records of zone by AXFR, SOA and dnssec records maintained by the server are dropped
+func (c *rfc2136Conn) transfer() ([]miekg.RR, error) {
+	m := new(miekg.Msg)
+	m.SetAxfr(c.zone)
+	m.SetTsig(c.keyName, c.algorithm, rfc2136Fudge, time.Now().Unix())
+
+	t := &miekg.Transfer{
+		DialTimeout:  rfc2136Timeout,
+		ReadTimeout:  rfc2136Timeout,
+		WriteTimeout: rfc2136Timeout,
+		TsigSecret:   c.tsig(),
+	}
+	envelopes, err := t.In(m, c.server)
+	if err != nil {
+		return nil, err
+	}
+	var rrs []miekg.RR
+	for env := range envelopes {
+		if env.Error != nil {
+			return nil, fmt.Errorf("transfer zone %s: %w", c.zone, env.Error)
+		}
+		for _, rr := range env.RR {
+			switch rr.Header().Rrtype {
+			case miekg.TypeSOA, miekg.TypeRRSIG, miekg.TypeNSEC, miekg.TypeNSEC3, miekg.TypeNSEC3PARAM,
+				miekg.TypeDNSKEY, miekg.TypeCDS, miekg.TypeCDNSKEY:
+				continue
+			}
+			rrs = append(rrs, rr)
+		}
+	}
+	return rrs, nil
+}
+
+// find gets the resource record by id from the server
+func (c *rfc2136Conn) find(id string) (miekg.RR, error) {
+	rrs, err := c.transfer()
+	if err != nil {
+		return nil, err
+	}
+	for _, rr := range rrs {
+		if rrId(rr) == id {
+			return rr, nil
+		}
+	}
+	return nil, fmt.Errorf("record %s is not found in zone %s", id, c.zone)
+}
+
+func rfc2136Record(zone string, rr miekg.RR) Record {
+	r := RecordFromRR(zone, rr)
+	r.Id = rrId(rr)
+	return r
+}
+
+func (r *RFC2136DNS) ToRecord() Record {
+	return r.Record
+}
+
+func (r *RFC2136DNS) FromRecord(rec Record) {
+	r.Record = rec
+}
+
+func (r *RFC2136DNS) Create() error {
+	conn, err := newRFC2136Conn(&r.Domain)
+	if err != nil {
+		return err
+	}
+	rr, err := r.Record.ToRR(r.Domain.Name)
+	if err != nil {
+		return err
+	}
+
+	logrus.Info("Create DNS record: ", rr)
+	if err := conn.update(nil, []miekg.RR{rr}); err != nil {
+		return err
+	}
+	r.Record = rfc2136Record(r.Domain.Name, rr)
+	return nil
+}
+
+func (r *RFC2136DNS) Get(id string) error {
+	conn, err := newRFC2136Conn(&r.Domain)
+	if err != nil {
+		return err
+	}
+	rr, err := conn.find(id)
+	if err != nil {
+		return err
+	}
+	r.Record = rfc2136Record(r.Domain.Name, rr)
+	return nil
+}
+
+// Update replaces the record of the id with the new content in one UPDATE message
+func (r *RFC2136DNS) Update() error {
+	if r.Record.Id == "" {
+		return errors.New("update without record id")
+	}
+	conn, err := newRFC2136Conn(&r.Domain)
+	if err != nil {
+		return err
+	}
+	old, err := conn.find(r.Record.Id)
+	if err != nil {
+		return err
+	}
+	rr, err := r.Record.ToRR(r.Domain.Name)
+	if err != nil {
+		return err
+	}
+
+	logrus.Info("Update DNS record: ", old, " => ", rr)
+	if err := conn.update([]miekg.RR{old}, []miekg.RR{rr}); err != nil {
+		return err
+	}
+	r.Record = rfc2136Record(r.Domain.Name, rr)
+	return nil
+}
+
+func (r *RFC2136DNS) Delete() error {
+	conn, err := newRFC2136Conn(&r.Domain)
+	if err != nil {
+		return err
+	}
+	rr, err := conn.find(r.Record.Id)
+	if err != nil {
+		return err
+	}
+
+	logrus.Info("Delete DNS record: ", rr)
+	return conn.update([]miekg.RR{rr}, nil)
+}
+
+func (l *RFC2136DNSList) GetDNSList(d *models.Domain) error {
+	logrus.Infof("Get DNS records of domain: %s", d.Name)
+
+	conn, err := newRFC2136Conn(d)
+	if err != nil {
+		l.Errors = []interface{}{err.Error()}
+		return nil
+	}
+	rrs, err := conn.transfer()
+	if err != nil {
+		l.Errors = []interface{}{err.Error()}
+		return nil
+	}
+	l.Result = make([]RFC2136DNS, 0, len(rrs))
+	for _, rr := range rrs {
+		l.Result = append(l.Result, RFC2136DNS{Record: rfc2136Record(d.Name, rr), Domain: *d})
+	}
+	l.Success = true
+	return nil
+}
+
+func (l *RFC2136DNSList) MultipleSelectWithIds(ids []string, r *[]interface{}) error {
+	for i := range l.Result {
+		for _, id := range ids {
+			if l.Result[i].Record.Id == id {
+				*r = append(*r, &l.Result[i])
+			}
+		}
+	}
+	if len(ids) != len(*r) {
+		return errors.New("some DNS records are not found")
+	}
+	return nil
+}
+
+func (l *RFC2136DNSList) Records() ([]Record, error) {
+	if !l.Success {
+		return nil, listError(l.Errors)
+	}
+	res := make([]Record, 0, len(l.Result))
+	for i := range l.Result {
+		res = append(res, l.Result[i].ToRecord())
+	}
+	return res, nil
+}
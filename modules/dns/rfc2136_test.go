@@ -0,0 +1,222 @@
+package dns
+
+import (
+	"encoding/base64"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	miekg "github.com/miekg/dns"
+
+	"domain0/models"
+)
+
+var testTsigSecret = base64.StdEncoding.EncodeToString([]byte("domain0 rfc2136 test secret"))
+
+// testRFC2136Server is a primary server of example.com. which applies UPDATE and answers AXFR,
+// both signed with TSIG
+type testRFC2136Server struct {
+	mu      sync.Mutex
+	soa     miekg.RR
+	zone    []miekg.RR
+	updates int // UPDATE messages received
+}
+
+// state returns the UPDATE messages received and the records of zone
+func (s *testRFC2136Server) state() (int, []miekg.RR) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.updates, append([]miekg.RR{}, s.zone...)
+}
+
+func (s *testRFC2136Server) ServeDNS(w miekg.ResponseWriter, req *miekg.Msg) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m := new(miekg.Msg)
+	m.SetReply(req)
+	tsig := req.IsTsig()
+	if tsig == nil || w.TsigStatus() != nil {
+		m.SetRcode(req, miekg.RcodeNotAuth)
+		w.WriteMsg(m)
+		return
+	}
+
+	if req.Opcode == miekg.OpcodeUpdate {
+		s.updates++
+		for _, rr := range req.Ns {
+			if rr.Header().Class != miekg.ClassNONE {
+				s.zone = append(s.zone, rr)
+				continue
+			}
+			removed := miekg.Copy(rr)
+			removed.Header().Class = miekg.ClassINET
+			zone := s.zone[:0]
+			for _, z := range s.zone {
+				if !miekg.IsDuplicate(z, removed) {
+					zone = append(zone, z)
+				}
+			}
+			s.zone = zone
+		}
+		m.SetTsig(tsig.Hdr.Name, tsig.Algorithm, tsig.Fudge, time.Now().Unix())
+		w.WriteMsg(m)
+		return
+	}
+
+	ch := make(chan *miekg.Envelope, 1)
+	ch <- &miekg.Envelope{RR: append(append([]miekg.RR{s.soa}, s.zone...), s.soa)}
+	close(ch)
+	new(miekg.Transfer).Out(w, req, ch)
+}
+
+// startTestRFC2136Server serves example.com. over tcp and returns the domain using it
+func startTestRFC2136Server(t *testing.T) (*testRFC2136Server, *models.Domain) {
+	t.Helper()
+	soa, err := miekg.NewRR("example.com. 3600 IN SOA ns.example.com. hostmaster.example.com. 1 3600 600 604800 300")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &testRFC2136Server{soa: soa}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen error: %v", err)
+	}
+	started := make(chan struct{})
+	srv := &miekg.Server{
+		Listener:          l,
+		Handler:           s,
+		TsigSecret:        map[string]string{"key.example.com.": testTsigSecret},
+		NotifyStartedFunc: func() { close(started) },
+		// UPDATE is refused by the default accept func
+		MsgAcceptFunc: func(miekg.Header) miekg.MsgAcceptAction { return miekg.MsgAccept },
+	}
+	go srv.ActivateAndServe()
+	<-started
+	t.Cleanup(func() { srv.Shutdown() })
+
+	return s, &models.Domain{
+		Name:      "example.com",
+		ApiId:     "Key.Example.com",
+		ApiSecret: "hmac-sha512:" + testTsigSecret,
+		Vendor:    "rfc2136",
+		Endpoint:  l.Addr().String(),
+	}
+}
+
+func TestNewRFC2136Conn(t *testing.T) {
+	tests := []struct {
+		name      string
+		secret    string
+		endpoint  string
+		algorithm string
+		key       string
+		server    string
+	}{
+		{"default algorithm", "c2VjcmV0", "192.0.2.1", miekg.HmacSHA256, "c2VjcmV0", "192.0.2.1:53"},
+		{"algorithm prefix", "hmac-sha512:c2VjcmV0", "192.0.2.1:5353", miekg.HmacSHA512, "c2VjcmV0", "192.0.2.1:5353"},
+		{"upper case algorithm", "HMAC-SHA1:c2VjcmV0", "ns.example.com", miekg.HmacSHA1, "c2VjcmV0", "ns.example.com:53"},
+		{"ipv6 server", "c2VjcmV0", "[2001:db8::53]:5353", miekg.HmacSHA256, "c2VjcmV0", "[2001:db8::53]:5353"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &models.Domain{Name: "Example.com", ApiId: "Key.Example.com", ApiSecret: tt.secret, Endpoint: tt.endpoint}
+			conn, err := newRFC2136Conn(d)
+			if err != nil {
+				t.Fatalf("newRFC2136Conn() error: %v", err)
+			}
+			if conn.zone != "example.com." || conn.keyName != "key.example.com." {
+				t.Errorf("zone, key name = %s, %s, want example.com., key.example.com.", conn.zone, conn.keyName)
+			}
+			if conn.algorithm != tt.algorithm || conn.secret != tt.key {
+				t.Errorf("algorithm, secret = %s, %s, want %s, %s", conn.algorithm, conn.secret, tt.algorithm, tt.key)
+			}
+			if conn.server != tt.server {
+				t.Errorf("server = %s, want %s", conn.server, tt.server)
+			}
+		})
+	}
+
+	if _, err := newRFC2136Conn(&models.Domain{Name: "example.com", ApiId: "key"}); err == nil {
+		t.Error("newRFC2136Conn() without secret, want error")
+	}
+}
+
+func TestRFC2136DNS(t *testing.T) {
+	s, d := startTestRFC2136Server(t)
+
+	obj := &RFC2136DNS{Domain: *d}
+	obj.FromRecord(Record{Name: "www", Type: "A", Content: "192.0.2.1", TTL: 600})
+	if err := obj.Create(); err != nil {
+		t.Fatalf("Create() error: %v", err)
+	}
+	created := obj.ToRecord()
+	if created.Id == "" || created.Content != "192.0.2.1" {
+		t.Fatalf("Create() record = %+v, want it with id", created)
+	}
+
+	got := &RFC2136DNS{Domain: *d}
+	if err := got.Get(created.Id); err != nil {
+		t.Fatalf("Get() error: %v", err)
+	}
+	if r := got.ToRecord(); r.Name != "www" || r.Type != "A" || r.Content != "192.0.2.1" || r.TTL != 600 {
+		t.Errorf("Get() = %+v, want www A 192.0.2.1 ttl 600", r)
+	}
+
+	// the record is replaced in one UPDATE message, and has a new id from its content
+	updates, _ := s.state()
+	obj.FromRecord(Record{Id: created.Id, Name: "www", Type: "A", Content: "192.0.2.2", TTL: 300})
+	if err := obj.Update(); err != nil {
+		t.Fatalf("Update() error: %v", err)
+	}
+	if n, _ := s.state(); n != updates+1 {
+		t.Errorf("Update() sent %d UPDATE messages, want 1", n-updates)
+	}
+	updated := obj.ToRecord()
+	if updated.Id == created.Id {
+		t.Errorf("Update() kept id %s, want a new one", updated.Id)
+	}
+
+	list := &RFC2136DNSList{}
+	if err := list.GetDNSList(d); err != nil {
+		t.Fatalf("GetDNSList() error: %v", err)
+	}
+	records, err := list.Records()
+	if err != nil {
+		t.Fatalf("Records() error: %v", err)
+	}
+	if len(records) != 1 || records[0].Id != updated.Id || records[0].Content != "192.0.2.2" || records[0].TTL != 300 {
+		t.Errorf("Records() = %+v, want only the updated record", records)
+	}
+
+	if err := obj.Delete(); err != nil {
+		t.Fatalf("Delete() error: %v", err)
+	}
+	list = &RFC2136DNSList{}
+	list.GetDNSList(d)
+	if records, err := list.Records(); err != nil || len(records) != 0 {
+		t.Errorf("Records() after Delete() = %+v, %v, want none", records, err)
+	}
+}
+
+func TestRFC2136DNSBadSecret(t *testing.T) {
+	s, d := startTestRFC2136Server(t)
+	d.ApiSecret = "hmac-sha512:" + base64.StdEncoding.EncodeToString([]byte("wrong"))
+
+	obj := &RFC2136DNS{Domain: *d}
+	obj.FromRecord(Record{Name: "www", Type: "A", Content: "192.0.2.1", TTL: 600})
+	if err := obj.Create(); err == nil {
+		t.Error("Create() with wrong secret, want error")
+	}
+	if _, zone := s.state(); len(zone) != 0 {
+		t.Errorf("zone = %v, want no record", zone)
+	}
+
+	list := &RFC2136DNSList{}
+	list.GetDNSList(d)
+	if _, err := list.Records(); err == nil {
+		t.Error("Records() with wrong secret, want error")
+	}
+}
@@ -0,0 +1,122 @@
+package dns
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	miekg "github.com/miekg/dns"
+)
+
+// DefaultTTL is used when the vendor returns no ttl or an "automatic" ttl
+const DefaultTTL = 600
+
+// max length of a single character-string in TXT rdata
+const txtChunkSize = 255
+
+func absoluteHost(host string) string {
+	if host == "" || host == "." {
+		return "."
+	}
+	return miekg.Fqdn(host)
+}
+
+// relativeHost is the host of rdata as content, without the trailing dot except the root,
+// which is the target of null MX and SRV
+func relativeHost(host string) string {
+	if host == "." {
+		return host
+	}
+	return strings.TrimSuffix(host, ".")
+}
+
+func escapeTxt(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, `\`, `\\`), `"`, `\"`)
+}
+
+func unescapeTxt(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, `\"`, `"`), `\\`, `\`)
+}
+
+// quoteTxt splits raw txt content into quoted character-strings
+func quoteTxt(content string) string {
+	if content == "" {
+		return `""`
+	}
+	var chunks []string
+	for len(content) > txtChunkSize {
+		chunks = append(chunks, `"`+escapeTxt(content[:txtChunkSize])+`"`)
+		content = content[txtChunkSize:]
+	}
+	chunks = append(chunks, `"`+escapeTxt(content)+`"`)
+	return strings.Join(chunks, " ")
+}
+
+// ToRR converts the record of zone to a resource record in rfc 1035 presentation format
+func (r *Record) ToRR(zone string) (miekg.RR, error) {
+	name := miekg.Fqdn(FQDN(r.Name, zone))
+	typ := strings.ToUpper(r.Type)
+	rdata := r.Content
+	switch typ {
+	case "CNAME", "NS", "PTR", "DNAME":
+		rdata = absoluteHost(rdata)
+	case "MX":
+		rdata = fmt.Sprintf("%d %s", r.Priority, absoluteHost(rdata))
+	case "SRV":
+		fields := strings.Fields(rdata)
+		switch len(fields) {
+		case 3: // weight port target, priority is kept in its own field
+			rdata = fmt.Sprintf("%d %s %s %s", r.Priority, fields[0], fields[1], absoluteHost(fields[2]))
+		case 4:
+			rdata = fmt.Sprintf("%s %s %s %s", fields[0], fields[1], fields[2], absoluteHost(fields[3]))
+		}
+	case "TXT", "SPF":
+		rdata = quoteTxt(rdata)
+	}
+	ttl := r.TTL
+	if ttl <= 1 {
+		ttl = DefaultTTL
+	}
+	return miekg.NewRR(fmt.Sprintf("%s %d IN %s %s", name, ttl, typ, rdata))
+}
+
+// RecordFromRR converts a resource record of zone to Record
+func RecordFromRR(zone string, rr miekg.RR) Record {
+	h := rr.Header()
+	r := Record{
+		Name: RelativeName(h.Name, zone),
+		Type: miekg.TypeToString[h.Rrtype],
+		TTL:  int(h.Ttl),
+	}
+	switch v := rr.(type) {
+	case *miekg.CNAME:
+		r.Content = relativeHost(v.Target)
+	case *miekg.NS:
+		r.Content = relativeHost(v.Ns)
+	case *miekg.PTR:
+		r.Content = relativeHost(v.Ptr)
+	case *miekg.MX:
+		r.Priority = v.Preference
+		r.Content = relativeHost(v.Mx)
+	case *miekg.SRV:
+		r.Priority = v.Priority
+		r.Content = fmt.Sprintf("%d %d %s", v.Weight, v.Port, relativeHost(v.Target))
+	case *miekg.TXT:
+		r.Content = unescapeTxt(strings.Join(v.Txt, ""))
+	case *miekg.SPF:
+		r.Content = unescapeTxt(strings.Join(v.Txt, ""))
+	default:
+		r.Content = strings.TrimSpace(strings.TrimPrefix(rr.String(), h.String()))
+	}
+	return r
+}
+
+// rrId is the id of a record for vendors without record ids, it is the hash of owner name, type
+// and rdata of the resource record, ttl is not included. The id changes if the content is updated
+func rrId(rr miekg.RR) string {
+	h := rr.Header()
+	rdata := strings.TrimPrefix(rr.String(), h.String())
+	sum := md5.Sum([]byte(strings.ToLower(h.Name) + " " + miekg.TypeToString[h.Rrtype] + " " + rdata))
+	return hex.EncodeToString(sum[:8])
+}
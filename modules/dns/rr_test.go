@@ -0,0 +1,58 @@
+package dns
+
+import (
+	"strings"
+	"testing"
+
+	miekg "github.com/miekg/dns"
+)
+
+func TestRecordRRRoundTrip(t *testing.T) {
+	long := strings.Repeat("x", 300)
+	tests := []struct {
+		name    string
+		record  Record
+		txt     []string // character-strings of TXT rdata
+		content string   // content after round trip, same as record if empty
+	}{
+		{"spf", Record{Name: "@", Type: "TXT", Content: "v=spf1 -all", TTL: 600}, []string{"v=spf1 -all"}, ""},
+		{"empty", Record{Name: "www", Type: "TXT", Content: "", TTL: 600}, []string{""}, ""},
+		{"quote inside", Record{Name: "www", Type: "TXT", Content: `say "hi"`, TTL: 600}, []string{`say \"hi\"`}, ""},
+		{"backslash", Record{Name: "www", Type: "TXT", Content: `a\b`, TTL: 600}, []string{`a\\b`}, ""},
+		{"split at 255 bytes", Record{Name: "www", Type: "TXT", Content: long, TTL: 600}, []string{long[:255], long[255:]}, ""},
+		{"mx", Record{Name: "@", Type: "MX", Content: "mail.example.com", Priority: 10, TTL: 600}, nil, ""},
+		{"null mx", Record{Name: "@", Type: "MX", Content: ".", TTL: 600}, nil, ""},
+		{"cname", Record{Name: "www", Type: "CNAME", Content: "example.net", TTL: 600}, nil, ""},
+		{"srv", Record{Name: "_sip._tcp", Type: "SRV", Content: "5 5060 sip.example.com", Priority: 10, TTL: 600}, nil, ""},
+		{"srv not available", Record{Name: "_sip._tcp", Type: "SRV", Content: "0 0 .", TTL: 600}, nil, ""},
+		{"srv with priority", Record{Name: "_sip._tcp", Type: "SRV", Content: "10 5 5060 sip.example.com", TTL: 600}, nil, "5 5060 sip.example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr, err := tt.record.ToRR("example.com")
+			if err != nil {
+				t.Fatalf("ToRR() error: %v", err)
+			}
+			if tt.txt != nil {
+				txt, ok := rr.(*miekg.TXT)
+				if !ok {
+					t.Fatalf("ToRR() = %T, want *dns.TXT", rr)
+				}
+				if strings.Join(txt.Txt, "|") != strings.Join(tt.txt, "|") {
+					t.Errorf("TXT strings = %q, want %q", txt.Txt, tt.txt)
+				}
+			}
+
+			got := RecordFromRR("example.com.", rr)
+			want := tt.record
+			if tt.content != "" {
+				want.Content = tt.content
+				want.Priority = 10
+			}
+			if got.Name != want.Name || got.Type != want.Type || got.Content != want.Content ||
+				got.Priority != want.Priority || got.TTL != want.TTL {
+				t.Errorf("RecordFromRR() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
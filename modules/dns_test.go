@@ -0,0 +1,82 @@
+package modules
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"domain0/models"
+	md "domain0/modules/dns"
+)
+
+func TestDnsChangeStructLegacy(t *testing.T) {
+	tests := []struct {
+		name      string
+		operation string
+		want      md.Record
+	}{
+		{
+			"dnspod numeric id",
+			`{"dns":{"id":123,"type":"A","name":"www","content":"192.0.2.1","ttl":600,"comment":null,"priority":0,` +
+				`"custom":{"record_line":"默认","enable":"enable"}},"domain":{"ID":1,"Name":"example.com","vendor":"dnspod","ICP_reg":1}}`,
+			md.Record{Id: "123", Name: "www", Type: "A", Content: "192.0.2.1", TTL: 600,
+				Extensions: md.Extensions{md.ExtEnabled: true, md.ExtLine: "默认"}},
+		},
+		{
+			"dnspod create without id",
+			`{"dns":{"id":0,"type":"TXT","name":"@","content":"v=spf1 -all","ttl":600,"comment":null,"priority":0,` +
+				`"custom":{"record_line":"","enable":""}},"domain":{"ID":1,"Name":"example.com","vendor":"dnspod","ICP_reg":1}}`,
+			md.Record{Name: "@", Type: "TXT", Content: "v=spf1 -all", TTL: 600,
+				Extensions: md.Extensions{md.ExtEnabled: true}},
+		},
+		{
+			"cloudflare full name and proxied",
+			`{"dns":{"id":"abc","type":"A","name":"www.example.com","content":"192.0.2.1","proxied":true,"ttl":1,` +
+				`"comment":"web","data":null,"priority":0},"domain":{"ID":2,"Name":"example.com","vendor":"cloudflare","ICP_reg":1}}`,
+			md.Record{Id: "abc", Name: "www", Type: "A", Content: "192.0.2.1", TTL: 1, Comment: "web",
+				Extensions: md.Extensions{md.ExtProxied: true}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var dcs DnsChangeStruct
+			if err := json.Unmarshal([]byte(tt.operation), &dcs); err != nil {
+				t.Fatalf("Unmarshal() error: %v", err)
+			}
+			if !reflect.DeepEqual(dcs.Dns, tt.want) {
+				t.Errorf("Dns = %+v, want %+v", dcs.Dns, tt.want)
+			}
+			if dcs.Domain.Name != "example.com" || dcs.Base != nil {
+				t.Errorf("Domain = %s, Base = %v", dcs.Domain.Name, dcs.Base)
+			}
+		})
+	}
+}
+
+func TestDnsChangeStructLegacyUnknownVendor(t *testing.T) {
+	var dcs DnsChangeStruct
+	err := json.Unmarshal([]byte(`{"dns":{"id":1},"domain":{"Name":"example.com","vendor":"nope"}}`), &dcs)
+	if err != ErrUnknownVendor {
+		t.Errorf("Unmarshal() = %v, want %v", err, ErrUnknownVendor)
+	}
+}
+
+func TestDnsChangeStructRoundTrip(t *testing.T) {
+	base := md.Record{Id: "7", Name: "www", Type: "A", Content: "192.0.2.1", TTL: 600}
+	dcs := DnsChangeStruct{
+		Dns:    md.Record{Id: "7", Name: "www", Type: "A", Content: "192.0.2.2", TTL: 600, Extensions: md.Extensions{md.ExtProxied: true}},
+		Base:   &base,
+		Domain: models.Domain{Name: "example.com", Vendor: "cloudflare"},
+	}
+	b, err := json.Marshal(dcs)
+	if err != nil {
+		t.Fatalf("Marshal() error: %v", err)
+	}
+	var got DnsChangeStruct
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("Unmarshal() error: %v", err)
+	}
+	if !reflect.DeepEqual(got.Dns, dcs.Dns) || !reflect.DeepEqual(got.Base, dcs.Base) || got.Domain.Vendor != "cloudflare" {
+		t.Errorf("round trip = %+v, want %+v", got, dcs)
+	}
+}
@@ -0,0 +1,87 @@
+package modules
+
+import (
+	md "domain0/modules/dns"
+	"errors"
+	"sort"
+	"strings"
+)
+
+// recordMaxPageSize bounds page_size, 0 still means all records
+const recordMaxPageSize = 1000
+
+// RecordQuery filters, sorts and pages a record list on server side
+type RecordQuery struct {
+	Type     string `query:"type"`      // exact match, case insensitive
+	Name     string `query:"name"`      // substring match, case insensitive
+	Sort     string `query:"sort"`      // name, type, content, ttl
+	Order    string `query:"order"`     // asc, desc
+	Page     int    `query:"page"`      // start from 1
+	PageSize int    `query:"page_size"` // 0 means all records, at most recordMaxPageSize
+}
+
+var recordLess = map[string]func(a, b *md.Record) bool{
+	"name":    func(a, b *md.Record) bool { return a.Name < b.Name },
+	"type":    func(a, b *md.Record) bool { return a.Type < b.Type },
+	"content": func(a, b *md.Record) bool { return a.Content < b.Content },
+	"ttl":     func(a, b *md.Record) bool { return a.TTL < b.TTL },
+}
+
+func (q *RecordQuery) Check() error {
+	if q.Sort != "" {
+		if _, ok := recordLess[q.Sort]; !ok {
+			return errors.New("invalid sort field")
+		}
+	}
+	if q.Order != "" && q.Order != "asc" && q.Order != "desc" {
+		return errors.New("invalid sort order")
+	}
+	if q.Page < 0 || q.PageSize < 0 || q.PageSize > recordMaxPageSize {
+		return errors.New("invalid page")
+	}
+	return nil
+}
+
+// Apply returns the count of matched records and the records of the requested page
+func (q *RecordQuery) Apply(records []md.Record) (int, []md.Record) {
+	res := make([]md.Record, 0, len(records))
+	name := strings.ToLower(q.Name)
+	for _, r := range records {
+		if q.Type != "" && !strings.EqualFold(r.Type, q.Type) {
+			continue
+		}
+		if name != "" && !strings.Contains(strings.ToLower(r.Name), name) {
+			continue
+		}
+		res = append(res, r)
+	}
+
+	if less, ok := recordLess[q.Sort]; ok {
+		desc := q.Order == "desc"
+		sort.SliceStable(res, func(i, j int) bool {
+			if desc {
+				return less(&res[j], &res[i])
+			}
+			return less(&res[i], &res[j])
+		})
+	}
+
+	total := len(res)
+	if q.PageSize == 0 {
+		return total, res
+	}
+	page := q.Page
+	if page < 1 {
+		page = 1
+	}
+	// compare pages before multiplying, a huge page would overflow
+	if page-1 >= (total+q.PageSize-1)/q.PageSize {
+		return total, []md.Record{}
+	}
+	start := (page - 1) * q.PageSize
+	end := start + q.PageSize
+	if end > total {
+		end = total
+	}
+	return total, res[start:end]
+}
@@ -0,0 +1,75 @@
+package modules
+
+import (
+	"reflect"
+	"testing"
+
+	md "domain0/modules/dns"
+)
+
+func TestRecordQueryApply(t *testing.T) {
+	records := []md.Record{
+		{Id: "1", Name: "www", Type: "A", Content: "192.0.2.2", TTL: 600},
+		{Id: "2", Name: "@", Type: "MX", Content: "mail.example.com", TTL: 300},
+		{Id: "3", Name: "WWW2", Type: "a", Content: "192.0.2.1", TTL: 60},
+		{Id: "4", Name: "mail", Type: "A", Content: "192.0.2.3", TTL: 600},
+		{Id: "5", Name: "www", Type: "TXT", Content: "v=spf1 -all", TTL: 600},
+	}
+	tests := []struct {
+		name  string
+		query RecordQuery
+		total int
+		ids   []string
+	}{
+		{"all", RecordQuery{}, 5, []string{"1", "2", "3", "4", "5"}},
+		{"type case insensitive", RecordQuery{Type: "A"}, 3, []string{"1", "3", "4"}},
+		{"name substring case insensitive", RecordQuery{Name: "Ww"}, 3, []string{"1", "3", "5"}},
+		{"type and name", RecordQuery{Type: "txt", Name: "www"}, 1, []string{"5"}},
+		{"no match", RecordQuery{Type: "AAAA"}, 0, []string{}},
+		{"sort by ttl", RecordQuery{Sort: "ttl"}, 5, []string{"3", "2", "1", "4", "5"}},
+		{"sort by ttl desc is stable", RecordQuery{Sort: "ttl", Order: "desc"}, 5, []string{"1", "4", "5", "2", "3"}},
+		{"sort by content", RecordQuery{Type: "a", Sort: "content"}, 3, []string{"3", "1", "4"}},
+		{"first page", RecordQuery{PageSize: 2}, 5, []string{"1", "2"}},
+		{"last page", RecordQuery{Page: 3, PageSize: 2}, 5, []string{"5"}},
+		{"page after the last", RecordQuery{Page: 4, PageSize: 2}, 5, []string{}},
+		{"page overflowing offset", RecordQuery{Page: 4611686018427387905, PageSize: 2}, 5, []string{}},
+		{"huge page size", RecordQuery{Page: 2, PageSize: 1000}, 5, []string{}},
+		{"filtered page", RecordQuery{Type: "A", Sort: "ttl", Page: 2, PageSize: 2}, 3, []string{"4"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			total, page := tt.query.Apply(records)
+			ids := make([]string, 0, len(page))
+			for _, r := range page {
+				ids = append(ids, r.Id)
+			}
+			if total != tt.total || !reflect.DeepEqual(ids, tt.ids) {
+				t.Errorf("Apply() = %d %v, want %d %v", total, ids, tt.total, tt.ids)
+			}
+		})
+	}
+}
+
+func TestRecordQueryCheck(t *testing.T) {
+	tests := []struct {
+		name  string
+		query RecordQuery
+		valid bool
+	}{
+		{"empty", RecordQuery{}, true},
+		{"sort and order", RecordQuery{Sort: "name", Order: "desc"}, true},
+		{"unknown sort", RecordQuery{Sort: "id"}, false},
+		{"unknown order", RecordQuery{Order: "up"}, false},
+		{"negative page", RecordQuery{Page: -1}, false},
+		{"negative page size", RecordQuery{PageSize: -1}, false},
+		{"max page size", RecordQuery{PageSize: 1000}, true},
+		{"page size too large", RecordQuery{PageSize: 1001}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.query.Check(); (err == nil) != tt.valid {
+				t.Errorf("Check() = %v, want valid %v", err, tt.valid)
+			}
+		})
+	}
+}
@@ -0,0 +1,248 @@
+package modules
+
+import (
+	"domain0/models"
+	md "domain0/modules/dns"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ZoneDocument is the desired state of a zone, kept as yaml or json in git
+//
+//	domain: example.com
+//	prune: true
+//	records:
+//	  - name: www
+//	    type: A
+//	    content: 1.2.3.4
+//	    ttl: 600
+type ZoneDocument struct {
+	Domain  string      `json:"domain" yaml:"domain"` // optional, must be the synced domain if set
+	Prune   *bool       `json:"prune" yaml:"prune"`   // delete records absent in document, default true
+	Records []md.Record `json:"records" yaml:"records"`
+}
+
+type PlanUpdate struct {
+	Before md.Record `json:"before"`
+	After  md.Record `json:"after"`
+}
+
+// Plan is the changes to make the live records the same as the desired ones
+type Plan struct {
+	Creates []md.Record  `json:"creates"`
+	Updates []PlanUpdate `json:"updates"`
+	Deletes []md.Record  `json:"deletes"`
+}
+
+// SyncChangeStruct is the operation of a DomainChange applying a plan
+type SyncChangeStruct struct {
+	Plan   Plan          `json:"plan"`
+	Domain models.Domain `json:"domain"`
+}
+
+// ParseZoneDocument parses the document in yaml or json, json is a subset of yaml
+func ParseZoneDocument(d *models.Domain, body []byte) (*ZoneDocument, error) {
+	var doc ZoneDocument
+	if err := yaml.Unmarshal(body, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Domain != "" && !strings.EqualFold(strings.TrimSuffix(doc.Domain, "."), d.Name) {
+		return nil, fmt.Errorf("document is for domain %s, not %s", doc.Domain, d.Name)
+	}
+	for i := range doc.Records {
+		r := &doc.Records[i]
+		if r.Name == "" || r.Type == "" || r.Content == "" {
+			return nil, fmt.Errorf("record %d: name, type and content are required", i)
+		}
+		r.Id = ""
+		r.Name = md.RelativeName(r.Name, d.Name)
+		r.Type = strings.ToUpper(r.Type)
+		if vendorManaged(r) {
+			return nil, fmt.Errorf("record %d: %s %s is managed by the vendor", i, r.Name, r.Type)
+		}
+	}
+	if err := ValidateRecords(d, doc.Records, "records."); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// UserRecords drops the records managed by the vendor
+func UserRecords(records []md.Record) []md.Record {
+	res := make([]md.Record, 0, len(records))
+	for i := range records {
+		if !vendorManaged(&records[i]) {
+			res = append(res, records[i])
+		}
+	}
+	return res
+}
+
+func (p *Plan) Empty() bool {
+	return len(p.Creates) == 0 && len(p.Updates) == 0 && len(p.Deletes) == 0
+}
+
+func describeRecord(r *md.Record) string {
+	return fmt.Sprintf("%s %s %s ttl %d", r.Name, r.Type, r.Content, r.TTL)
+}
+
+// Describe lists the changes of the plan in one line each, "+" for create, "~" for update and "-" for delete
+func (p *Plan) Describe() []string {
+	var lines []string
+	for i := range p.Creates {
+		lines = append(lines, "+ "+describeRecord(&p.Creates[i]))
+	}
+	for i := range p.Updates {
+		lines = append(lines, "~ "+describeRecord(&p.Updates[i].Before)+" => "+describeRecord(&p.Updates[i].After))
+	}
+	for i := range p.Deletes {
+		lines = append(lines, "- "+describeRecord(&p.Deletes[i]))
+	}
+	return lines
+}
+
+// needUpdate reports whether live differs from desired, zero values of desired are "don't care"
+func needUpdate(desired, live *md.Record) bool {
+	if desired.TTL != 0 && desired.TTL != live.TTL {
+		return true
+	}
+	if desired.Comment != "" && desired.Comment != live.Comment {
+		return true
+	}
+	for key, val := range desired.Extensions {
+		if !reflect.DeepEqual(val, live.Extensions.Value(key)) {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeDesired fills the "don't care" fields of desired with live ones, so an update won't reset them
+func mergeDesired(live, desired md.Record) md.Record {
+	desired.Id = live.Id
+	if desired.TTL == 0 {
+		desired.TTL = live.TTL
+	}
+	if desired.Comment == "" {
+		desired.Comment = live.Comment
+	}
+	ext := md.Extensions{}
+	for key, val := range live.Extensions {
+		ext[key] = val
+	}
+	for key, val := range desired.Extensions {
+		ext[key] = val
+	}
+	desired.Extensions = ext
+	return desired
+}
+
+// ComputePlan compares desired records with live ones rrset by rrset,
+// live records out of desired are deleted only when prune is set
+func ComputePlan(desired, live []md.Record, prune bool) Plan {
+	plan := Plan{Creates: []md.Record{}, Updates: []PlanUpdate{}, Deletes: []md.Record{}}
+
+	liveSets := map[string][]md.Record{}
+	for _, r := range live {
+		if vendorManaged(&r) {
+			continue
+		}
+		liveSets[r.RRSetKey()] = append(liveSets[r.RRSetKey()], r)
+	}
+	desiredSets := map[string][]md.Record{}
+	var keys []string
+	for _, r := range desired {
+		if _, ok := desiredSets[r.RRSetKey()]; !ok {
+			keys = append(keys, r.RRSetKey())
+		}
+		desiredSets[r.RRSetKey()] = append(desiredSets[r.RRSetKey()], r)
+	}
+
+	for _, key := range keys {
+		want, have := desiredSets[key], liveSets[key]
+		delete(liveSets, key)
+
+		// pair the same records first, they may only need ttl or comment changes
+		var restWant []md.Record
+		for _, w := range want {
+			matched := -1
+			for i := range have {
+				if have[i].SameAs(&w) {
+					matched = i
+					break
+				}
+			}
+			if matched < 0 {
+				restWant = append(restWant, w)
+				continue
+			}
+			if needUpdate(&w, &have[matched]) {
+				plan.Updates = append(plan.Updates, PlanUpdate{Before: have[matched], After: mergeDesired(have[matched], w)})
+			}
+			have = append(have[:matched], have[matched+1:]...)
+		}
+
+		// then reuse the left records of rrset for changed contents
+		for i, w := range restWant {
+			if i < len(have) {
+				plan.Updates = append(plan.Updates, PlanUpdate{Before: have[i], After: mergeDesired(have[i], w)})
+			} else {
+				plan.Creates = append(plan.Creates, w)
+			}
+		}
+		if prune && len(have) > len(restWant) {
+			plan.Deletes = append(plan.Deletes, have[len(restWant):]...)
+		}
+	}
+
+	if prune {
+		for _, rest := range liveSets {
+			plan.Deletes = append(plan.Deletes, rest...)
+		}
+	}
+	return plan
+}
+
+// ApplyPlan applies the plan to domain d through its vendor, deletes go first to make room
+// for the creates, e.g. replacing A records with a CNAME. The plan should be checked by
+// CheckZoneConflicts with its Batch first
+func ApplyPlan(d *models.Domain, plan *Plan) error {
+	for _, r := range plan.Deletes {
+		dnsObj, err := DnsObjGen(d)
+		if err != nil {
+			return err
+		}
+		if err := dnsObj.Get(r.Id); err != nil {
+			return fmt.Errorf("delete %s %s: %w", r.Name, r.Type, err)
+		}
+		if err := dnsObj.Delete(); err != nil {
+			return fmt.Errorf("delete %s %s: %w", r.Name, r.Type, err)
+		}
+	}
+	for _, u := range plan.Updates {
+		if u.After.Id == "" {
+			return errors.New("update without record id")
+		}
+		dnsObj, err := DnsObjFromRecord(d, u.After)
+		if err == nil {
+			err = dnsObj.Update()
+		}
+		if err != nil {
+			return fmt.Errorf("update %s %s: %w", u.After.Name, u.After.Type, err)
+		}
+	}
+	for _, r := range plan.Creates {
+		dnsObj, err := DnsObjFromRecord(d, r)
+		if err == nil {
+			err = dnsObj.Create()
+		}
+		if err != nil {
+			return fmt.Errorf("create %s %s: %w", r.Name, r.Type, err)
+		}
+	}
+	return nil
+}
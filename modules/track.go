@@ -0,0 +1,49 @@
+package modules
+
+import (
+	"domain0/models"
+)
+
+type RecordChangeOp int
+
+const (
+	RecordCreated RecordChangeOp = iota
+	RecordUpdated
+	RecordDeleted
+)
+
+// RecordChangeHook wraps every record change made through domain0, change makes the vendor
+// call and must be called once, obj is the changed dns object
+type RecordChangeHook func(d *models.Domain, op RecordChangeOp, obj DnsObj, change func() error) error
+
+var recordChangeHook RecordChangeHook
+
+// SetRecordChangeHook sets the hook of dns objects generated by DnsObjGen and DnsObjFromRecord
+func SetRecordChangeHook(h RecordChangeHook) {
+	recordChangeHook = h
+}
+
+// trackedObj calls recordChangeHook around Create, Update and Delete of the vendor object
+type trackedObj struct {
+	DnsObj
+	domain models.Domain
+}
+
+func (o *trackedObj) Create() error {
+	return o.track(RecordCreated, o.DnsObj.Create)
+}
+
+func (o *trackedObj) Update() error {
+	return o.track(RecordUpdated, o.DnsObj.Update)
+}
+
+func (o *trackedObj) Delete() error {
+	return o.track(RecordDeleted, o.DnsObj.Delete)
+}
+
+func (o *trackedObj) track(op RecordChangeOp, change func() error) error {
+	if recordChangeHook == nil {
+		return change()
+	}
+	return recordChangeHook(&o.domain, op, o.DnsObj, change)
+}
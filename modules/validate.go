@@ -0,0 +1,267 @@
+package modules
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	miekg "github.com/miekg/dns"
+
+	"domain0/models"
+	md "domain0/modules/dns"
+)
+
+// max length of a character-string, a TXT content longer is split by vendors of SplitTXT
+const txtStringSize = 255
+
+// FieldError is an invalid field of a record, field is the json name, e.g. content or extensions.proxied
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError is the invalid fields of records, found before the vendor api is called
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		msgs = append(msgs, f.Field+": "+f.Message)
+	}
+	return "invalid record, " + strings.Join(msgs, "; ")
+}
+
+func (e *ValidationError) add(field, format string, args ...interface{}) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// orNil returns nil if there is no field error, so the result can be returned as error
+func (e *ValidationError) orNil() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+// PrefixFieldErrors prefixes the fields of a *ValidationError with the position of the record,
+// e.g. "records.2.", other errors are prefixed in message
+func PrefixFieldErrors(err error, prefix string) error {
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		return fmt.Errorf("%s %w", strings.TrimSuffix(prefix, "."), err)
+	}
+	res := &ValidationError{}
+	for _, f := range verr.Fields {
+		res.add(prefix+f.Field, "%s", f.Message)
+	}
+	return res
+}
+
+// caaTags are the property tags registered for CAA records
+var caaTags = map[string]bool{
+	"issue": true, "issuewild": true, "iodef": true, "issuemail": true, "contactemail": true, "contactphone": true,
+}
+
+// ValidateRecord checks the fields of record r by its type and the vendor of domain d, it returns
+// a *ValidationError listing all invalid fields
+func ValidateRecord(d *models.Domain, r *md.Record) error {
+	verr := &ValidationError{}
+	p, ok := md.LookupProvider(d.Vendor)
+	if !ok {
+		return ErrUnknownVendor
+	}
+	if err := r.Extensions.Check(p.Capabilities.Extensions); err != nil {
+		verr.add("extensions", "%v", err)
+	}
+
+	if name := md.FQDN(r.Name, d.Name); r.Name != "@" && !isHostName(name) {
+		verr.add("name", "%q is not a valid domain name", r.Name)
+	}
+	validateTTL(verr, &p.Capabilities, r.TTL)
+
+	typ := strings.ToUpper(r.Type)
+	if _, ok := miekg.StringToType[typ]; !ok {
+		verr.add("type", "unknown record type %q", r.Type)
+		return verr
+	}
+	if typ != "MX" && typ != "SRV" && r.Priority != 0 {
+		verr.add("priority", "only MX and SRV records have priority")
+	}
+	// structured data is checked by the vendor, content is generated from it
+	if r.Extensions.Value(md.ExtData) != nil {
+		return verr.orNil()
+	}
+
+	content := strings.TrimSpace(r.Content)
+	if content == "" {
+		verr.add("content", "content is required")
+		return verr
+	}
+	switch typ {
+	case "A":
+		if ip := net.ParseIP(content); ip == nil || ip.To4() == nil || strings.Contains(content, ":") {
+			verr.add("content", "%q is not an IPv4 address", content)
+		}
+	case "AAAA":
+		if ip := net.ParseIP(content); ip == nil || !strings.Contains(content, ":") {
+			verr.add("content", "%q is not an IPv6 address", content)
+		}
+	case "CNAME", "NS", "PTR", "DNAME":
+		validateTarget(verr, "content", content)
+	case "MX":
+		// null MX "." tells the domain accepts no mail
+		if content != "." {
+			validateTarget(verr, "content", content)
+		}
+	case "SRV":
+		validateSRV(verr, r, content)
+	case "TXT", "SPF":
+		validateTXT(verr, content, p.Capabilities.SplitTXT)
+	case "CAA":
+		validateCAA(verr, content)
+	}
+	return verr.orNil()
+}
+
+// ValidateRecords checks each record like ValidateRecord, and that a name with CNAME
+// has no other record
+func ValidateRecords(d *models.Domain, records []md.Record, prefix string) error {
+	verr := &ValidationError{}
+	for i := range records {
+		if err := ValidateRecord(d, &records[i]); err != nil {
+			err = PrefixFieldErrors(err, fmt.Sprintf("%s%d.", prefix, i))
+			var e *ValidationError
+			if !errors.As(err, &e) {
+				return err
+			}
+			verr.Fields = append(verr.Fields, e.Fields...)
+		}
+	}
+
+	for _, i := range nonExclusiveCNAMEs(d, records) {
+		verr.add(fmt.Sprintf("%s%d.type", prefix, i), "CNAME must be the only record at %s", records[i].Name)
+	}
+	return verr.orNil()
+}
+
+// nonExclusiveCNAMEs returns the index of CNAME records sharing the name with other records
+func nonExclusiveCNAMEs(d *models.Domain, records []md.Record) []int {
+	atName := map[string]int{}
+	for i := range records {
+		atName[strings.ToLower(md.RelativeName(records[i].Name, d.Name))]++
+	}
+	var res []int
+	for i := range records {
+		if strings.EqualFold(records[i].Type, "CNAME") &&
+			atName[strings.ToLower(md.RelativeName(records[i].Name, d.Name))] > 1 {
+			res = append(res, i)
+		}
+	}
+	return res
+}
+
+func validateTTL(verr *ValidationError, caps *md.Capabilities, ttl int) {
+	switch {
+	case ttl == 0: // vendor default
+	case ttl == 1 && caps.AutoTTL:
+	case ttl < 0:
+		verr.add("ttl", "ttl must not be negative")
+	case caps.MinTTL > 0 && ttl < caps.MinTTL:
+		verr.add("ttl", "ttl must be at least %d", caps.MinTTL)
+	case caps.MaxTTL > 0 && ttl > caps.MaxTTL:
+		verr.add("ttl", "ttl must be at most %d", caps.MaxTTL)
+	}
+}
+
+func isHostName(name string) bool {
+	name = strings.TrimSuffix(name, ".")
+	if _, ok := miekg.IsDomainName(name); !ok || name == "" || strings.ContainsAny(name, " \t\\") {
+		return false
+	}
+	for _, label := range strings.Split(name, ".") {
+		if label == "" || len(label) > 63 {
+			return false
+		}
+	}
+	return true
+}
+
+func validateTarget(verr *ValidationError, field, target string) {
+	if strings.ContainsAny(target, " \t") {
+		verr.add(field, "%q must be a single host name, set priority in its own field", target)
+	} else if net.ParseIP(target) != nil {
+		verr.add(field, "%q must be a host name, not an address", target)
+	} else if !isHostName(target) {
+		verr.add(field, "%q is not a valid host name", target)
+	}
+}
+
+// validateSRV checks "weight port target", or "priority weight port target" as in zone files
+func validateSRV(verr *ValidationError, r *md.Record, content string) {
+	if labels := strings.Split(md.RelativeName(r.Name, ""), "."); len(labels) < 2 ||
+		!strings.HasPrefix(labels[0], "_") || !strings.HasPrefix(labels[1], "_") {
+		verr.add("name", "SRV name must be _service._proto, e.g. _sip._tcp")
+	}
+	fields := strings.Fields(content)
+	if len(fields) != 3 && len(fields) != 4 {
+		verr.add("content", "SRV content must be \"weight port target\"")
+		return
+	}
+	names := []string{"weight", "port"}
+	if len(fields) == 4 {
+		names = []string{"priority", "weight", "port"}
+		if r.Priority != 0 {
+			verr.add("priority", "SRV priority is in content already, leave priority 0")
+		}
+	}
+	for i, n := range names {
+		if _, err := strconv.ParseUint(fields[i], 10, 16); err != nil {
+			verr.add("content", "SRV %s %q must be 0 to 65535", n, fields[i])
+		}
+	}
+	// "." tells the service is not available
+	if target := fields[len(fields)-1]; target != "." {
+		validateTarget(verr, "content", target)
+	}
+}
+
+// validateTXT rejects quoted content, the content is the raw text, it is quoted and split into
+// 255 bytes character-strings if the vendor splits TXT, others take it as one string of 255 bytes
+func validateTXT(verr *ValidationError, content string, split bool) {
+	if len(content) >= 2 && strings.HasPrefix(content, `"`) && strings.HasSuffix(content, `"`) {
+		verr.add("content", "TXT content must be the raw text without quotes, it is quoted by domain0")
+	}
+	if !split && len(content) > txtStringSize {
+		verr.add("content", "TXT content is %d bytes, at most %d bytes for this vendor", len(content), txtStringSize)
+	}
+}
+
+// validateCAA checks "flags tag value", e.g. 0 issue "letsencrypt.org"
+func validateCAA(verr *ValidationError, content string) {
+	fields := strings.SplitN(content, " ", 3)
+	if len(fields) != 3 {
+		verr.add("content", "CAA content must be \"flags tag value\"")
+		return
+	}
+	if flags, err := strconv.ParseUint(fields[0], 10, 8); err != nil {
+		verr.add("content", "CAA flags %q must be 0 to 255", fields[0])
+	} else if flags != 0 && flags != 128 {
+		verr.add("content", "CAA flags must be 0, or 128 for critical")
+	}
+	tag := strings.ToLower(fields[1])
+	if !caaTags[tag] {
+		verr.add("content", "unknown CAA tag %q", fields[1])
+		return
+	}
+	value := strings.Trim(strings.TrimSpace(fields[2]), `"`)
+	if tag == "iodef" {
+		if u, err := url.Parse(value); err != nil || (u.Scheme != "mailto" && u.Scheme != "http" && u.Scheme != "https") {
+			verr.add("content", "CAA iodef %q must be a mailto, http or https url", value)
+		}
+	}
+}
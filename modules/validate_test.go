@@ -0,0 +1,88 @@
+package modules
+
+import (
+	"strings"
+	"testing"
+
+	"domain0/models"
+	md "domain0/modules/dns"
+)
+
+func TestValidateTXT(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		split   bool
+		valid   bool
+	}{
+		{"raw", "v=spf1 -all", false, true},
+		{"empty", "", false, true},
+		{"quote inside", `say "hi"`, false, true},
+		{"leading quote only", `"hi`, false, true},
+		{"one string", strings.Repeat("a", 255), false, true},
+		{"longer than a string", strings.Repeat("a", 256), false, false},
+		{"split by vendor", strings.Repeat("a", 300), true, true},
+		{"quoted", `"v=spf1 -all"`, false, false},
+		{"quoted strings", `"a" "b"`, true, false},
+		{"quotes only", `""`, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verr := &ValidationError{}
+			validateTXT(verr, tt.content, tt.split)
+			if valid := verr.orNil() == nil; valid != tt.valid {
+				t.Errorf("validateTXT(%q, %v) valid = %v, want %v: %v", tt.content, tt.split, valid, tt.valid, verr.Fields)
+			}
+		})
+	}
+}
+
+func TestValidateRecordTXTByVendor(t *testing.T) {
+	long := md.Record{Name: "www", Type: "TXT", Content: strings.Repeat("a", 300), TTL: 600}
+	tests := []struct {
+		vendor string
+		valid  bool
+	}{
+		{md.LocalVendor, true},
+		{"rfc2136", true},
+		{"powerdns", true},
+		{"cloudflare", false},
+		{"aliyun", false},
+		{"huawei", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.vendor, func(t *testing.T) {
+			r := long
+			err := ValidateRecord(&models.Domain{Name: "example.com", Vendor: tt.vendor}, &r)
+			if (err == nil) != tt.valid {
+				t.Errorf("ValidateRecord() = %v, want valid %v", err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestValidateSRV(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		priority uint16
+		valid    bool
+	}{
+		{"weight port target", "5 5060 sip.example.com", 10, true},
+		{"priority in content", "10 5 5060 sip.example.com", 0, true},
+		{"priority twice", "10 5 5060 sip.example.com", 20, false},
+		{"not available", "0 0 .", 0, true},
+		{"too few fields", "5060 sip.example.com", 0, false},
+		{"port out of range", "5 70000 sip.example.com", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verr := &ValidationError{}
+			r := md.Record{Name: "_sip._tcp", Type: "SRV", Content: tt.content, Priority: tt.priority}
+			validateSRV(verr, &r, tt.content)
+			if valid := verr.orNil() == nil; valid != tt.valid {
+				t.Errorf("validateSRV(%q, %d) valid = %v, want %v: %v", tt.content, tt.priority, valid, tt.valid, verr.Fields)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package modules
+
+import (
+	"domain0/models"
+	md "domain0/modules/dns"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	miekg "github.com/miekg/dns"
+)
+
+// ZoneImportResult is the result of importing a zone file into a domain
+type ZoneImportResult struct {
+	Created []md.Record        `json:"created"`
+	Skipped []md.Record        `json:"skipped"` // already exist, or managed by the vendor
+	Failed  []ZoneImportFailed `json:"failed"`
+}
+
+type ZoneImportFailed struct {
+	Record md.Record `json:"record"`
+	Error  string    `json:"error"`
+}
+
+// vendorManaged reports whether the record is maintained by the vendor and can't be edited
+func vendorManaged(r *md.Record) bool {
+	typ := strings.ToUpper(r.Type)
+	return typ == "SOA" || (typ == "NS" && r.Name == "@")
+}
+
+// ExportZoneFile renders records of domain d as a rfc 1035 zone file
+func ExportZoneFile(d *models.Domain, records []md.Record) string {
+	sorted := make([]md.Record, len(records))
+	copy(sorted, records)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Name != sorted[j].Name {
+			return sorted[i].Name < sorted[j].Name
+		}
+		return sorted[i].Type < sorted[j].Type
+	})
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "; zone %s exported by domain0 at %s\n", d.Name, time.Now().Format(time.RFC3339))
+	fmt.Fprintf(&b, "; vendor: %s, records: %d\n", d.Vendor, len(sorted))
+	fmt.Fprintf(&b, "$ORIGIN %s\n", miekg.Fqdn(d.Name))
+	fmt.Fprintf(&b, "$TTL %d\n", md.DefaultTTL)
+	for i := range sorted {
+		rr, err := sorted[i].ToRR(d.Name)
+		if err != nil {
+			// keep it in the file, so nothing is lost silently
+			fmt.Fprintf(&b, "; unsupported record %s %s %s: %v\n", sorted[i].Name, sorted[i].Type, sorted[i].Content, err)
+			continue
+		}
+		if sorted[i].Comment != "" {
+			fmt.Fprintf(&b, "; %s\n", strings.ReplaceAll(sorted[i].Comment, "\n", " "))
+		}
+		b.WriteString(rr.String())
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// ParseZoneFile parses a rfc 1035 zone file of domain d, records out of the domain are rejected
+func ParseZoneFile(d *models.Domain, r io.Reader) ([]md.Record, error) {
+	origin := miekg.Fqdn(d.Name)
+	zp := miekg.NewZoneParser(r, origin, "")
+	zp.SetDefaultTTL(md.DefaultTTL)
+
+	var records []md.Record
+	for rr, ok := zp.Next(); ok; rr, ok = zp.Next() {
+		if !miekg.IsSubDomain(origin, rr.Header().Name) {
+			return nil, fmt.Errorf("record %s is out of zone %s", rr.Header().Name, d.Name)
+		}
+		records = append(records, md.RecordFromRR(d.Name, rr))
+	}
+	if err := zp.Err(); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// ZoneImportPlan lists records of the zone file which are missing in domain d as the creates of a
+// plan, the others are returned as skipped
+func ZoneImportPlan(d *models.Domain, records []md.Record) (*Plan, []md.Record, error) {
+	live, err := DnsRecordList(d)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	plan := &Plan{Creates: []md.Record{}, Updates: []PlanUpdate{}, Deletes: []md.Record{}}
+	skipped := []md.Record{}
+	for i := range records {
+		record := &records[i]
+		exist := vendorManaged(record)
+		for j := 0; !exist && j < len(live); j++ {
+			exist = live[j].SameAs(record)
+		}
+		// the zone file may contain the same record twice
+		for j := 0; !exist && j < len(plan.Creates); j++ {
+			exist = plan.Creates[j].SameAs(record)
+		}
+		if exist {
+			skipped = append(skipped, *record)
+			continue
+		}
+		plan.Creates = append(plan.Creates, *record)
+	}
+	return plan, skipped, nil
+}
+
+// ImportZoneRecords creates records of the zone file which are missing in domain d, nothing is
+// created if any of them conflicts with the zone
+func ImportZoneRecords(d *models.Domain, records []md.Record) (*ZoneImportResult, error) {
+	plan, skipped, err := ZoneImportPlan(d, records)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &ZoneImportResult{
+		Created: []md.Record{},
+		Skipped: skipped,
+		Failed:  []ZoneImportFailed{},
+	}
+	batch := plan.Batch()
+	if err := CheckZoneConflicts(d, batch); err != nil {
+		return nil, err
+	}
+
+	for _, step := range batch.Steps {
+		dnsObj, err := DnsObjFromRecord(d, *step.After)
+		if err == nil {
+			err = dnsObj.Create()
+		}
+		if err != nil {
+			res.Failed = append(res.Failed, ZoneImportFailed{Record: *step.After, Error: err.Error()})
+			continue
+		}
+		res.Created = append(res.Created, dnsObj.ToRecord())
+	}
+	return res, nil
+}
@@ -0,0 +1,15 @@
+package routers
+
+import (
+	"domain0/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupAcmeRouterPub is authenticated by acme credentials instead of jwt
+func SetupAcmeRouterPub(r fiber.Router) {
+	acme := r.Group("/acme")
+	acme.Post("/update", services.AcmeDnsUpdate)
+	acme.Post("/present", services.AcmePresent)
+	acme.Post("/cleanup", services.AcmeCleanup)
+}
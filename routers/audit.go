@@ -0,0 +1,15 @@
+package routers
+
+import (
+	"domain0/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func SetupAuditRouter(r fiber.Router) {
+	r.Get("/audit", services.AuditList)
+}
+
+func SetUpAuditMiddleware(r fiber.Router) {
+	r.Use(services.AuditLog)
+}
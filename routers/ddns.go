@@ -0,0 +1,14 @@
+package routers
+
+import (
+	"domain0/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupDdnsRouterPub is authenticated by ddns tokens instead of jwt
+func SetupDdnsRouterPub(r fiber.Router) {
+	ddns := r.Group("/ddns")
+	ddns.Get("/update", services.DdnsUpdate)
+	ddns.Post("/update", services.DdnsUpdate)
+}
@@ -25,19 +25,39 @@ func SetupUserDomainRouter(r fiber.Router) {
 	r.Get(":id/user", services.UserDomainList)
 	r.Post(":id/user", services.UserDomainCreate)
 	r.Delete(":id/user/:uid", services.UserDomainDelete)
+	r.Post(":id/access/grant", services.DomainAccessGrantRequest)
+	r.Post(":id/access/revoke", services.DomainAccessRevokeRequest)
+	r.Get(":id/approval_policy", services.DomainApprovalPolicyGet)
+	r.Put(":id/approval_policy", services.DomainApprovalPolicyUpdate)
 }
 
 func SetupDomainDnsRouter(r fiber.Router) {
 	r.Get(":id/dns", services.DomainDnsList)
 	r.Post(":id/dns", services.DomainDnsCreate)
+	r.Post(":id/dns/batch", services.DomainDnsBatch)
 	r.Put(":id/dns/:dnsId", services.DomainDnsUpdate)
 	r.Delete(":id/dns/:dnsId", services.DomainDnsDelete)
+	r.Get(":id/dns/:dnsId/ddns", services.DomainDdnsTokenList)
+	r.Post(":id/dns/:dnsId/ddns", services.DomainDdnsTokenCreate)
+	r.Delete(":id/ddns/:tid", services.DomainDdnsTokenDelete)
+	r.Get(":id/acme", services.DomainAcmeCredentialList)
+	r.Post(":id/acme", services.DomainAcmeCredentialCreate)
+	r.Delete(":id/acme/:cid", services.DomainAcmeCredentialDelete)
+	r.Get(":id/zonefile", services.DomainZoneFileExport)
+	r.Post(":id/zonefile", services.DomainZoneFileImport)
+	r.Post(":id/sync/plan", services.DomainSyncPlan)
+	r.Post(":id/sync/apply", services.DomainSyncApply)
+	r.Get(":id/snapshots", services.DomainSnapshotList)
+	r.Get(":id/snapshots/:sid", services.DomainSnapshotGet)
+	r.Post(":id/snapshots/:sid/restore", services.DomainSnapshotRestore)
 }
 
 func SetupDomainChangeRouter(r fiber.Router) {
 	r.Get("/change/myapply", services.DomainChangeListMyApply)
 	r.Get("/change/myapprove", services.DomainChangeListMyApprove)
 	r.Put("/change/:id", services.DomainChangeCheck)
+	r.Post("/change/:id/cancel", services.DomainChangeCancel)
+	r.Get("/change/:id/diff", services.DomainChangeDiff)
 }
 
 func SetUpDomainChangeNotifyMiddleware(r fiber.Router) {
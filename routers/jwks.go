@@ -0,0 +1,12 @@
+package routers
+
+import (
+	"domain0/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupJwksRouter publishes the public keys for other services to verify jwt issued by domain0
+func SetupJwksRouter(r fiber.Router) {
+	r.Get("/.well-known/jwks.json", services.Jwks)
+}
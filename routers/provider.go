@@ -0,0 +1,11 @@
+package routers
+
+import (
+	"domain0/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func SetupProviderRouter(r fiber.Router) {
+	r.Get("/providers", services.ProviderList)
+}
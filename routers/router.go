@@ -5,9 +5,14 @@ import (
 )
 
 func InitRouter(fiber *fiber.App) {
+	SetupJwksRouter(fiber)
+
 	// init public router
 	r := fiber.Group("/api/v1")
+	SetUpAuditMiddleware(r)
 	SetupUserRouterPub(r)
+	SetupDdnsRouterPub(r)
+	SetupAcmeRouterPub(r)
 
 	// init fiber jwt
 	SetUpJwtTokenMiddleware(r)
@@ -15,4 +20,6 @@ func InitRouter(fiber *fiber.App) {
 	// init private router
 	SetupUserRouter(r)
 	SetupDomainRouter(r)
+	SetupProviderRouter(r)
+	SetupAuditRouter(r)
 }
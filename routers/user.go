@@ -1,7 +1,6 @@
 package routers
 
 import (
-	"domain0/config"
 	"domain0/services"
 
 	"github.com/gofiber/fiber/v2"
@@ -16,6 +15,7 @@ func SetupUserRouterPub(r fiber.Router) {
 	user := r.Group("/user")
 	user.Post("/login", services.Login)
 	user.Post("/register", services.Register)
+	user.Post("/refresh", services.Refresh)
 	user.Get("/feishu/enable", services.FeishuAuthEnable)
 	user.Get("/feishu", services.FeishuAuthRedirect)
 	user.Get("/oidc/enable", services.OIDCAuthEnable)
@@ -26,14 +26,20 @@ func SetupUserRouterPub(r fiber.Router) {
 func SetupUserRouter(r fiber.Router) {
 	user := r.Group("/user")
 	user.Get("/", services.UserList)
+	user.Post("/logout", services.Logout)
+	user.Get("/token", services.AccessTokenList)
+	user.Post("/token", services.AccessTokenCreate)
+	user.Delete("/token/:tid", services.AccessTokenDelete)
 	user.Get("/:id", services.UserInfoGet)
 	user.Put("/:id", services.UserInfoUpdate)
 	user.Delete("/:id", services.UserInfoDelete)
 }
 
 func SetUpJwtTokenMiddleware(r fiber.Router) {
+	r.Use(services.AccessTokenWare)
 	r.Use(jwtware.New(jwtware.Config{
-		SigningKey: []byte(config.CONFIG.JwtKey),
+		Filter:  services.IsAccessTokenAuth,
+		KeyFunc: services.JwtKeyFunc,
 	}))
 	r.Use(services.JwtToLocalsWare)
 }
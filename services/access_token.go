@@ -0,0 +1,296 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+
+	db "domain0/database"
+	"domain0/models"
+	mw "domain0/models/web"
+)
+
+const (
+	accessTokenPrefix     = "d0_" // never the start of a jwt, which is base64 of a json object
+	accessTokenBytes      = 32
+	accessTokenShownChars = 8
+	accessTokenTouchEvery = time.Minute // last used time is not written more often
+
+	localsAccessToken = "access_token"
+)
+
+// domainPath matches the domain id and sub resource of domain routes
+var domainPath = regexp.MustCompile(`^/api/v1/domain/(\d+)(?:/([a-z_]+))?`)
+
+// credentialPath matches routes of ddns and acme credentials, which outlive the token and change
+// records on their own, so they need admin even under dns
+var credentialPath = regexp.MustCompile(`^/api/v1/domain/\d+/(?:dns/[^/]+/ddns|ddns|acme)(?:/|$)`)
+
+// sub resources of domain routes which dns:write scope can change
+var dnsWriteResources = map[string]bool{
+	"dns":       true,
+	"zonefile":  true,
+	"sync":      true,
+	"snapshots": true,
+}
+
+var accessTokenScopes = map[string]bool{
+	models.ScopeRead:     true,
+	models.ScopeDnsWrite: true,
+	models.ScopeAdmin:    true,
+}
+
+func accessTokenHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func bearerAccessToken(c *fiber.Ctx) (string, bool) {
+	auth := c.Get(fiber.HeaderAuthorization)
+	if len(auth) < 7 || !strings.EqualFold(auth[:7], "Bearer ") {
+		return "", false
+	}
+	token := strings.TrimSpace(auth[7:])
+	return token, strings.HasPrefix(token, accessTokenPrefix)
+}
+
+// IsAccessTokenAuth reports whether the request is authenticated by a personal access token,
+// jwt middleware is skipped for it
+func IsAccessTokenAuth(c *fiber.Ctx) bool {
+	_, ok := c.Locals(localsAccessToken).(*models.AccessToken)
+	return ok
+}
+
+// AccessTokenWare authenticates personal access tokens, and checks the request is within
+// the scopes and domain allow-list of the token, other requests are left to jwt middleware
+func AccessTokenWare(c *fiber.Ctx) error {
+	raw, ok := bearerAccessToken(c)
+	if !ok {
+		return c.Next()
+	}
+
+	var token models.AccessToken
+	if err := db.DB.Where("token_hash = ?", accessTokenHash(raw)).First(&token).Error; err != nil || token.Expired() {
+		return c.Status(fiber.StatusUnauthorized).JSON(mw.User{
+			Status: fiber.StatusUnauthorized,
+			Errors: "invalid or expired access token",
+		})
+	}
+	var user models.User
+	if err := db.DB.Where("id = ?", token.UserId).First(&user).Error; err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(mw.User{
+			Status: fiber.StatusUnauthorized,
+			Errors: "invalid or expired access token",
+		})
+	}
+	c.Locals(localsAccessToken, &token)
+	c.Locals("sub", user.ID)
+	c.Locals("role", user.Role)
+	c.Locals(localsUserName, user.Name)
+
+	if now := time.Now(); token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) > accessTokenTouchEvery {
+		db.DB.Model(&token).Update("last_used_at", now)
+	}
+
+	if !accessTokenPermitted(c.Method(), c.Path(), &token) {
+		return c.Status(fiber.StatusForbidden).JSON(mw.User{
+			Status: fiber.StatusForbidden,
+			Errors: "access token scope does not permit this request",
+			Data:   token.ID,
+		})
+	}
+	return c.Next()
+}
+
+// accessTokenPermitted checks the request by the path, as the routes are not matched yet:
+// reads need read scope, changes of records and zones need dns:write, and others need admin,
+// a token with domain allow-list can only access routes of the allowed domains
+func accessTokenPermitted(method, path string, t *models.AccessToken) bool {
+	// routes are matched case insensitive and with trailing slash, so the path is too
+	path = strings.TrimRight(strings.ToLower(path), "/")
+	match := domainPath.FindStringSubmatch(path)
+	if t.Domains != "" {
+		if match == nil {
+			return false
+		}
+		id, err := strconv.ParseUint(match[1], 10, 64)
+		if err != nil || !t.DomainAllowed(uint(id)) {
+			return false
+		}
+	}
+
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return t.HasScope(models.ScopeRead)
+	}
+	if match != nil && dnsWriteResources[match[2]] && !credentialPath.MatchString(path) {
+		return t.HasScope(models.ScopeDnsWrite)
+	}
+	return t.HasScope(models.ScopeAdmin)
+}
+
+// @Summary List Access Tokens
+// @Description List personal access tokens of current user, tokens are not responded
+// @Tags user
+// @Produce json
+// @Success 200 {object} mw.User{data=[]models.AccessToken}
+// @Failure 500 {object} mw.User{data=int}
+// @Router /api/v1/user/token [get]
+func AccessTokenList(c *fiber.Ctx) error {
+	uId := c.Locals("sub").(uint)
+
+	var tokens []models.AccessToken
+	if err := db.DB.Where("user_id = ?", uId).Find(&tokens).Error; err != nil {
+		logrus.Error(err)
+		return c.Status(fiber.StatusInternalServerError).JSON(mw.User{
+			Status: fiber.StatusInternalServerError,
+			Errors: err.Error(),
+			Data:   uId,
+		})
+	}
+	return c.JSON(mw.User{
+		Status: fiber.StatusOK,
+		Data:   tokens,
+	})
+}
+
+// @Summary Create Access Token
+// @Description Create a personal access token of current user for API automation, it is used as
+// @Description bearer token like jwt, and acts as the user within its scopes:
+// @Description read for reads, dns:write for changes of records and zones, admin for everything
+// @Description else, including ddns and acme credentials
+// @Description domains limits the token to routes of these domains, user must have access to them
+// @Description the token is only responded here, keep it safe
+// @Tags user
+// @Accept json
+// @Param token body mw.AccessTokenCreate true "scopes, domains and expiry"
+// @Produce json
+// @Success 200 {object} mw.User{data=mw.AccessToken}
+// @Failure 400 {object} mw.User{data=int}
+// @Failure 403 {object} mw.User{data=int}
+// @Failure 500 {object} mw.User{data=int}
+// @Router /api/v1/user/token [post]
+func AccessTokenCreate(c *fiber.Ctx) error {
+	uId := c.Locals("sub").(uint)
+	isAdmin := c.Locals("role").(models.UserRole) >= models.Admin
+
+	var req mw.AccessTokenCreate
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(mw.User{
+			Status: fiber.StatusBadRequest,
+			Errors: err.Error(),
+			Data:   uId,
+		})
+	}
+	if len(req.Scopes) == 0 || req.ExpiresIn < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(mw.User{
+			Status: fiber.StatusBadRequest,
+			Errors: "scopes must not be empty and expires_in must not be negative",
+			Data:   uId,
+		})
+	}
+	for _, s := range req.Scopes {
+		if !accessTokenScopes[s] {
+			return c.Status(fiber.StatusBadRequest).JSON(mw.User{
+				Status: fiber.StatusBadRequest,
+				Errors: "unknown scope: " + s,
+				Data:   uId,
+			})
+		}
+	}
+	domains := make([]string, 0, len(req.Domains))
+	for _, dId := range req.Domains {
+		var domain models.Domain
+		if err := db.DB.Where("id = ?", dId).First(&domain).Error; err != nil ||
+			!(checkUserDomainPermission(uId, dId, models.ReadOnly) || (isAdmin && !domain.Privacy)) {
+			return c.Status(fiber.StatusForbidden).JSON(mw.User{
+				Status: fiber.StatusForbidden,
+				Errors: "permission denied to domain: " + strconv.FormatUint(uint64(dId), 10),
+				Data:   uId,
+			})
+		}
+		domains = append(domains, strconv.FormatUint(uint64(dId), 10))
+	}
+
+	b := make([]byte, accessTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		logrus.Error(err)
+		return c.Status(fiber.StatusInternalServerError).JSON(mw.User{
+			Status: fiber.StatusInternalServerError,
+			Errors: err.Error(),
+			Data:   uId,
+		})
+	}
+	raw := accessTokenPrefix + base64.RawURLEncoding.EncodeToString(b)
+	token := models.AccessToken{
+		UserId:    uId,
+		Name:      req.Name,
+		Scopes:    strings.Join(req.Scopes, " "),
+		Domains:   strings.Join(domains, ","),
+		Prefix:    raw[:len(accessTokenPrefix)+accessTokenShownChars],
+		TokenHash: accessTokenHash(raw),
+	}
+	if req.ExpiresIn > 0 {
+		expires := time.Now().AddDate(0, 0, req.ExpiresIn)
+		token.ExpiresAt = &expires
+	}
+	if err := db.DB.Create(&token).Error; err != nil {
+		logrus.Error(err)
+		return c.Status(fiber.StatusInternalServerError).JSON(mw.User{
+			Status: fiber.StatusInternalServerError,
+			Errors: err.Error(),
+			Data:   uId,
+		})
+	}
+	auditChange(c, nil, token)
+
+	logrus.Info("User: ", uId, " create access token: ", token.ID, " scopes: ", token.Scopes)
+	return c.JSON(mw.User{
+		Status: fiber.StatusOK,
+		Data:   mw.AccessToken{Token: raw, AccessToken: token},
+	})
+}
+
+// @Summary Delete Access Token
+// @Description Revoke a personal access token of current user
+// @Tags user
+// @Param tid path string true "access token id"
+// @Produce json
+// @Success 200 {object} mw.User{data=int}
+// @Failure 404 {object} mw.User{data=int}
+// @Failure 500 {object} mw.User{data=int}
+// @Router /api/v1/user/token/{tid} [delete]
+func AccessTokenDelete(c *fiber.Ctx) error {
+	uId := c.Locals("sub").(uint)
+
+	var token models.AccessToken
+	if err := db.DB.Where("id = ? AND user_id = ?", c.Params("tid"), uId).First(&token).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(mw.User{
+			Status: fiber.StatusNotFound,
+			Errors: "access token not found",
+			Data:   uId,
+		})
+	}
+	if err := db.DB.Delete(&token).Error; err != nil {
+		logrus.Error(err)
+		return c.Status(fiber.StatusInternalServerError).JSON(mw.User{
+			Status: fiber.StatusInternalServerError,
+			Errors: err.Error(),
+			Data:   uId,
+		})
+	}
+	auditChange(c, token, nil)
+	return c.JSON(mw.User{
+		Status: fiber.StatusOK,
+		Data:   token.ID,
+	})
+}
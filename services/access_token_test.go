@@ -0,0 +1,61 @@
+package services
+
+import (
+	"net/http"
+	"testing"
+
+	"domain0/models"
+)
+
+func TestAccessTokenPermitted(t *testing.T) {
+	read := &models.AccessToken{Scopes: "read"}
+	write := &models.AccessToken{Scopes: "dns:write"}
+	admin := &models.AccessToken{Scopes: "admin"}
+	domain1 := &models.AccessToken{Scopes: "dns:write", Domains: "1"}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		token  *models.AccessToken
+		want   bool
+	}{
+		{"read lists records", http.MethodGet, "/api/v1/domain/1/dns", read, true},
+		{"read lists domains", http.MethodGet, "/api/v1/domain", read, true},
+		{"read creates no record", http.MethodPost, "/api/v1/domain/1/dns", read, false},
+		{"write implies read", http.MethodGet, "/api/v1/domain/1/dns", write, true},
+		{"write creates record", http.MethodPost, "/api/v1/domain/1/dns", write, true},
+		{"write updates record", http.MethodPut, "/api/v1/domain/1/dns/5", write, true},
+		{"write deletes record", http.MethodDelete, "/api/v1/domain/1/dns/5", write, true},
+		{"write imports zone file", http.MethodPost, "/api/v1/domain/1/zonefile", write, true},
+		{"write applies sync", http.MethodPost, "/api/v1/domain/1/sync/apply", write, true},
+		{"write restores snapshot", http.MethodPost, "/api/v1/domain/1/snapshots/3/restore", write, true},
+		{"write lists ddns credentials", http.MethodGet, "/api/v1/domain/1/ddns", write, true},
+		{"write mints no ddns token", http.MethodPost, "/api/v1/domain/1/dns/5/ddns", write, false},
+		{"write deletes no ddns token", http.MethodDelete, "/api/v1/domain/1/ddns/2", write, false},
+		{"write runs batch", http.MethodPost, "/api/v1/domain/1/dns/batch", write, true},
+		{"write mints no acme credential", http.MethodPost, "/api/v1/domain/1/acme", write, false},
+		{"write deletes no acme credential", http.MethodDelete, "/api/v1/domain/1/acme/2", write, false},
+		{"write changes no domain", http.MethodPut, "/api/v1/domain/1", write, false},
+		{"write adds no domain user", http.MethodPost, "/api/v1/domain/1/user", write, false},
+		{"write creates no token", http.MethodPost, "/api/v1/user/token", write, false},
+		{"write mints no ddns token in upper case", http.MethodPost, "/api/v1/domain/1/dns/5/DDNS", write, false},
+		{"write mints no acme credential in mixed case", http.MethodPost, "/API/v1/Domain/1/Acme", write, false},
+		{"write mints no ddns token with trailing slash", http.MethodPost, "/api/v1/domain/1/dns/5/ddns/", write, false},
+		{"write creates record in upper case", http.MethodPost, "/api/v1/domain/1/DNS/", write, true},
+		{"other domain in upper case", http.MethodPost, "/API/V1/DOMAIN/2/DNS", domain1, false},
+		{"admin mints ddns token", http.MethodPost, "/api/v1/domain/1/dns/5/ddns", admin, true},
+		{"admin creates token", http.MethodPost, "/api/v1/user/token", admin, true},
+		{"allowed domain", http.MethodPost, "/api/v1/domain/1/dns", domain1, true},
+		{"other domain", http.MethodPost, "/api/v1/domain/2/dns", domain1, false},
+		{"domain id prefix", http.MethodGet, "/api/v1/domain/12/dns", domain1, false},
+		{"no domain route", http.MethodGet, "/api/v1/user/token", domain1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := accessTokenPermitted(tt.method, tt.path, tt.token); got != tt.want {
+				t.Errorf("accessTokenPermitted(%s %s, %q) = %v, want %v", tt.method, tt.path, tt.token.Scopes, got, tt.want)
+			}
+		})
+	}
+}
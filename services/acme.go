@@ -0,0 +1,505 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+
+	"domain0/config"
+	db "domain0/database"
+	"domain0/models"
+	mw "domain0/models/web"
+	"domain0/modules"
+	md "domain0/modules/dns"
+)
+
+const (
+	acmeChallengeLabel = "_acme-challenge"
+	acmeChallengeTTL   = 60 // ttl of challenge TXT records, raised to the min ttl of vendor
+	acmeValueLength    = 43 // base64url of sha256 without padding
+	acmeDnsKeepValues  = 2  // acme-dns keeps two values, for a name and its wildcard
+	acmeCleanInterval  = time.Minute
+)
+
+var errAcmeBadValue = errors.New("challenge value must be 43 characters of base64url")
+
+// acmeChallengeName is the name of challenge TXT records of the credential, relative to the domain
+func acmeChallengeName(cred *models.AcmeCredential) string {
+	if cred.Name == "@" || cred.Name == "" {
+		return acmeChallengeLabel
+	}
+	return acmeChallengeLabel + "." + cred.Name
+}
+
+func acmeValidValue(v string) bool {
+	if len(v) != acmeValueLength {
+		return false
+	}
+	_, err := base64.RawURLEncoding.DecodeString(v)
+	return err == nil
+}
+
+func acmeKeyHash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// newAcmeAccount generates username in uuid format and password of 40 characters, as acme-dns does
+func newAcmeAccount() (string, string, error) {
+	b := make([]byte, 16+30)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	u := hex.EncodeToString(b[:16])
+	username := fmt.Sprintf("%s-%s-%s-%s-%s", u[:8], u[8:12], u[12:16], u[16:20], u[20:])
+	return username, base64.RawURLEncoding.EncodeToString(b[16:]), nil
+}
+
+// @Summary List ACME Credentials
+// @Description List credentials for ACME DNS-01 challenges of the domain, keys are not responded
+// @Description user must have readwrite permission to domain or be admin
+// @Tags domain
+// @Param id path string true "domain id"
+// @Produce json
+// @Success 200 {object} mw.Domain{data=[]models.AcmeCredential}
+// @Failure 403 {object} mw.Domain{data=int}
+// @Failure 404 {object} mw.Domain{data=int}
+// @Failure 500 {object} mw.Domain{data=int}
+// @Router /api/v1/domain/{id}/acme [get]
+func DomainAcmeCredentialList(c *fiber.Ctx) error {
+	domain, ok, err := permittedDomain(c, models.ReadWrite)
+	if !ok {
+		return err
+	}
+
+	var creds []models.AcmeCredential
+	if err := db.DB.Where("domain_id = ?", domain.ID).Find(&creds).Error; err != nil {
+		logrus.Error(err)
+		return c.Status(fiber.StatusInternalServerError).JSON(mw.Domain{
+			Status: fiber.StatusInternalServerError,
+			Errors: err.Error(),
+			Data:   domain.ID,
+		})
+	}
+	return c.JSON(mw.Domain{
+		Status: fiber.StatusOK,
+		Data:   creds,
+	})
+}
+
+// @Summary Create ACME Credential
+// @Description Create a credential which can only create and delete TXT records of _acme-challenge.<name>,
+// @Description for acme-dns clients it is the registered account, for lego httpreq username and password
+// @Description are the basic auth, the password is only responded here, keep it safe
+// @Description user must have readwrite permission to domain or be admin,
+// @Description for ICP domain user must be owner, as changes by the credential are not reviewed
+// @Tags domain
+// @Accept json
+// @Param id path string true "domain id"
+// @Param credential body mw.AcmeCredentialCreate true "name the certificate is for"
+// @Produce json
+// @Success 200 {object} mw.Domain{data=mw.AcmeCredential}
+// @Failure 400 {object} mw.Domain{data=[]modules.FieldError}
+// @Failure 403 {object} mw.Domain{data=int}
+// @Failure 404 {object} mw.Domain{data=int}
+// @Failure 500 {object} mw.Domain{data=int}
+// @Router /api/v1/domain/{id}/acme [post]
+func DomainAcmeCredentialCreate(c *fiber.Ctx) error {
+	domain, ok, err := permittedDomain(c, models.ReadWrite)
+	if !ok {
+		return err
+	}
+	uId := c.Locals("sub").(uint)
+	if domain.ICPReg > 0 && !checkUserDomainPermission(uId, domain.ID, models.Owner) {
+		return c.Status(fiber.StatusForbidden).JSON(mw.Domain{
+			Status: fiber.StatusForbidden,
+			Errors: "ICP domain need owner permission to create acme credential",
+			Data:   domain.ID,
+		})
+	}
+
+	var req mw.AcmeCredentialCreate
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(mw.Domain{
+			Status: fiber.StatusBadRequest,
+			Errors: err.Error(),
+			Data:   domain.ID,
+		})
+	}
+	cred := models.AcmeCredential{
+		DomainId:    domain.ID,
+		Name:        md.RelativeName(strings.TrimPrefix(strings.TrimSpace(req.Name), "*."), domain.Name),
+		UserId:      uId,
+		Description: req.Description,
+	}
+	if cred.Name == "" {
+		cred.Name = "@"
+	}
+	// the challenge record must be valid for the vendor
+	challenge := md.Record{Name: acmeChallengeName(&cred), Type: "TXT", Content: "x", TTL: acmeTTL(domain)}
+	if err := modules.ValidateRecord(domain, &challenge); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(mw.Domain{
+			Status: fiber.StatusBadRequest,
+			Errors: err.Error(),
+			Data:   invalidRecordData(c, err),
+		})
+	}
+
+	username, password, err := newAcmeAccount()
+	if err != nil {
+		logrus.Error(err)
+		return c.Status(fiber.StatusInternalServerError).JSON(mw.Domain{
+			Status: fiber.StatusInternalServerError,
+			Errors: err.Error(),
+			Data:   domain.ID,
+		})
+	}
+	cred.Username, cred.KeyHash = username, acmeKeyHash(password)
+	if err := db.DB.Create(&cred).Error; err != nil {
+		logrus.Error(err)
+		return c.Status(fiber.StatusInternalServerError).JSON(mw.Domain{
+			Status: fiber.StatusInternalServerError,
+			Errors: err.Error(),
+			Data:   domain.ID,
+		})
+	}
+	auditChange(c, nil, cred)
+
+	logrus.Info("User: ", uId, " create acme credential: ", cred.ID, " for ", cred.Name, " of domain: ", domain.ID)
+	return c.JSON(mw.Domain{
+		Status: fiber.StatusOK,
+		Data: mw.AcmeCredential{
+			Username:   username,
+			Password:   password,
+			FullDomain: md.FQDN(acmeChallengeName(&cred), domain.Name),
+			Subdomain:  username,
+			AllowFrom:  []string{},
+			Credential: cred,
+		},
+	})
+}
+
+// @Summary Delete ACME Credential
+// @Description Revoke an acme credential of the domain, its challenge records are deleted
+// @Description user must have readwrite permission to domain or be admin
+// @Tags domain
+// @Param id path string true "domain id"
+// @Param cid path string true "acme credential id"
+// @Produce json
+// @Success 200 {object} mw.Domain{data=int}
+// @Failure 403 {object} mw.Domain{data=int}
+// @Failure 404 {object} mw.Domain{data=int}
+// @Failure 500 {object} mw.Domain{data=int}
+// @Router /api/v1/domain/{id}/acme/{cid} [delete]
+func DomainAcmeCredentialDelete(c *fiber.Ctx) error {
+	domain, ok, err := permittedDomain(c, models.ReadWrite)
+	if !ok {
+		return err
+	}
+
+	var cred models.AcmeCredential
+	if err := db.DB.Where("id = ? AND domain_id = ?", c.Params("cid"), domain.ID).First(&cred).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(mw.Domain{
+			Status: fiber.StatusNotFound,
+			Errors: "acme credential not found",
+			Data:   c.Params("cid"),
+		})
+	}
+	if err := db.DB.Delete(&cred).Error; err != nil {
+		logrus.Error(err)
+		return c.Status(fiber.StatusInternalServerError).JSON(mw.Domain{
+			Status: fiber.StatusInternalServerError,
+			Errors: err.Error(),
+			Data:   cred.ID,
+		})
+	}
+	var challenges []models.AcmeChallenge
+	db.DB.Where("credential_id = ?", cred.ID).Find(&challenges)
+	for i := range challenges {
+		if err := acmeCleanup(domain, &challenges[i]); err != nil {
+			logrus.Warn("Delete acme challenge: ", challenges[i].ID, " error: ", err)
+		}
+	}
+	auditChange(c, cred, nil)
+	return c.JSON(mw.Domain{
+		Status: fiber.StatusOK,
+		Data:   cred.ID,
+	})
+}
+
+// acmeTTL is the ttl of challenge records, the lowest the vendor accepts
+func acmeTTL(d *models.Domain) int {
+	ttl := acmeChallengeTTL
+	if p, ok := md.LookupProvider(d.Vendor); ok && p.Capabilities.MinTTL > ttl {
+		ttl = p.Capabilities.MinTTL
+	}
+	return ttl
+}
+
+// acmeAuth checks the username and key of a credential, and that its creator can still change the
+// domain without review, the creator is the actor in audit
+func acmeAuth(c *fiber.Ctx, username, key string) (*models.AcmeCredential, *models.Domain, bool) {
+	var cred models.AcmeCredential
+	if username == "" || db.DB.Where("username = ?", username).First(&cred).Error != nil ||
+		subtle.ConstantTimeCompare([]byte(acmeKeyHash(key)), []byte(cred.KeyHash)) != 1 {
+		return nil, nil, false
+	}
+	var domain models.Domain
+	if db.DB.Where("id = ?", cred.DomainId).First(&domain).Error != nil {
+		return nil, nil, false
+	}
+	user, ok := unreviewedPermitted(cred.UserId, &domain)
+	if !ok {
+		logrus.Info("ACME credential: ", cred.ID, " of user: ", cred.UserId, " is used without permission to domain: ", cred.DomainId)
+		return nil, nil, false
+	}
+	c.Locals("sub", user.ID)
+	c.Locals(localsUserName, user.Name)
+	auditDomain(c, domain.ID)
+
+	now := time.Now()
+	cred.LastUsedAt = &now
+	db.DB.Model(&cred).Update("last_used_at", now)
+	return &cred, &domain, true
+}
+
+// acmePresent creates the challenge TXT record of value like other record creates, checked against the
+// zone and kept in versions, it is deleted after challenge_ttl of config if the client does not clean it up
+func acmePresent(c *fiber.Ctx, cred *models.AcmeCredential, d *models.Domain, value string) error {
+	var existing models.AcmeChallenge
+	if db.DB.Where("credential_id = ? AND value = ?", cred.ID, value).First(&existing).Error == nil {
+		return nil
+	}
+
+	record := md.Record{Name: acmeChallengeName(cred), Type: "TXT", Content: value, TTL: acmeTTL(d)}
+	dnsObj, err := modules.DnsObjFromRecord(d, record)
+	if err != nil {
+		return err
+	}
+	if err := modules.CheckRecordConflicts(d, &record); err != nil {
+		return err
+	}
+	created, err := createRecord(d, cred.UserId, dnsObj)
+	if err != nil {
+		return err
+	}
+	ch := models.AcmeChallenge{
+		CredentialId: cred.ID,
+		DomainId:     d.ID,
+		RecordId:     created.Id,
+		Value:        value,
+		ExpiresAt:    time.Now().Add(time.Duration(config.CONFIG.Acme.ChallengeTTL) * time.Second),
+	}
+	if err := db.DB.Create(&ch).Error; err != nil {
+		return err
+	}
+	auditRecord(c, created.Id)
+	auditChange(c, nil, created)
+	logrus.Info("ACME credential: ", cred.ID, " create challenge record: ", created.Id, " of domain: ", d.ID)
+	return nil
+}
+
+// acmeCleanup deletes the challenge record, a record already deleted outside is ignored
+func acmeCleanup(d *models.Domain, ch *models.AcmeChallenge) error {
+	dnsObj, err := modules.DnsObjGen(d)
+	if err != nil {
+		return err
+	}
+	if err := dnsObj.Get(ch.RecordId); err == nil {
+		if err := dnsObj.Delete(); err != nil {
+			return err
+		}
+	} else {
+		logrus.Warn("ACME challenge record: ", ch.RecordId, " of domain: ", d.ID, " is not found: ", err)
+	}
+	return db.DB.Delete(ch).Error
+}
+
+// StartAcmeCleaner deletes the challenge records not cleaned up by clients in challenge_ttl of config
+func StartAcmeCleaner() {
+	go func() {
+		for {
+			time.Sleep(acmeCleanInterval)
+			cleanAcmeChallenges()
+		}
+	}()
+}
+
+func cleanAcmeChallenges() {
+	var challenges []models.AcmeChallenge
+	if err := db.DB.Where("expires_at < ?", time.Now()).Find(&challenges).Error; err != nil {
+		logrus.Errorf("acme cleaner: query challenges error:%v", err)
+		return
+	}
+	for i := range challenges {
+		var domain models.Domain
+		if err := db.DB.Where("id = ?", challenges[i].DomainId).First(&domain).Error; err != nil {
+			db.DB.Delete(&challenges[i])
+			continue
+		}
+		if err := acmeCleanup(&domain, &challenges[i]); err != nil {
+			logrus.Warnf("acme cleaner: delete challenge %d of domain %s error:%v", challenges[i].ID, domain.Name, err)
+		}
+	}
+}
+
+// @Summary ACME DNS Update
+// @Description Set the challenge TXT record, compatible with acme-dns /update, the two latest values
+// @Description are kept for a name and its wildcard, older ones are deleted
+// @Description subdomain is the username of the credential, the TXT record is _acme-challenge.<name>
+// @Tags acme
+// @Accept json
+// @Param X-Api-User header string true "username of acme credential"
+// @Param X-Api-Key header string true "password of acme credential"
+// @Param body body object true "{\"subdomain\": \"<username>\", \"txt\": \"<value>\"}"
+// @Produce json
+// @Success 200 {object} object "{\"txt\": \"<value>\"}"
+// @Failure 400 {object} object "{\"error\": \"bad_txt\"}"
+// @Failure 401 {object} object "{\"error\": \"forbidden\"}"
+// @Failure 409 {object} object "{\"error\": \"<conflict with the zone>\"}"
+// @Failure 500 {object} object "{\"error\": \"<message>\"}"
+// @Router /api/v1/acme/update [post]
+func AcmeDnsUpdate(c *fiber.Ctx) error {
+	cred, domain, ok := acmeAuth(c, c.Get("X-Api-User"), c.Get("X-Api-Key"))
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "forbidden"})
+	}
+	var req struct {
+		Subdomain string `json:"subdomain"`
+		Txt       string `json:"txt"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "malformed_json_payload"})
+	}
+	if !strings.EqualFold(req.Subdomain, cred.Username) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "forbidden"})
+	}
+	if !acmeValidValue(req.Txt) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "bad_txt"})
+	}
+
+	if err := acmePresent(c, cred, domain, req.Txt); err != nil {
+		logrus.Error(err)
+		return c.Status(recordErrorStatus(err, fiber.StatusInternalServerError)).JSON(fiber.Map{"error": err.Error()})
+	}
+	var older []models.AcmeChallenge
+	db.DB.Where("credential_id = ?", cred.ID).Order("id desc").Offset(acmeDnsKeepValues).Find(&older)
+	for i := range older {
+		if err := acmeCleanup(domain, &older[i]); err != nil {
+			logrus.Warn("Delete acme challenge: ", older[i].ID, " error: ", err)
+		}
+	}
+	return c.JSON(fiber.Map{"txt": req.Txt})
+}
+
+// acmeHttpreqValue gets the challenge value from body of lego httpreq, in default mode it is given,
+// in raw mode it is computed from the key authorization
+func acmeHttpreqValue(c *fiber.Ctx, cred *models.AcmeCredential, d *models.Domain) (string, error) {
+	var req struct {
+		FQDN    string `json:"fqdn"`
+		Value   string `json:"value"`
+		Domain  string `json:"domain"`
+		KeyAuth string `json:"keyAuth"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return "", err
+	}
+	fqdn, value := req.FQDN, req.Value
+	if req.KeyAuth != "" {
+		sum := sha256.Sum256([]byte(req.KeyAuth))
+		fqdn = acmeChallengeLabel + "." + strings.TrimPrefix(req.Domain, "*.")
+		value = base64.RawURLEncoding.EncodeToString(sum[:])
+	}
+	if want := md.FQDN(acmeChallengeName(cred), d.Name); !strings.EqualFold(strings.TrimSuffix(fqdn, "."), want) {
+		return "", fmt.Errorf("credential is only for %s", want)
+	}
+	if !acmeValidValue(value) {
+		return "", errAcmeBadValue
+	}
+	return value, nil
+}
+
+// acmeHttpreq authenticates lego httpreq by basic auth and gets the challenge value, the returned
+// error is the response on failure
+func acmeHttpreq(c *fiber.Ctx) (*models.AcmeCredential, *models.Domain, string, error) {
+	username, password := "", ""
+	if auth := c.Get(fiber.HeaderAuthorization); strings.HasPrefix(auth, "Basic ") {
+		if raw, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(auth, "Basic ")); err == nil {
+			username, password, _ = strings.Cut(string(raw), ":")
+		}
+	}
+	cred, domain, ok := acmeAuth(c, username, password)
+	if !ok {
+		c.Set(fiber.HeaderWWWAuthenticate, `Basic realm="acme"`)
+		return nil, nil, "", c.Status(fiber.StatusUnauthorized).SendString("forbidden")
+	}
+	value, err := acmeHttpreqValue(c, cred, domain)
+	if err != nil {
+		return nil, nil, "", c.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+	return cred, domain, value, nil
+}
+
+// @Summary ACME Present
+// @Description Create the challenge TXT record, compatible with lego httpreq /present in default and raw mode
+// @Description username and password of the acme credential are the basic auth
+// @Tags acme
+// @Accept json
+// @Param body body object true "{\"fqdn\": \"_acme-challenge.<name>.\", \"value\": \"<value>\"}, or {\"domain\", \"token\", \"keyAuth\"} in raw mode"
+// @Produce plain
+// @Success 200 {string} string
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Failure 409 {string} string
+// @Failure 500 {string} string
+// @Router /api/v1/acme/present [post]
+func AcmePresent(c *fiber.Ctx) error {
+	cred, domain, value, err := acmeHttpreq(c)
+	if cred == nil {
+		return err
+	}
+	if err := acmePresent(c, cred, domain, value); err != nil {
+		logrus.Error(err)
+		return c.Status(recordErrorStatus(err, fiber.StatusInternalServerError)).SendString(err.Error())
+	}
+	return c.SendString("ok")
+}
+
+// @Summary ACME Cleanup
+// @Description Delete the challenge TXT record, compatible with lego httpreq /cleanup in default and raw mode
+// @Description username and password of the acme credential are the basic auth
+// @Tags acme
+// @Accept json
+// @Param body body object true "{\"fqdn\": \"_acme-challenge.<name>.\", \"value\": \"<value>\"}, or {\"domain\", \"token\", \"keyAuth\"} in raw mode"
+// @Produce plain
+// @Success 200 {string} string
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Failure 500 {string} string
+// @Router /api/v1/acme/cleanup [post]
+func AcmeCleanup(c *fiber.Ctx) error {
+	cred, domain, value, err := acmeHttpreq(c)
+	if cred == nil {
+		return err
+	}
+	var challenges []models.AcmeChallenge
+	db.DB.Where("credential_id = ? AND value = ?", cred.ID, value).Find(&challenges)
+	for i := range challenges {
+		if err := acmeCleanup(domain, &challenges[i]); err != nil {
+			logrus.Error(err)
+			return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+		}
+		auditRecord(c, challenges[i].RecordId)
+		auditChange(c, md.Record{Id: challenges[i].RecordId, Name: acmeChallengeName(cred), Type: "TXT", Content: value}, nil)
+	}
+	return c.SendString("ok")
+}
@@ -0,0 +1,221 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+
+	db "domain0/database"
+	"domain0/models"
+	mw "domain0/models/web"
+)
+
+// loadApprovalPolicy gets the approval policy of domain, or the default one
+func loadApprovalPolicy(domainId uint) models.ApprovalPolicy {
+	var policies []models.ApprovalPolicy
+	if err := db.DB.Where("domain_id = ?", domainId).Limit(1).Find(&policies).Error; err != nil || len(policies) == 0 {
+		return models.DefaultApprovalPolicy(domainId)
+	}
+	return policies[0]
+}
+
+// reviewerKind tells how the user can vote for the change, as a domain reviewer of approverRole,
+// or as an admin if policy needs admins, admin have no access to privacy domain
+func reviewerKind(uId uint, role models.UserRole, dc *models.DomainChange, policy *models.ApprovalPolicy) (bool, bool) {
+	if uId == dc.UserId {
+		return false, false
+	}
+	domainReviewer := checkUserDomainPermission(uId, dc.DomainId, approverRole(dc))
+	if policy.Admins == 0 || role < models.Admin {
+		return domainReviewer, false
+	}
+	if !checkUserDomainPermission(uId, dc.DomainId, models.ReadOnly) {
+		var d models.Domain
+		if err := db.DB.Where("id = ?", dc.DomainId).First(&d).Error; err != nil || d.Privacy {
+			return domainReviewer, false
+		}
+	}
+	return domainReviewer, true
+}
+
+// quorumMet reports whether the approvals satisfy policy, a vote of reviewer being both domain
+// reviewer and admin is counted for either side that needs it
+func quorumMet(dc *models.DomainChange, policy *models.ApprovalPolicy, votes []models.DomainChangeVote) bool {
+	var owners, admins, both int
+	for _, v := range votes {
+		if !v.Approve {
+			continue
+		}
+		var u models.User
+		if err := db.DB.Where("id = ?", v.ReviewerId).First(&u).Error; err != nil {
+			continue
+		}
+		domainReviewer, admin := reviewerKind(u.ID, u.Role, dc, policy)
+		switch {
+		case domainReviewer && admin:
+			both++
+		case domainReviewer:
+			owners++
+		case admin:
+			admins++
+		}
+	}
+	return quorumSatisfied(policy, owners, admins, both)
+}
+
+// quorumSatisfied reports whether approvals of owners, admins and reviewers being both satisfy
+// policy, a vote of both fills the lack of either side
+func quorumSatisfied(policy *models.ApprovalPolicy, owners, admins, both int) bool {
+	lack := 0
+	if owners < policy.Owners {
+		lack += policy.Owners - owners
+	}
+	if admins < policy.Admins {
+		lack += policy.Admins - admins
+	}
+	return lack <= both
+}
+
+// unreachablePolicy tells why no change of the domain could satisfy policy with its owners and the
+// admins, empty if one could, a vote counts for one side only, so the sides need distinct reviewers
+func unreachablePolicy(policy *models.ApprovalPolicy, privacy bool, owners, admins []uint) string {
+	if privacy && policy.Admins > 0 {
+		return "admin have no access to privacy domain"
+	}
+	if policy.Owners > len(owners) {
+		return fmt.Sprintf("%d owner approvals are needed, the domain has %d owners", policy.Owners, len(owners))
+	}
+	if policy.Admins > len(admins) {
+		return fmt.Sprintf("%d admin approvals are needed, there are %d admins", policy.Admins, len(admins))
+	}
+	reviewers := make(map[uint]bool, len(owners)+len(admins))
+	for _, id := range owners {
+		reviewers[id] = true
+	}
+	for _, id := range admins {
+		reviewers[id] = true
+	}
+	if policy.Owners+policy.Admins > len(reviewers) {
+		return fmt.Sprintf("%d approvals are needed, there are %d owners and admins", policy.Owners+policy.Admins, len(reviewers))
+	}
+	return ""
+}
+
+// @Summary Get Domain Approval Policy
+// @Description Get the quorum of domain changes, domain without policy needs 1 owner
+// @Description user must have read permission to domain or be admin
+// @Tags domain
+// @Param id path string true "domain id"
+// @Produce json
+// @Success 200 {object} mw.Domain{data=models.ApprovalPolicy}
+// @Failure 403 {object} mw.Domain{data=int}
+// @Failure 404 {object} mw.Domain{data=int}
+// @Router /api/v1/domain/{id}/approval_policy [get]
+func DomainApprovalPolicyGet(c *fiber.Ctx) error {
+	domain, ok, err := permittedDomain(c, models.ReadOnly)
+	if !ok {
+		return err
+	}
+
+	return c.JSON(mw.Domain{
+		Status: fiber.StatusOK,
+		Data:   loadApprovalPolicy(domain.ID),
+	})
+}
+
+// @Summary Update Domain Approval Policy
+// @Description Set the quorum of domain changes, e.g. 2 owners, or 1 owner and 1 admin
+// @Description a vote counts for owners or admins, not both, any reject vote rejects the change
+// @Description the quorum must be reachable by the current owners and admins, and privacy domain needs no admins
+// @Description user must have owner permission to domain
+// @Tags domain
+// @Accept json
+// @Param id path string true "domain id"
+// @Param policy body models.ApprovalPolicy true "owners and admins"
+// @Produce json
+// @Success 200 {object} mw.Domain{data=models.ApprovalPolicy}
+// @Failure 400 {object} mw.Domain{data=int}
+// @Failure 403 {object} mw.Domain{data=int}
+// @Failure 404 {object} mw.Domain{data=int}
+// @Failure 500 {object} mw.Domain{data=int}
+// @Router /api/v1/domain/{id}/approval_policy [put]
+func DomainApprovalPolicyUpdate(c *fiber.Ctx) error {
+	// get domainId restful api
+	qId := c.Params("id")
+
+	// get query user info from jwt sub
+	uId := c.Locals("sub").(uint)
+
+	// only owner can loosen or tighten the review of his domain
+	if !checkUserDomainPermission(uId, qId, models.Owner) {
+		return c.Status(fiber.StatusForbidden).JSON(mw.Domain{
+			Status: fiber.StatusForbidden,
+			Errors: "permission denied",
+			Data:   qId,
+		})
+	}
+
+	// get domain info
+	var domain models.Domain
+	if err := db.DB.Where("id = ?", qId).First(&domain).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(mw.Domain{
+			Status: fiber.StatusNotFound,
+			Errors: "domain not found",
+			Data:   qId,
+		})
+	}
+
+	var req models.ApprovalPolicy
+	if err := c.BodyParser(&req); err != nil || req.Owners < 0 || req.Admins < 0 || req.Owners+req.Admins == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(mw.Domain{
+			Status: fiber.StatusBadRequest,
+			Errors: "invalid policy, at least one approval is needed",
+			Data:   qId,
+		})
+	}
+	var owners, admins []uint
+	if err := db.DB.Model(&models.UserDomain{}).Where("domain_id = ? AND role >= ?", domain.ID, models.Owner).
+		Pluck("user_id", &owners).Error; err != nil {
+		logrus.Errorf("load domain owners error: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(mw.Domain{
+			Status: fiber.StatusInternalServerError,
+			Errors: "internal server error",
+			Data:   qId,
+		})
+	}
+	if err := db.DB.Model(&models.User{}).Where("role >= ?", models.Admin).Pluck("id", &admins).Error; err != nil {
+		logrus.Errorf("load admins error: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(mw.Domain{
+			Status: fiber.StatusInternalServerError,
+			Errors: "internal server error",
+			Data:   qId,
+		})
+	}
+	if msg := unreachablePolicy(&req, domain.Privacy, owners, admins); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(mw.Domain{
+			Status: fiber.StatusBadRequest,
+			Errors: "invalid policy, " + msg,
+			Data:   qId,
+		})
+	}
+
+	policy := loadApprovalPolicy(domain.ID)
+	old := policy
+	policy.Owners = req.Owners
+	policy.Admins = req.Admins
+	if err := db.DB.Save(&policy).Error; err != nil {
+		logrus.Errorf("save approval policy error: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(mw.Domain{
+			Status: fiber.StatusInternalServerError,
+			Errors: "internal server error",
+			Data:   qId,
+		})
+	}
+	auditChange(c, old, policy)
+
+	return c.JSON(mw.Domain{
+		Status: fiber.StatusOK,
+		Data:   policy,
+	})
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"testing"
+
+	"domain0/models"
+)
+
+func TestQuorumSatisfied(t *testing.T) {
+	tests := []struct {
+		name                 string
+		policy               models.ApprovalPolicy
+		owners, admins, both int
+		want                 bool
+	}{
+		{"default by owner", models.DefaultApprovalPolicy(1), 1, 0, 0, true},
+		{"default by both", models.DefaultApprovalPolicy(1), 0, 0, 1, true},
+		{"default by admin only", models.DefaultApprovalPolicy(1), 0, 1, 0, false},
+		{"default without vote", models.DefaultApprovalPolicy(1), 0, 0, 0, false},
+		{"owner and admin", models.ApprovalPolicy{Owners: 1, Admins: 1}, 1, 1, 0, true},
+		{"owner and admin lack admin", models.ApprovalPolicy{Owners: 1, Admins: 1}, 2, 0, 0, false},
+		{"both fills one side", models.ApprovalPolicy{Owners: 1, Admins: 1}, 1, 0, 1, true},
+		{"both counts once", models.ApprovalPolicy{Owners: 1, Admins: 1}, 0, 0, 1, false},
+		{"two both fill two sides", models.ApprovalPolicy{Owners: 1, Admins: 1}, 0, 0, 2, true},
+		{"two owners", models.ApprovalPolicy{Owners: 2}, 1, 0, 0, false},
+		{"two owners with both", models.ApprovalPolicy{Owners: 2}, 1, 0, 1, true},
+		{"no quorum", models.ApprovalPolicy{}, 0, 0, 0, true},
+		{"extra votes", models.ApprovalPolicy{Owners: 1}, 3, 2, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := quorumSatisfied(&tt.policy, tt.owners, tt.admins, tt.both); got != tt.want {
+				t.Errorf("quorumSatisfied(%+v, %d, %d, %d) = %v, want %v",
+					tt.policy, tt.owners, tt.admins, tt.both, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnreachablePolicy(t *testing.T) {
+	tests := []struct {
+		name      string
+		policy    models.ApprovalPolicy
+		privacy   bool
+		owners    []uint
+		admins    []uint
+		reachable bool
+	}{
+		{"one owner", models.ApprovalPolicy{Owners: 1}, false, []uint{1}, nil, true},
+		{"more owners than domain has", models.ApprovalPolicy{Owners: 2}, false, []uint{1}, []uint{2, 3}, false},
+		{"owner and admin", models.ApprovalPolicy{Owners: 1, Admins: 1}, false, []uint{1}, []uint{2}, true},
+		{"more admins than there are", models.ApprovalPolicy{Admins: 2}, false, []uint{1}, []uint{2}, false},
+		{"admin on privacy domain", models.ApprovalPolicy{Owners: 1, Admins: 1}, true, []uint{1}, []uint{2}, false},
+		{"owners on privacy domain", models.ApprovalPolicy{Owners: 1}, true, []uint{1}, []uint{2}, true},
+		{"owner being admin votes once", models.ApprovalPolicy{Owners: 1, Admins: 1}, false, []uint{1}, []uint{1}, false},
+		{"owners being admins", models.ApprovalPolicy{Owners: 1, Admins: 1}, false, []uint{1, 2}, []uint{2}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := unreachablePolicy(&tt.policy, tt.privacy, tt.owners, tt.admins)
+			if reachable := msg == ""; reachable != tt.reachable {
+				t.Errorf("unreachablePolicy() = %q, want reachable %v", msg, tt.reachable)
+			}
+		})
+	}
+}
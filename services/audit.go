@@ -0,0 +1,236 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	db "domain0/database"
+	"domain0/models"
+	mw "domain0/models/web"
+)
+
+const (
+	localsAuditDomain = "audit_domain"
+	localsAuditRecord = "audit_record"
+	localsAuditOld    = "audit_old"
+	localsAuditNew    = "audit_new"
+	localsDryRun      = "dry_run"
+
+	auditDefaultPageSize = 50
+	auditMaxPageSize     = 500
+)
+
+// auditDomain sets the domain of the audit event, by default it's the id in path of domain routes
+func auditDomain(c *fiber.Ctx, domainId uint) {
+	c.Locals(localsAuditDomain, domainId)
+}
+
+// auditRecord sets the dns record of the audit event, by default it's the dnsId in path
+func auditRecord(c *fiber.Ctx, recordId string) {
+	c.Locals(localsAuditRecord, recordId)
+}
+
+// auditChange sets the old and new value of the mutation, nil if there is none,
+// values are marshaled to json, so secrets must be hidden by json tags
+func auditChange(c *fiber.Ctx, oldValue, newValue interface{}) {
+	c.Locals(localsAuditOld, oldValue)
+	c.Locals(localsAuditNew, newValue)
+}
+
+func auditValue(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		logrus.Errorf("marshal audit value error: %v", err)
+		return ""
+	}
+	return string(b)
+}
+
+// AuditLog records every mutating request as an AuditEvent after it's handled
+func AuditLog(c *fiber.Ctx) error {
+	readOnly := false
+	switch c.Method() {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		readOnly = true
+	}
+	err := c.Next()
+	// nothing is changed if a dry run is responded, routes without dry run change even with the query
+	if respondedDryRun(c) {
+		return err
+	}
+	// reads are not recorded unless they make a change, e.g. ddns update by GET of dyndns2 protocol
+	if readOnly && c.Locals(localsAuditNew) == nil {
+		return err
+	}
+
+	status := c.Response().StatusCode()
+	if err != nil {
+		if e, ok := err.(*fiber.Error); ok {
+			status = e.Code
+		} else {
+			status = fiber.StatusInternalServerError
+		}
+	}
+	event := models.AuditEvent{
+		IP:        c.IP(),
+		UserAgent: c.Get(fiber.HeaderUserAgent),
+		Action:    c.Method() + " " + c.Route().Path,
+		OldValue:  auditValue(c.Locals(localsAuditOld)),
+		NewValue:  auditValue(c.Locals(localsAuditNew)),
+		Status:    status,
+		Result:    err == nil && status/100 == 2,
+	}
+	// not set if the request is rejected by jwt middleware
+	event.UserId, _ = c.Locals("sub").(uint)
+	event.UserName, _ = c.Locals(localsUserName).(string)
+	if domainId, ok := c.Locals(localsAuditDomain).(uint); ok {
+		event.DomainId = domainId
+	} else if strings.HasPrefix(c.Route().Path, "/api/v1/domain/:id") {
+		domainId, _ := strconv.ParseUint(c.Params("id"), 10, 0)
+		event.DomainId = uint(domainId)
+	}
+	if recordId, ok := c.Locals(localsAuditRecord).(string); ok {
+		event.RecordId = recordId
+	} else {
+		event.RecordId = c.Params("dnsId")
+	}
+	if err != nil {
+		event.Error = err.Error()
+	} else if !event.Result {
+		event.Error = responseError(c)
+	}
+
+	if dbErr := db.DB.Create(&event).Error; dbErr != nil {
+		logrus.Errorf("save audit event error: %v", dbErr)
+	}
+	return err
+}
+
+// responseError gets the error message of mw.Domain or mw.User response
+func responseError(c *fiber.Ctx) string {
+	var resp struct {
+		Errors string `json:"errors"`
+		Error  string `json:"error"`
+	}
+	if err := json.Unmarshal(c.Response().Body(), &resp); err != nil {
+		return ""
+	}
+	if resp.Errors != "" {
+		return resp.Errors
+	}
+	return resp.Error
+}
+
+// @Summary List audit events
+// @Description List audit events of mutating requests, newest first
+// @Description admin can list all events except those of privacy domains not granted to him,
+// @Description other users can list events of domains they own
+// @Tags audit
+// @Param domain_id query int false "domain id"
+// @Param user_id query int false "actor user id"
+// @Param record_id query string false "dns record id"
+// @Param action query string false "substring of action, e.g. /dns"
+// @Param result query bool false "true for succeeded, false for failed"
+// @Param from query string false "RFC 3339 time, inclusive"
+// @Param to query string false "RFC 3339 time, exclusive"
+// @Param page query int false "page, start from 1"
+// @Param page_size query int false "page size, default 50, max 500"
+// @Produce json
+// @Success 200 {object} mw.Domain{data=mw.AuditPage}
+// @Failure 400 {object} mw.Domain
+// @Failure 500 {object} mw.Domain
+// @Router /api/v1/audit [get]
+func AuditList(c *fiber.Ctx) error {
+	// get query user info from jwt sub
+	uId := c.Locals("sub").(uint)
+	isAdmin := c.Locals("role").(models.UserRole) >= models.Admin
+
+	var q mw.AuditQuery
+	if err := c.QueryParser(&q); err != nil || q.Page < 0 || q.PageSize < 0 || q.PageSize > auditMaxPageSize {
+		return c.Status(fiber.StatusBadRequest).JSON(mw.Domain{
+			Status: fiber.StatusBadRequest,
+			Errors: "invalid query",
+		})
+	}
+
+	tx := db.DB.Model(&models.AuditEvent{})
+	ownedDomains := db.DB.Model(&models.UserDomain{}).Select("domain_id").
+		Where("user_id = ? AND role >= ?", uId, models.Owner)
+	if isAdmin {
+		// admin have no access to privacy domain
+		privacyDomains := db.DB.Model(&models.Domain{}).Select("id").Where("privacy = ?", true)
+		tx = tx.Where("(domain_id NOT IN (?) OR domain_id IN (?))", privacyDomains, ownedDomains)
+	} else {
+		tx = tx.Where("domain_id IN (?)", ownedDomains)
+	}
+
+	if q.DomainId != 0 {
+		tx = tx.Where("domain_id = ?", q.DomainId)
+	}
+	if q.UserId != 0 {
+		tx = tx.Where("user_id = ?", q.UserId)
+	}
+	if q.RecordId != "" {
+		tx = tx.Where("record_id = ?", q.RecordId)
+	}
+	if q.Action != "" {
+		tx = tx.Where("action LIKE ?", "%"+q.Action+"%")
+	}
+	if q.Result != nil {
+		tx = tx.Where("result = ?", *q.Result)
+	}
+	for _, t := range []struct {
+		value string
+		cond  string
+	}{{q.From, "created_at >= ?"}, {q.To, "created_at < ?"}} {
+		if t.value == "" {
+			continue
+		}
+		at, err := time.Parse(time.RFC3339, t.value)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(mw.Domain{
+				Status: fiber.StatusBadRequest,
+				Errors: "invalid time, must be RFC 3339",
+			})
+		}
+		tx = tx.Where(t.cond, at)
+	}
+
+	page := mw.AuditPage{Page: q.Page, PageSize: q.PageSize}
+	if page.Page == 0 {
+		page.Page = 1
+	}
+	if page.PageSize == 0 {
+		page.PageSize = auditDefaultPageSize
+	}
+	if err := tx.Session(&gorm.Session{}).Count(&page.Total).Error; err != nil {
+		logrus.Errorf("count audit events error: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(mw.Domain{
+			Status: fiber.StatusInternalServerError,
+			Errors: "Database error",
+		})
+	}
+	if err := tx.Order("id desc").Offset((page.Page - 1) * page.PageSize).Limit(page.PageSize).
+		Find(&page.Events).Error; err != nil {
+		logrus.Errorf("list audit events error: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(mw.Domain{
+			Status: fiber.StatusInternalServerError,
+			Errors: "Database error",
+		})
+	}
+
+	return c.JSON(mw.Domain{
+		Status: fiber.StatusOK,
+		Data:   page,
+	})
+}
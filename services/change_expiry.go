@@ -0,0 +1,108 @@
+package services
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"domain0/bot"
+	"domain0/config"
+	db "domain0/database"
+	"domain0/models"
+)
+
+const changeExpiryCheckInterval = time.Minute
+
+// StartChangeExpirer closes the domain changes not reviewed in the window of config
+func StartChangeExpirer() {
+	if config.CONFIG.Change.Expiry <= 0 {
+		logrus.Info("domain change expiry is disabled")
+		return
+	}
+	go func() {
+		for {
+			expireChanges()
+			time.Sleep(changeExpiryCheckInterval)
+		}
+	}()
+}
+
+func expireChanges() {
+	var dcList []models.DomainChange
+	deadline := time.Now().Add(-time.Duration(config.CONFIG.Change.Expiry) * time.Second)
+	if err := db.DB.Where("action_status = ? AND created_at < ?", models.Reviewing, deadline).
+		Find(&dcList).Error; err != nil {
+		logrus.Errorf("change expiry: query domain changes error:%v", err)
+		return
+	}
+	for i := range dcList {
+		closed, err := closeDomainChange(&dcList[i], models.Expired, "")
+		if err != nil {
+			logrus.Errorf("change expiry: close domain change %d error:%v", dcList[i].ID, err)
+			continue
+		}
+		if closed {
+			logrus.Info("domain change ", dcList[i].ID, " is expired")
+			notifyChangeOutcome(&dcList[i], 0)
+		}
+	}
+}
+
+// changeExpired reports whether the change is out of the review window, it may be not closed yet
+func changeExpired(dc *models.DomainChange) bool {
+	if config.CONFIG.Change.Expiry <= 0 || dc.ActionStatus != models.Reviewing {
+		return false
+	}
+	return time.Since(dc.CreatedAt) > time.Duration(config.CONFIG.Change.Expiry)*time.Second
+}
+
+// closeDomainChange sets the status of a reviewing change without applying it,
+// false is returned if the change is closed by others already
+func closeDomainChange(dc *models.DomainChange, status models.ActionStatus, comment string) (bool, error) {
+	result := db.DB.Model(&models.DomainChange{}).
+		Where("id = ? AND action_status = ?", dc.ID, models.Reviewing).
+		Updates(map[string]interface{}{"action_status": status, "comment": comment})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+	dc.ActionStatus = status
+	dc.Comment = comment
+	return true, nil
+}
+
+// notifyChangeOutcome notifies the requester of a closed change in background,
+// operatorId is the user who closes it, 0 if expired
+func notifyChangeOutcome(dc *models.DomainChange, operatorId uint) {
+	record := bot.ChangeOutcomeRecord{
+		Reason:  dc.Reason,
+		Outcome: dc.ActionStatus.String(),
+		Comment: dc.Comment,
+		Time:    time.Now(),
+	}
+	var requester models.User
+	if err := db.DB.Where("id = ?", dc.UserId).First(&requester).Error; err == nil {
+		record.Requester = userDisplayName(&requester)
+	}
+	if operatorId != 0 {
+		var operator models.User
+		if err := db.DB.Where("id = ?", operatorId).First(&operator).Error; err == nil {
+			record.Operator = userDisplayName(&operator)
+		}
+	}
+	var domain models.Domain
+	if err := db.DB.Where("id = ?", dc.DomainId).First(&domain).Error; err == nil {
+		record.Domain = domain.Name
+	}
+	go bot.NotifyChangeOutcome(record)
+}
+
+// userDisplayName is the name of user, or email if name is not set
+func userDisplayName(u *models.User) string {
+	if u.Name != "" {
+		return u.Name
+	}
+	return u.Email
+}
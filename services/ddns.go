@@ -0,0 +1,406 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+
+	db "domain0/database"
+	"domain0/models"
+	mw "domain0/models/web"
+	"domain0/modules"
+	md "domain0/modules/dns"
+)
+
+// return codes of dyndns2 protocol
+const (
+	ddnsGood    = "good"
+	ddnsNoChg   = "nochg"
+	ddnsBadAuth = "badauth"
+	ddnsNoHost  = "nohost"
+	ddnsDnsErr  = "dnserr"
+)
+
+const ddnsTokenPrefix = "ddns_"
+
+func ddnsTokenHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func newDdnsToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return ddnsTokenPrefix + hex.EncodeToString(b), nil
+}
+
+// @Summary List DDNS Tokens
+// @Description List ddns tokens of a dns record, tokens themselves are not responded
+// @Description user must have readwrite permission to domain or be admin
+// @Tags domain
+// @Param id path string true "domain id"
+// @Param dnsId path string true "dns record id"
+// @Produce json
+// @Success 200 {object} mw.Domain{data=[]models.DdnsToken}
+// @Failure 403 {object} mw.Domain{data=int}
+// @Failure 404 {object} mw.Domain{data=int}
+// @Failure 500 {object} mw.Domain{data=int}
+// @Router /api/v1/domain/{id}/dns/{dnsId}/ddns [get]
+func DomainDdnsTokenList(c *fiber.Ctx) error {
+	domain, ok, err := permittedDomain(c, models.ReadWrite)
+	if !ok {
+		return err
+	}
+
+	var tokens []models.DdnsToken
+	if err := db.DB.Where("domain_id = ? AND record_id = ?", domain.ID, c.Params("dnsId")).
+		Find(&tokens).Error; err != nil {
+		logrus.Error(err)
+		return c.Status(fiber.StatusInternalServerError).JSON(mw.Domain{
+			Status: fiber.StatusInternalServerError,
+			Errors: err.Error(),
+			Data:   domain.ID,
+		})
+	}
+	return c.JSON(mw.Domain{
+		Status: fiber.StatusOK,
+		Data:   tokens,
+	})
+}
+
+// @Summary Create DDNS Token
+// @Description Create a token to update the address of an A or AAAA record by /api/v1/ddns/update,
+// @Description the token is only responded here, keep it safe
+// @Description user must have readwrite permission to domain or be admin,
+// @Description for ICP domain user must be owner, as updates by token are not reviewed
+// @Tags domain
+// @Accept json
+// @Param id path string true "domain id"
+// @Param dnsId path string true "dns record id"
+// @Param token body mw.DdnsTokenCreate false "token info"
+// @Produce json
+// @Success 200 {object} mw.Domain{data=mw.DdnsToken}
+// @Failure 400 {object} mw.Domain{data=int}
+// @Failure 403 {object} mw.Domain{data=int}
+// @Failure 404 {object} mw.Domain{data=int}
+// @Failure 500 {object} mw.Domain{data=int}
+// @Router /api/v1/domain/{id}/dns/{dnsId}/ddns [post]
+func DomainDdnsTokenCreate(c *fiber.Ctx) error {
+	domain, ok, err := permittedDomain(c, models.ReadWrite)
+	if !ok {
+		return err
+	}
+	uId := c.Locals("sub").(uint)
+	if domain.ICPReg > 0 && !checkUserDomainPermission(uId, domain.ID, models.Owner) {
+		return c.Status(fiber.StatusForbidden).JSON(mw.Domain{
+			Status: fiber.StatusForbidden,
+			Errors: "ICP domain need owner permission to create ddns token",
+			Data:   domain.ID,
+		})
+	}
+
+	var req mw.DdnsTokenCreate
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(mw.Domain{
+				Status: fiber.StatusBadRequest,
+				Errors: err.Error(),
+				Data:   domain.ID,
+			})
+		}
+	}
+
+	dnsObj, err := modules.DnsObjGen(domain)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(mw.Domain{
+			Status: fiber.StatusBadRequest,
+			Errors: err.Error(),
+			Data:   domain.ID,
+		})
+	}
+	if err := dnsObj.Get(c.Params("dnsId")); err != nil {
+		logrus.Error(err)
+		return c.Status(fiber.StatusNotFound).JSON(mw.Domain{
+			Status: fiber.StatusNotFound,
+			Errors: "dns record not found",
+			Data:   domain.ID,
+		})
+	}
+	record := dnsObj.ToRecord()
+	typ := strings.ToUpper(record.Type)
+	if typ != "A" && typ != "AAAA" {
+		return c.Status(fiber.StatusBadRequest).JSON(mw.Domain{
+			Status: fiber.StatusBadRequest,
+			Errors: "ddns token is only for A and AAAA records",
+			Data:   domain.ID,
+		})
+	}
+
+	token, err := newDdnsToken()
+	if err != nil {
+		logrus.Error(err)
+		return c.Status(fiber.StatusInternalServerError).JSON(mw.Domain{
+			Status: fiber.StatusInternalServerError,
+			Errors: err.Error(),
+			Data:   domain.ID,
+		})
+	}
+	t := models.DdnsToken{
+		DomainId:    domain.ID,
+		RecordId:    c.Params("dnsId"),
+		Name:        md.RelativeName(record.Name, domain.Name),
+		Type:        typ,
+		UserId:      uId,
+		Description: req.Description,
+		TokenHash:   ddnsTokenHash(token),
+	}
+	if err := db.DB.Create(&t).Error; err != nil {
+		logrus.Error(err)
+		return c.Status(fiber.StatusInternalServerError).JSON(mw.Domain{
+			Status: fiber.StatusInternalServerError,
+			Errors: err.Error(),
+			Data:   domain.ID,
+		})
+	}
+	auditChange(c, nil, t)
+
+	logrus.Info("User: ", uId, " create ddns token: ", t.ID, " for record: ", t.RecordId, " of domain: ", domain.ID)
+	return c.JSON(mw.Domain{
+		Status: fiber.StatusOK,
+		Data:   mw.DdnsToken{Token: token, DdnsToken: t},
+	})
+}
+
+// @Summary Delete DDNS Token
+// @Description Revoke a ddns token of the domain
+// @Description user must have readwrite permission to domain or be admin
+// @Tags domain
+// @Param id path string true "domain id"
+// @Param tid path string true "ddns token id"
+// @Produce json
+// @Success 200 {object} mw.Domain{data=int}
+// @Failure 403 {object} mw.Domain{data=int}
+// @Failure 404 {object} mw.Domain{data=int}
+// @Failure 500 {object} mw.Domain{data=int}
+// @Router /api/v1/domain/{id}/ddns/{tid} [delete]
+func DomainDdnsTokenDelete(c *fiber.Ctx) error {
+	domain, ok, err := permittedDomain(c, models.ReadWrite)
+	if !ok {
+		return err
+	}
+
+	var t models.DdnsToken
+	if err := db.DB.Where("id = ? AND domain_id = ?", c.Params("tid"), domain.ID).First(&t).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(mw.Domain{
+			Status: fiber.StatusNotFound,
+			Errors: "ddns token not found",
+			Data:   c.Params("tid"),
+		})
+	}
+	if err := db.DB.Delete(&t).Error; err != nil {
+		logrus.Error(err)
+		return c.Status(fiber.StatusInternalServerError).JSON(mw.Domain{
+			Status: fiber.StatusInternalServerError,
+			Errors: err.Error(),
+			Data:   t.ID,
+		})
+	}
+	auditRecord(c, t.RecordId)
+	auditChange(c, t, nil)
+	return c.JSON(mw.Domain{
+		Status: fiber.StatusOK,
+		Data:   t.ID,
+	})
+}
+
+// ddnsToken gets the token from password of basic auth, which dyndns2 clients use, or the token param
+func ddnsToken(c *fiber.Ctx) string {
+	if auth := c.Get(fiber.HeaderAuthorization); strings.HasPrefix(auth, "Basic ") {
+		if raw, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(auth, "Basic ")); err == nil {
+			if _, pass, ok := strings.Cut(string(raw), ":"); ok {
+				return pass
+			}
+		}
+	}
+	if t := c.Query("token"); t != "" {
+		return t
+	}
+	return c.FormValue("token")
+}
+
+// ddnsParam gets the param from query, or form of POST
+func ddnsParam(c *fiber.Ctx, key string) string {
+	if v := c.Query(key); v != "" {
+		return v
+	}
+	return c.FormValue(key)
+}
+
+// ddnsAddress picks the address of the record type from myip, which may list both families
+// separated by comma, it falls back to the address of caller as dyndns2 does. The caller address
+// is the one forwarded by a trusted proxy, see config.ProxyConfig, and it is not used if it's the
+// proxy or the server itself
+func ddnsAddress(c *fiber.Ctx, typ string) net.IP {
+	for _, s := range strings.Split(ddnsParam(c, "myip"), ",") {
+		ip := net.ParseIP(strings.TrimSpace(s))
+		if ip != nil && (typ == "A") == (ip.To4() != nil) {
+			return ip
+		}
+	}
+	ip := net.ParseIP(c.IP())
+	if ip == nil || ip.IsLoopback() || ip.IsUnspecified() {
+		return nil
+	}
+	if (typ == "A") == (ip.To4() != nil) {
+		return ip
+	}
+	return nil
+}
+
+// ddnsRecord gets the record of token, it is found by name and type if the id is changed outside
+func ddnsRecord(d *models.Domain, t *models.DdnsToken) (modules.DnsObj, error) {
+	dnsObj, err := modules.DnsObjGen(d)
+	if err != nil {
+		return nil, err
+	}
+	if err = dnsObj.Get(t.RecordId); err == nil {
+		return dnsObj, nil
+	}
+	records, listErr := modules.DnsRecordList(d)
+	if listErr != nil {
+		return nil, err
+	}
+	var found []md.Record
+	for _, r := range records {
+		if strings.EqualFold(md.RelativeName(r.Name, d.Name), t.Name) && strings.EqualFold(r.Type, t.Type) {
+			found = append(found, r)
+		}
+	}
+	if len(found) != 1 {
+		return nil, err
+	}
+	return dnsObj, dnsObj.Get(found[0].Id)
+}
+
+// @Summary DDNS Update
+// @Description Update the address of the record of a ddns token, compatible with dyndns2 protocol,
+// @Description the token is the password of basic auth, username is ignored, or given by token param
+// @Description hostname is the fqdn of the record, optional, myip is the new address, the address of
+// @Description caller is used if it is absent or has no address of the record type
+// @Description the record is only updated if the address is changed
+// @Description response is plain text of dyndns2: "good <ip>", "nochg <ip>", "badauth", "nohost" or "dnserr"
+// @Tags ddns
+// @Param hostname query string false "fqdn of the record, comma separated for several"
+// @Param myip query string false "new address, comma separated ipv4 and ipv6"
+// @Param token query string false "ddns token, if not by basic auth"
+// @Produce plain
+// @Success 200 {string} string
+// @Failure 401 {string} string
+// @Router /api/v1/ddns/update [get]
+// @Router /api/v1/ddns/update [post]
+func DdnsUpdate(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderContentType, fiber.MIMETextPlainCharsetUTF8)
+
+	token := ddnsToken(c)
+	var t models.DdnsToken
+	if token == "" || db.DB.Where("token_hash = ?", ddnsTokenHash(token)).First(&t).Error != nil {
+		c.Set(fiber.HeaderWWWAuthenticate, `Basic realm="ddns"`)
+		return c.Status(fiber.StatusUnauthorized).SendString(ddnsBadAuth)
+	}
+	var domain models.Domain
+	if db.DB.Where("id = ?", t.DomainId).First(&domain).Error != nil {
+		return c.Status(fiber.StatusUnauthorized).SendString(ddnsBadAuth)
+	}
+	user, ok := unreviewedPermitted(t.UserId, &domain)
+	if !ok {
+		logrus.Info("DDNS token: ", t.ID, " of user: ", t.UserId, " is used without permission to domain: ", t.DomainId)
+		return c.Status(fiber.StatusUnauthorized).SendString(ddnsBadAuth)
+	}
+	c.Locals("sub", user.ID)
+	c.Locals(localsUserName, user.Name)
+	auditDomain(c, domain.ID)
+	auditRecord(c, t.RecordId)
+
+	// the token is for one record, so is each hostname
+	fqdn := md.FQDN(t.Name, domain.Name)
+	hostnames := []string{fqdn}
+	if h := ddnsParam(c, "hostname"); h != "" {
+		hostnames = strings.Split(h, ",")
+	}
+	result := ddnsNoHost
+	if ip := ddnsAddress(c, t.Type); ip != nil {
+		for _, h := range hostnames {
+			if strings.EqualFold(strings.TrimSuffix(strings.TrimSpace(h), "."), fqdn) {
+				result = ddnsApply(c, &domain, &t, ip)
+				break
+			}
+		}
+	}
+
+	lines := make([]string, len(hostnames))
+	for i, h := range hostnames {
+		lines[i] = ddnsNoHost
+		if strings.EqualFold(strings.TrimSuffix(strings.TrimSpace(h), "."), fqdn) {
+			lines[i] = result
+		}
+	}
+	return c.SendString(strings.Join(lines, "\n"))
+}
+
+// ddnsApply updates the record of token to ip if it is changed, and returns the dyndns2 result
+func ddnsApply(c *fiber.Ctx, d *models.Domain, t *models.DdnsToken, ip net.IP) string {
+	dnsObj, err := ddnsRecord(d, t)
+	if err != nil {
+		logrus.Warn("DDNS token: ", t.ID, " record: ", t.RecordId, " not found: ", err)
+		return ddnsNoHost
+	}
+	old := dnsObj.ToRecord()
+
+	now := time.Now()
+	t.LastIP, t.LastUsedAt = ip.String(), &now
+	if current := net.ParseIP(old.Content); current != nil && current.Equal(ip) {
+		t.RecordId = old.Id
+		db.DB.Save(t)
+		return ddnsNoChg + " " + ip.String()
+	}
+
+	record := old
+	record.Content = ip.String()
+	if dnsObj, err = modules.DnsObjFromRecord(d, record); err != nil {
+		logrus.Error(err)
+		return ddnsDnsErr
+	}
+	// keep the records before change, so it can be rolled back
+	prior, err := capturePriorState(d, t.UserId)
+	if err != nil {
+		logrus.Error(err)
+		return ddnsDnsErr
+	}
+	defer prior.Release()
+	if err := dnsObj.Update(); err != nil {
+		logrus.Error(err)
+		return ddnsDnsErr
+	}
+	prior.Save()
+	updated := dnsObj.ToRecord()
+	auditRecord(c, updated.Id)
+	auditChange(c, old, updated)
+
+	if updated.Id != "" {
+		t.RecordId = updated.Id
+	}
+	if err := db.DB.Save(t).Error; err != nil {
+		logrus.Error(err)
+	}
+	logrus.Info("DDNS token: ", t.ID, " update record: ", updated.Id, " of domain: ", d.ID, " to ", ip)
+	return ddnsGood + " " + ip.String()
+}
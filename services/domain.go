@@ -8,6 +8,7 @@ import (
 	db "domain0/database"
 	"domain0/models"
 	mw "domain0/models/web"
+	"domain0/modules"
 	"domain0/utils"
 )
 
@@ -71,6 +72,7 @@ func DomainGet(c *fiber.Ctx) error {
 // @Summary Create domain
 // @Description Create domain
 // @Description user must have contributor role or higher
+// @Description vendor must be one of the providers listed by /api/v1/providers
 // @Tags domain
 // @Accept json
 // @Produce json
@@ -112,17 +114,27 @@ func DomainCreate(c *fiber.Ctx) error {
 		})
 	}
 
+	// reject vendor without provider, or every dns operation on it will fail
+	if !modules.VendorSupported(*domain.Vendor) {
+		return c.Status(fiber.StatusBadRequest).JSON(mw.Domain{
+			Status: fiber.StatusBadRequest,
+			Errors: "unsupported vendor",
+			Data:   domain,
+		})
+	}
+
 	// add domain and grant user owner rights to domain with transaction
+	d := models.Domain{
+		Name:      *domain.Name,
+		ApiId:     *domain.ApiId,
+		ApiSecret: *domain.ApiSecret,
+		Vendor:    *domain.Vendor,
+		Endpoint:  utils.IfThenPtr(domain.Endpoint, ""),
+		ICPReg:    *domain.ICPReg,
+		Privacy:   *domain.Privacy,
+	}
 	if err := db.DB.Transaction(func(tx *gorm.DB) error {
 		// add domain
-		d := models.Domain{
-			Name:      *domain.Name,
-			ApiId:     *domain.ApiId,
-			ApiSecret: *domain.ApiSecret,
-			Vendor:    *domain.Vendor,
-			ICPReg:    *domain.ICPReg,
-			Privacy:   *domain.Privacy,
-		}
 		if err := tx.Create(&d).Error; err != nil {
 			return err
 		}
@@ -146,6 +158,8 @@ func DomainCreate(c *fiber.Ctx) error {
 			Data:   domain,
 		})
 	}
+	auditDomain(c, d.ID)
+	auditChange(c, nil, d)
 
 	return c.Status(fiber.StatusCreated).JSON(mw.Domain{
 		Status: fiber.StatusCreated,
@@ -195,6 +209,14 @@ func DomainUpdate(c *fiber.Ctx) error {
 		})
 	}
 
+	if domain.Vendor != nil && !modules.VendorSupported(*domain.Vendor) {
+		return c.Status(fiber.StatusBadRequest).JSON(mw.Domain{
+			Status: fiber.StatusBadRequest,
+			Errors: "unsupported vendor",
+			Data:   domain,
+		})
+	}
+
 	var d models.Domain
 	if err := db.DB.Where("id = ?", qId).First(&d).Error; err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(mw.Domain{
@@ -211,11 +233,22 @@ func DomainUpdate(c *fiber.Ctx) error {
 		})
 	}
 
+	// admins could not approve changes any more
+	if domain.Privacy != nil && *domain.Privacy && !d.Privacy && loadApprovalPolicy(d.ID).Admins > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(mw.Domain{
+			Status: fiber.StatusBadRequest,
+			Errors: "approval policy needs admins, which have no access to privacy domain",
+			Data:   qId,
+		})
+	}
+
 	// update domain info
+	old := d
 	d.Name = utils.IfThenPtr(domain.Name, d.Name)
 	d.ApiId = utils.IfThenPtr(domain.ApiId, d.ApiId)
 	d.ApiSecret = utils.IfThenPtr(domain.ApiSecret, d.ApiSecret)
 	d.Vendor = utils.IfThenPtr(domain.Vendor, d.Vendor)
+	d.Endpoint = utils.IfThenPtr(domain.Endpoint, d.Endpoint)
 	d.Privacy = utils.IfThenPtr(domain.Privacy, d.Privacy)
 	// d.ICPReg = utils.IfThenPtr(domain.ICPReg, d.ICPReg)
 	if err := db.DB.Save(&d).Error; err != nil {
@@ -226,6 +259,7 @@ func DomainUpdate(c *fiber.Ctx) error {
 			Data:   domain,
 		})
 	}
+	auditChange(c, old, d)
 
 	return c.Status(fiber.StatusOK).JSON(mw.Domain{
 		Status: fiber.StatusOK,
@@ -290,6 +324,7 @@ func DomainDelete(c *fiber.Ctx) error {
 			Data:   domain,
 		})
 	}
+	auditChange(c, domain, nil)
 
 	return c.Status(fiber.StatusOK).JSON(mw.Domain{
 		Status: fiber.StatusOK,
@@ -0,0 +1,215 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+
+	db "domain0/database"
+	"domain0/models"
+	mw "domain0/models/web"
+)
+
+// submitAccessChange creates the pending access change of domain, unless there is the same one reviewing
+func submitAccessChange(c *fiber.Ctx, domain *models.Domain, action models.DomainAction, du mw.DomainUser, reason string) error {
+	qId := c.Params("id")
+	uId := c.Locals("sub").(uint)
+
+	var pending int64
+	err := db.DB.Model(&models.DomainChange{}).Where(
+		"domain_id = ? AND user_id = ? AND action_type = ? AND action_status = ?",
+		domain.ID, uId, action, models.Reviewing,
+	).Count(&pending).Error
+	if err != nil {
+		logrus.Error(err)
+		return c.Status(fiber.StatusInternalServerError).JSON(mw.Domain{
+			Status: fiber.StatusInternalServerError,
+			Errors: "Database error",
+			Data:   qId,
+		})
+	}
+	if pending > 0 {
+		return c.Status(fiber.StatusConflict).JSON(mw.Domain{
+			Status: fiber.StatusConflict,
+			Errors: "there is a reviewing request already",
+			Data:   qId,
+		})
+	}
+
+	duJson, err := json.Marshal(du)
+	if err != nil {
+		logrus.Error(err)
+		return c.Status(fiber.StatusInternalServerError).JSON(mw.Domain{
+			Status: fiber.StatusInternalServerError,
+			Errors: err.Error(),
+			Data:   qId,
+		})
+	}
+	dc := models.DomainChange{
+		DomainId:     domain.ID,
+		UserId:       uId,
+		ActionType:   action,
+		ActionStatus: models.Reviewing,
+		Reason:       reason,
+		Operation:    string(duJson),
+	}
+	if err := db.DB.Create(&dc).Error; err != nil {
+		logrus.Error(err)
+		return c.Status(fiber.StatusInternalServerError).JSON(mw.Domain{
+			Status: fiber.StatusInternalServerError,
+			Errors: err.Error(),
+			Data:   qId,
+		})
+	}
+	auditChange(c, nil, dc)
+
+	return c.Status(fiber.StatusAlreadyReported).JSON(mw.Domain{
+		Status: fiber.StatusAlreadyReported,
+		Data:   "request is sent to domain managers, please wait for approval",
+	})
+}
+
+// @Summary Request Domain Access
+// @Description Request a role on domain for the user himself, with a justification
+// @Description user must have a role lower than requested, or the domain is not privacy
+// @Description managers can approve roles below manager, owners can approve all
+// @Tags domain
+// @Accept json
+// @Param id path string true "domain id"
+// @Param request body mw.AccessRequest true "role and reason"
+// @Produce json
+// @Success 208 {object} mw.Domain{data=string}
+// @Failure 400 {object} mw.Domain{data=int}
+// @Failure 404 {object} mw.Domain{data=int}
+// @Failure 409 {object} mw.Domain{data=int}
+// @Failure 500 {object} mw.Domain{data=int}
+// @Router /api/v1/domain/{id}/access/grant [post]
+func DomainAccessGrantRequest(c *fiber.Ctx) error {
+	// get domainId restful api
+	qId := c.Params("id")
+
+	// get query user info from jwt sub
+	uId := c.Locals("sub").(uint)
+
+	// get domain info
+	var domain models.Domain
+	if err := db.DB.Where("id = ?", qId).First(&domain).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(mw.Domain{
+			Status: fiber.StatusNotFound,
+			Errors: "domain not found",
+			Data:   qId,
+		})
+	}
+
+	var ud models.UserDomain
+	member := db.DB.Where("user_id = ? AND domain_id = ?", uId, domain.ID).First(&ud).Error == nil
+
+	// privacy domain is invisible to others
+	if !member && domain.Privacy {
+		return c.Status(fiber.StatusNotFound).JSON(mw.Domain{
+			Status: fiber.StatusNotFound,
+			Errors: "domain not found",
+			Data:   qId,
+		})
+	}
+
+	var req mw.AccessRequest
+	if err := c.BodyParser(&req); err != nil || req.Role < models.ReadOnly || req.Role > models.Owner {
+		return c.Status(fiber.StatusBadRequest).JSON(mw.Domain{
+			Status: fiber.StatusBadRequest,
+			Errors: "invalid request body",
+			Data:   qId,
+		})
+	}
+	if req.Reason == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(mw.Domain{
+			Status: fiber.StatusBadRequest,
+			Errors: "reason is required",
+			Data:   qId,
+		})
+	}
+	if member && ud.Role >= req.Role {
+		return c.Status(fiber.StatusBadRequest).JSON(mw.Domain{
+			Status: fiber.StatusBadRequest,
+			Errors: fmt.Sprintf("you have %s role already", ud.Role.String()),
+			Data:   qId,
+		})
+	}
+
+	reason := fmt.Sprintf("%d want %s role of domain %s: %s", uId, req.Role.String(), domain.Name, req.Reason)
+	return submitAccessChange(c, &domain, models.GrantAccess, mw.DomainUser{UserId: int(uId), Role: req.Role}, reason)
+}
+
+// @Summary Request Domain Access Revoke
+// @Description Request to revoke the role of a user on domain, with a justification
+// @Description user must have a role on domain
+// @Description managers can approve revoking roles below owner, owners can approve all
+// @Tags domain
+// @Accept json
+// @Param id path string true "domain id"
+// @Param request body mw.AccessRequest true "user and reason"
+// @Produce json
+// @Success 208 {object} mw.Domain{data=string}
+// @Failure 400 {object} mw.Domain{data=int}
+// @Failure 403 {object} mw.Domain{data=int}
+// @Failure 404 {object} mw.Domain{data=int}
+// @Failure 409 {object} mw.Domain{data=int}
+// @Failure 500 {object} mw.Domain{data=int}
+// @Router /api/v1/domain/{id}/access/revoke [post]
+func DomainAccessRevokeRequest(c *fiber.Ctx) error {
+	// get domainId restful api
+	qId := c.Params("id")
+
+	// get query user info from jwt sub
+	uId := c.Locals("sub").(uint)
+
+	// check if user role level
+	if !checkUserDomainPermission(uId, qId, models.ReadOnly) {
+		return c.Status(fiber.StatusForbidden).JSON(mw.Domain{
+			Status: fiber.StatusForbidden,
+			Errors: "permission denied",
+			Data:   qId,
+		})
+	}
+
+	// get domain info
+	var domain models.Domain
+	if err := db.DB.Where("id = ?", qId).First(&domain).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(mw.Domain{
+			Status: fiber.StatusNotFound,
+			Errors: "domain not found",
+			Data:   qId,
+		})
+	}
+
+	var req mw.AccessRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(mw.Domain{
+			Status: fiber.StatusBadRequest,
+			Errors: "invalid request body",
+			Data:   qId,
+		})
+	}
+	if req.Reason == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(mw.Domain{
+			Status: fiber.StatusBadRequest,
+			Errors: "reason is required",
+			Data:   qId,
+		})
+	}
+
+	var ud models.UserDomain
+	if err := db.DB.Where("user_id = ? AND domain_id = ?", req.UserId, domain.ID).First(&ud).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(mw.Domain{
+			Status: fiber.StatusNotFound,
+			Errors: "user has no role on domain",
+			Data:   qId,
+		})
+	}
+
+	reason := fmt.Sprintf("%d want to revoke %s role of user %d on domain %s: %s",
+		uId, ud.Role.String(), req.UserId, domain.Name, req.Reason)
+	return submitAccessChange(c, &domain, models.RevokeAccess, mw.DomainUser{UserId: req.UserId, Role: ud.Role}, reason)
+}
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+
+	db "domain0/database"
+	"domain0/models"
+	mw "domain0/models/web"
+	"domain0/modules"
+)
+
+// @Summary Batch Domain Dns
+// @Description Create, update and delete dns records together, all operations are validated first,
+// @Description then applied in order, if any of them fails the applied ones are rolled back
+// @Description user must have readwrite permission to domain or be admin
+// @Description for ICP domain the batch of non-owner is sent to owner for approval as one domain change
+// @Tags domain
+// @Accept json
+// @Param id path string true "domain id"
+// @Param ops body []modules.BatchOp true "operations in order"
+// @Param dry_run query bool false "only preview the change, data is mw.DnsDryRun"
+// @Produce json
+// @Success 200 {object} mw.Domain{data=modules.Batch}
+// @Success 208 {object} mw.Domain{data=string}
+// @Failure 400 {object} mw.Domain{data=[]modules.FieldError}
+// @Failure 403 {object} mw.Domain{data=int}
+// @Failure 404 {object} mw.Domain{data=int}
+// @Failure 409 {object} mw.Domain{data=[]modules.FieldError}
+// @Failure 500 {object} mw.Domain{data=int}
+// @Router /api/v1/domain/{id}/dns/batch [post]
+func DomainDnsBatch(c *fiber.Ctx) error {
+	// get domainId restful api
+	qId := c.Params("id")
+
+	// get query user info from jwt sub
+	uId := c.Locals("sub").(uint)
+
+	// check if user role level
+	flag := c.Locals("role").(models.UserRole) >= models.Admin
+	if !(flag || checkUserDomainPermission(uId, qId, models.ReadWrite)) {
+		logrus.Info("User: ", uId, " try to access domain: ", qId, " without permission")
+		return c.Status(fiber.StatusForbidden).JSON(mw.Domain{
+			Status: fiber.StatusForbidden,
+			Errors: "permission denied",
+			Data:   qId,
+		})
+	}
+
+	// get domain info
+	var domain models.Domain
+	if err := db.DB.Where("id = ?", qId).First(&domain).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(mw.Domain{
+			Status: fiber.StatusNotFound,
+			Errors: "domain not found",
+			Data:   qId,
+		})
+	}
+
+	// validate all operations before changing anything
+	var ops []modules.BatchOp
+	if err := json.Unmarshal(c.Body(), &ops); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(mw.Domain{
+			Status: fiber.StatusBadRequest,
+			Errors: err.Error(),
+			Data:   qId,
+		})
+	}
+	batch, err := modules.PrepareBatch(&domain, ops)
+	if err != nil {
+		logrus.Error(err)
+		status := recordErrorStatus(err, fiber.StatusBadRequest)
+		return c.Status(status).JSON(mw.Domain{
+			Status: status,
+			Errors: err.Error(),
+			Data:   invalidRecordData(c, err),
+		})
+	}
+
+	needApproval := domain.ICPReg > 0 && !checkUserDomainPermission(uId, qId, models.Owner)
+	if dryRun(c) {
+		plan := batch.Plan()
+		return dryRunPlan(c, needApproval, &plan)
+	}
+	if needApproval {
+		bcsJson, err := json.Marshal(modules.BatchChangeStruct{
+			Batch:  *batch,
+			Domain: domain,
+		})
+		if err != nil {
+			logrus.Error(err)
+			return c.Status(fiber.StatusInternalServerError).JSON(mw.Domain{
+				Status: fiber.StatusInternalServerError,
+				Errors: err.Error(),
+				Data:   qId,
+			})
+		}
+		dc := models.DomainChange{
+			DomainId:     domain.ID,
+			UserId:       uId,
+			ActionType:   models.Batch,
+			ActionStatus: models.Reviewing,
+			Reason:       fmt.Sprintf("%d want to change %d dns records for domain %s", uId, len(batch.Steps), domain.Name),
+			Operation:    string(bcsJson),
+		}
+		if err := db.DB.Create(&dc).Error; err != nil {
+			logrus.Error(err)
+			return c.Status(fiber.StatusInternalServerError).JSON(mw.Domain{
+				Status: fiber.StatusInternalServerError,
+				Errors: err.Error(),
+				Data:   qId,
+			})
+		}
+		// the change is only submitted, it is audited again when applied
+		auditChange(c, nil, dc)
+		return c.Status(fiber.StatusAlreadyReported).JSON(mw.Domain{
+			Status: fiber.StatusAlreadyReported,
+			Data:   "ICP domain need owner permission, please wait for approval",
+		})
+	}
+
+	// keep the records before change, so it can be rolled back
+	prior, err := capturePriorState(&domain, uId)
+	if err != nil {
+		logrus.Error(err)
+		return c.Status(fiber.StatusInternalServerError).JSON(mw.Domain{
+			Status: fiber.StatusInternalServerError,
+			Errors: err.Error(),
+			Data:   qId,
+		})
+	}
+	defer prior.Release()
+
+	applied, err := modules.ApplyBatch(&domain, batch)
+	if err != nil {
+		// the batch is rolled back, keep the prior version only if the rollback failed
+		if batchErr, ok := err.(*modules.BatchError); !ok || len(batchErr.RollbackErrors) > 0 {
+			prior.Save()
+		}
+		auditChange(c, nil, batch)
+		logrus.Error(err)
+		return c.Status(fiber.StatusInternalServerError).JSON(mw.Domain{
+			Status: fiber.StatusInternalServerError,
+			Errors: err.Error(),
+			Data:   qId,
+		})
+	}
+	prior.Save()
+	auditChange(c, nil, applied)
+
+	logrus.Info("User: ", uId, " apply ", len(applied.Steps), " dns operations for domain: ", qId)
+	return c.JSON(mw.Domain{
+		Status: fiber.StatusOK,
+		Data:   applied,
+	})
+}
@@ -2,6 +2,7 @@ package services
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strings"
 	"time"
@@ -14,6 +15,7 @@ import (
 	"domain0/models"
 	mw "domain0/models/web"
 	"domain0/modules"
+	"domain0/utils"
 )
 
 // @Summary list all domain change requests generated by the user
@@ -28,7 +30,7 @@ func DomainChangeListMyApply(c *fiber.Ctx) error {
 
 	// get domain change list
 	var dcList []models.DomainChange
-	err := db.DB.Preload("Domain").Preload("User").Where("user_id = ?", uid).Find(&dcList).Error
+	err := db.DB.Preload("Domain").Preload("User").Preload("Votes").Where("user_id = ?", uid).Find(&dcList).Error
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(mw.Domain{
 			Status: fiber.StatusInternalServerError,
@@ -51,45 +53,80 @@ func DomainChangeListMyApply(c *fiber.Ctx) error {
 func DomainChangeListMyApprove(c *fiber.Ctx) error {
 	// extract info
 	uid := c.Locals("sub").(uint)
+	role := c.Locals("role").(models.UserRole)
 
 	// get domain change list
 	var dcList []models.DomainChange
-	err := db.DB.Preload("Domain").Preload("User").Where(
+	// managers can approve part of access changes, see approverRole,
+	// and admins can approve changes of domains whose policy needs admins
+	tx := db.DB.Preload("Domain").Preload("User").Preload("Votes").Where(
 		"domain_id IN (SELECT domain_id FROM user_domains WHERE user_id = ? AND role >= ?)",
-		uid, models.Owner,
-	).Find(&dcList).Error
-	if err != nil {
+		uid, models.Manager,
+	)
+	if role >= models.Admin {
+		tx = tx.Or("domain_id IN (SELECT domain_id FROM approval_policies WHERE admins > 0)")
+	}
+	if err := tx.Find(&dcList).Error; err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(mw.Domain{
 			Status: fiber.StatusInternalServerError,
 			Errors: "Database error",
 		})
 	}
 
+	// drop changes beyond the user
+	approvable := make([]models.DomainChange, 0, len(dcList))
+	policies := map[uint]models.ApprovalPolicy{}
+	for i := range dcList {
+		policy, ok := policies[dcList[i].DomainId]
+		if !ok {
+			policy = loadApprovalPolicy(dcList[i].DomainId)
+			policies[dcList[i].DomainId] = policy
+		}
+		if domainReviewer, admin := reviewerKind(uid, role, &dcList[i], &policy); domainReviewer || admin {
+			approvable = append(approvable, dcList[i])
+		}
+	}
+
 	return c.Status(fiber.StatusOK).JSON(mw.Domain{
 		Status: fiber.StatusOK,
-		Data:   dcList,
+		Data:   approvable,
 	})
 }
 
 // @Summary modify domain change request
+// @Description vote for the domain change, the change is applied once the approval policy of domain is met,
+// @Description and rejected by any reject vote, requester can't vote for his own change
+// @Description the approval is refused with the diff if records are changed since the change is submitted
 // @Tags domain
 // @Produce json
 // @Param id path string true "domain change id"
 // @Param opt query string true "operation: accept or reject"
+// @Param comment query string false "comment of the vote, shown to the requester if rejected"
 // @Success 200 {object} mw.Domain{data=models.DomainChange}
+// @Failure 400 {object} mw.Domain
+// @Failure 403 {object} mw.Domain
+// @Failure 404 {object} mw.Domain
+// @Failure 409 {object} mw.Domain{data=[]modules.RecordDiff}
 // @Failure 500 {object} mw.Domain
 // @Router /api/v1/domain/change/{id} [put]
 func DomainChangeCheck(c *fiber.Ctx) error {
 	// extract info
 	uid := c.Locals("sub").(uint)
+	role := c.Locals("role").(models.UserRole)
 
 	// get domain change id
 	dcId := c.Params("id")
 	opt := c.Query("opt")
+	if opt != "accept" && opt != "reject" {
+		return c.Status(fiber.StatusBadRequest).JSON(mw.Domain{
+			Status: fiber.StatusBadRequest,
+			Errors: "Invalid opt",
+		})
+	}
 
 	// get domain change
 	var dc models.DomainChange
-	err := db.DB.Where("id = ?", dcId).First(&dc).Error
+	err := db.DB.Preload("Votes").Where("id = ?", dcId).First(&dc).Error
 	if err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(mw.Domain{
 			Status: fiber.StatusNotFound,
@@ -97,71 +134,170 @@ func DomainChangeCheck(c *fiber.Ctx) error {
 		})
 	}
 
+	auditDomain(c, dc.DomainId)
+	old := dc
+
 	// check permission
-	var ud models.UserDomain
-	err = db.DB.Where("domain_id = ? AND user_id = ? AND role >= ?", dc.DomainId, uid, models.Owner).First(&ud).Error
-	if err != nil {
+	policy := loadApprovalPolicy(dc.DomainId)
+	if dc.UserId == uid {
+		return c.Status(fiber.StatusForbidden).JSON(mw.Domain{
+			Status: fiber.StatusForbidden,
+			Errors: "Can't review your own change",
+		})
+	}
+	if domainReviewer, admin := reviewerKind(uid, role, &dc, &policy); !(domainReviewer || admin) {
 		return c.Status(fiber.StatusForbidden).JSON(mw.Domain{
 			Status: fiber.StatusForbidden,
 			Errors: "Permission denied",
 		})
 	}
 
-	// oprate
-	if opt == "accept" {
-		dc.ActionStatus = models.Approved
-		var d models.Domain
-		if err := db.DB.Where("id = ?", dc.DomainId).First(&d).Error; err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(mw.Domain{
-				Status: fiber.StatusInternalServerError,
-				Errors: "Database error",
-			})
-		}
-		dcs := modules.DnsChangeStruct{
-			Dns:    modules.DnsObjGen(&d),
-			Domain: d,
-		}
-		if err := json.Unmarshal([]byte(dc.Operation), &dcs); err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(mw.Domain{
-				Status: fiber.StatusInternalServerError,
-				Errors: "Database error",
-			})
+	if dc.ActionStatus != models.Reviewing {
+		return c.Status(fiber.StatusConflict).JSON(mw.Domain{
+			Status: fiber.StatusConflict,
+			Errors: "Domain change is already checked",
+		})
+	}
+	if changeExpired(&dc) {
+		// not closed by the expirer yet
+		if closed, err := closeDomainChange(&dc, models.Expired, ""); err == nil && closed {
+			notifyChangeOutcome(&dc, 0)
 		}
-		if err := dcs.DnsChangeRestore(); err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(mw.Domain{
-				Status: fiber.StatusInternalServerError,
-				Errors: "Database error",
+		return c.Status(fiber.StatusConflict).JSON(mw.Domain{
+			Status: fiber.StatusConflict,
+			Errors: "Domain change is expired",
+		})
+	}
+	for _, v := range dc.Votes {
+		if v.ReviewerId == uid {
+			return c.Status(fiber.StatusConflict).JSON(mw.Domain{
+				Status: fiber.StatusConflict,
+				Errors: "You have voted already",
 			})
 		}
-		var err error
-		if dc.ActionType == models.Submit {
-			err = dcs.Dns.Create()
-		} else if dc.ActionType == models.EditDNS {
-			err = dcs.Dns.Update()
+	}
+
+	// the vote is deleted if the change is not closed or applied by it, so it can be voted again
+	vote := models.DomainChangeVote{
+		DomainChangeId: dc.ID,
+		ReviewerId:     uid,
+		Approve:        opt == "accept",
+		Comment:        c.Query("comment"),
+	}
+	if err := db.DB.Create(&vote).Error; err != nil {
+		// a concurrent vote of the same reviewer
+		return c.Status(fiber.StatusConflict).JSON(mw.Domain{
+			Status: fiber.StatusConflict,
+			Errors: "You have voted already",
+		})
+	}
+	dc.Votes = append(dc.Votes, vote)
+
+	// oprate
+	if !vote.Approve {
+		closed, err := closeDomainChange(&dc, models.Rejected, vote.Comment)
+		if err != nil || !closed {
+			db.DB.Unscoped().Delete(&vote)
+			return checkedConflict(c, err)
 		}
-		if err != nil {
+	} else {
+		// votes of other reviewers may be saved since the change is read
+		if err := db.DB.Where("domain_change_id = ?", dc.ID).Find(&dc.Votes).Error; err != nil {
+			db.DB.Unscoped().Delete(&vote)
 			return c.Status(fiber.StatusInternalServerError).JSON(mw.Domain{
 				Status: fiber.StatusInternalServerError,
 				Errors: "Database error",
 			})
 		}
-	} else if opt == "reject" {
-		dc.ActionStatus = models.Rejected
-	} else {
-		return c.Status(fiber.StatusBadRequest).JSON(mw.Domain{
-			Status: fiber.StatusBadRequest,
-			Errors: "Invalid opt",
+		if quorumMet(&dc, &policy, dc.Votes) {
+			// only the approval claiming the change applies it, not one racing it, cancel or expiry
+			claimed, err := claimDomainChange(&dc, models.Reviewing, models.Applying)
+			if err != nil || !claimed {
+				db.DB.Unscoped().Delete(&vote)
+				return checkedConflict(c, err)
+			}
+			if status, resp := applyClaimedChange(&dc); resp != nil {
+				if dc.ActionStatus != models.Failed {
+					claimDomainChange(&dc, models.Applying, models.Reviewing)
+					db.DB.Unscoped().Delete(&vote)
+					return c.Status(status).JSON(resp)
+				}
+				// the records are changed partly, the change is closed with the vote
+				auditChange(c, old, dc)
+				notifyChangeOutcome(&dc, uid)
+				return c.Status(status).JSON(resp)
+			}
+			if _, err := claimDomainChange(&dc, models.Applying, models.Approved); err != nil {
+				logrus.Error(err)
+			}
+			auditRecord(c, changeRecordId(&dc))
+		}
+	}
+
+	auditChange(c, old, dc)
+	if dc.ActionStatus != models.Reviewing {
+		notifyChangeOutcome(&dc, uid)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(mw.Domain{
+		Status: fiber.StatusOK,
+		Data:   dc,
+	})
+}
+
+// @Summary cancel domain change request
+// @Description withdraw the domain change by its requester, it must be still reviewing
+// @Tags domain
+// @Produce json
+// @Param id path string true "domain change id"
+// @Param comment query string false "why the change is cancelled"
+// @Success 200 {object} mw.Domain{data=models.DomainChange}
+// @Failure 403 {object} mw.Domain
+// @Failure 404 {object} mw.Domain
+// @Failure 409 {object} mw.Domain
+// @Failure 500 {object} mw.Domain
+// @Router /api/v1/domain/change/{id}/cancel [post]
+func DomainChangeCancel(c *fiber.Ctx) error {
+	// extract info
+	uid := c.Locals("sub").(uint)
+
+	// get domain change
+	dcId := c.Params("id")
+	var dc models.DomainChange
+	if err := db.DB.Where("id = ?", dcId).First(&dc).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(mw.Domain{
+			Status: fiber.StatusNotFound,
+			Errors: "Domain change not found",
 		})
 	}
 
-	// update domain change
-	err = db.DB.Save(&dc).Error
+	auditDomain(c, dc.DomainId)
+	old := dc
+
+	// only requester can cancel his change
+	if dc.UserId != uid {
+		return c.Status(fiber.StatusForbidden).JSON(mw.Domain{
+			Status: fiber.StatusForbidden,
+			Errors: "Permission denied",
+		})
+	}
+
+	closed, err := closeDomainChange(&dc, models.Cancelled, c.Query("comment"))
 	if err != nil {
+		logrus.Error(err)
 		return c.Status(fiber.StatusInternalServerError).JSON(mw.Domain{
 			Status: fiber.StatusInternalServerError,
 			Errors: "Database error",
 		})
 	}
+	if !closed {
+		return c.Status(fiber.StatusConflict).JSON(mw.Domain{
+			Status: fiber.StatusConflict,
+			Errors: "Domain change is already checked",
+		})
+	}
+	auditChange(c, old, dc)
+	notifyChangeOutcome(&dc, uid)
 
 	return c.Status(fiber.StatusOK).JSON(mw.Domain{
 		Status: fiber.StatusOK,
@@ -169,6 +305,312 @@ func DomainChangeCheck(c *fiber.Ctx) error {
 	})
 }
 
+// @Summary diff of domain change request
+// @Description three-way diff of the records touched by the domain change: the state when submitted,
+// @Description the live state and the proposed state, the change can't be approved if there is a conflict
+// @Description user must be the requester or a reviewer of the change
+// @Tags domain
+// @Produce json
+// @Param id path string true "domain change id"
+// @Success 200 {object} mw.Domain{data=[]modules.RecordDiff}
+// @Failure 403 {object} mw.Domain
+// @Failure 404 {object} mw.Domain
+// @Failure 500 {object} mw.Domain
+// @Router /api/v1/domain/change/{id}/diff [get]
+func DomainChangeDiff(c *fiber.Ctx) error {
+	// extract info
+	uid := c.Locals("sub").(uint)
+	role := c.Locals("role").(models.UserRole)
+
+	// get domain change
+	dcId := c.Params("id")
+	var dc models.DomainChange
+	if err := db.DB.Preload("Domain").Where("id = ?", dcId).First(&dc).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(mw.Domain{
+			Status: fiber.StatusNotFound,
+			Errors: "Domain change not found",
+		})
+	}
+
+	// check permission
+	policy := loadApprovalPolicy(dc.DomainId)
+	if domainReviewer, admin := reviewerKind(uid, role, &dc, &policy); !(dc.UserId == uid || domainReviewer || admin) {
+		return c.Status(fiber.StatusForbidden).JSON(mw.Domain{
+			Status: fiber.StatusForbidden,
+			Errors: "Permission denied",
+		})
+	}
+
+	diffs, err := changeDiff(&dc, &dc.Domain)
+	if err != nil {
+		logrus.Error(err)
+		return c.Status(fiber.StatusInternalServerError).JSON(mw.Domain{
+			Status: fiber.StatusInternalServerError,
+			Errors: err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(mw.Domain{
+		Status: fiber.StatusOK,
+		Data:   diffs,
+	})
+}
+
+// changeDiff compares the records touched by the change with live ones, nil if it doesn't change dns
+func changeDiff(dc *models.DomainChange, d *models.Domain) ([]modules.RecordDiff, error) {
+	switch dc.ActionType {
+	case models.Submit, models.EditDNS, models.Delete:
+		var dcs modules.DnsChangeStruct
+		if err := json.Unmarshal([]byte(dc.Operation), &dcs); err != nil {
+			return nil, err
+		}
+		return dcs.Diff(d, dc.ActionType == models.Delete)
+	case models.SyncPlan:
+		var scs modules.SyncChangeStruct
+		if err := json.Unmarshal([]byte(dc.Operation), &scs); err != nil {
+			return nil, err
+		}
+		return scs.Diff(d)
+	case models.Batch:
+		var bcs modules.BatchChangeStruct
+		if err := json.Unmarshal([]byte(dc.Operation), &bcs); err != nil {
+			return nil, err
+		}
+		return bcs.Diff(d)
+	}
+	return nil, nil
+}
+
+// checkedConflict responds the change is closed or being applied by another request
+func checkedConflict(c *fiber.Ctx, err error) error {
+	if err != nil {
+		logrus.Error(err)
+		return c.Status(fiber.StatusInternalServerError).JSON(mw.Domain{
+			Status: fiber.StatusInternalServerError,
+			Errors: "Database error",
+		})
+	}
+	return c.Status(fiber.StatusConflict).JSON(mw.Domain{
+		Status: fiber.StatusConflict,
+		Errors: "Domain change is already checked",
+	})
+}
+
+// claimDomainChange moves the change from status from to to, false if it is not in from any more
+func claimDomainChange(dc *models.DomainChange, from, to models.ActionStatus) (bool, error) {
+	result := db.DB.Model(&models.DomainChange{}).
+		Where("id = ? AND action_status = ?", dc.ID, from).
+		Update("action_status", to)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+	dc.ActionStatus = to
+	return true, nil
+}
+
+// failDomainChange closes the change being applied as failed, comment is the error
+func failDomainChange(dc *models.DomainChange, comment string) error {
+	result := db.DB.Model(&models.DomainChange{}).
+		Where("id = ? AND action_status = ?", dc.ID, models.Applying).
+		Updates(map[string]interface{}{"action_status": models.Failed, "comment": comment})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		dc.ActionStatus = models.Failed
+		dc.Comment = comment
+	}
+	return nil
+}
+
+// applyClaimedChange applies the change claimed by the approval, the response is returned if it fails,
+// and the change is closed as failed if the records are changed partly
+func applyClaimedChange(dc *models.DomainChange) (int, *mw.Domain) {
+	var d models.Domain
+	if err := db.DB.Where("id = ?", dc.DomainId).First(&d).Error; err != nil {
+		return fiber.StatusInternalServerError, &mw.Domain{
+			Status: fiber.StatusInternalServerError,
+			Errors: "Database error",
+		}
+	}
+	// the records may be changed since the change is submitted
+	diffs, err := changeDiff(dc, &d)
+	if err != nil {
+		logrus.Error(err)
+		return fiber.StatusInternalServerError, &mw.Domain{
+			Status: fiber.StatusInternalServerError,
+			Errors: err.Error(),
+		}
+	}
+	if modules.HasConflict(diffs) {
+		return fiber.StatusConflict, &mw.Domain{
+			Status: fiber.StatusConflict,
+			Errors: "Dns records are changed since the change is submitted, please review the diff",
+			Data:   diffs,
+		}
+	}
+
+	var prior *priorState
+	if dc.ActionType.ChangesDns() {
+		if prior, err = capturePriorState(&d, dc.UserId); err != nil {
+			logrus.Error(err)
+			return fiber.StatusInternalServerError, &mw.Domain{
+				Status: fiber.StatusInternalServerError,
+				Errors: err.Error(),
+			}
+		}
+		defer prior.Release()
+	}
+	err = applyDomainChange(dc, &d)
+	if prior != nil && (err == nil || appliedPartly(dc, err)) {
+		prior.Save()
+	}
+	if err != nil {
+		logrus.Error(err)
+		if appliedPartly(dc, err) {
+			if err := failDomainChange(dc, err.Error()); err != nil {
+				logrus.Error(err)
+			}
+		}
+		status := recordErrorStatus(err, fiber.StatusInternalServerError)
+		return status, &mw.Domain{
+			Status: status,
+			Errors: err.Error(),
+		}
+	}
+	return fiber.StatusOK, nil
+}
+
+// appliedPartly reports whether the failed change may have changed records, a plan stops at the
+// failed step, and a batch is left half changed if its rollback failed
+func appliedPartly(dc *models.DomainChange, err error) bool {
+	switch dc.ActionType {
+	case models.SyncPlan:
+		// a plan conflicting with the zone is refused before any step
+		var conflict *modules.ZoneConflictError
+		return !errors.As(err, &conflict)
+	case models.Batch:
+		batchErr, ok := err.(*modules.BatchError)
+		return ok && len(batchErr.RollbackErrors) > 0
+	}
+	return false
+}
+
+// changeRecordId is the id of the record edited or deleted by the change, empty for others
+func changeRecordId(dc *models.DomainChange) string {
+	switch dc.ActionType {
+	case models.EditDNS, models.Delete:
+		var dcs modules.DnsChangeStruct
+		if err := json.Unmarshal([]byte(dc.Operation), &dcs); err == nil {
+			return dcs.Dns.Id
+		}
+	}
+	return ""
+}
+
+// applyDomainChange carries out the operation of an approved domain change on domain d
+func applyDomainChange(dc *models.DomainChange, d *models.Domain) error {
+	switch dc.ActionType {
+	case models.Submit, models.EditDNS:
+		var dcs modules.DnsChangeStruct
+		if err := json.Unmarshal([]byte(dc.Operation), &dcs); err != nil {
+			return err
+		}
+		// the zone may be changed since the change is submitted
+		if err := modules.CheckRecordConflicts(d, &dcs.Dns); err != nil {
+			return err
+		}
+		dnsObj, err := dcs.DnsChangeRestore(d)
+		if err != nil {
+			return err
+		}
+		if dc.ActionType == models.Submit {
+			return dnsObj.Create()
+		}
+		return dnsObj.Update()
+	case models.Delete:
+		var dcs modules.DnsChangeStruct
+		if err := json.Unmarshal([]byte(dc.Operation), &dcs); err != nil {
+			return err
+		}
+		dnsObj, err := modules.DnsObjGen(d)
+		if err != nil {
+			return err
+		}
+		if err := dnsObj.Get(dcs.Dns.Id); err != nil {
+			return err
+		}
+		return dnsObj.Delete()
+	case models.SyncPlan:
+		var scs modules.SyncChangeStruct
+		if err := json.Unmarshal([]byte(dc.Operation), &scs); err != nil {
+			return err
+		}
+		if err := modules.CheckZoneConflicts(d, scs.Plan.Batch()); err != nil {
+			return err
+		}
+		return modules.ApplyPlan(d, &scs.Plan)
+	case models.Batch:
+		var bcs modules.BatchChangeStruct
+		if err := json.Unmarshal([]byte(dc.Operation), &bcs); err != nil {
+			return err
+		}
+		if err := modules.CheckZoneConflicts(d, &bcs.Batch); err != nil {
+			return err
+		}
+		_, err := modules.ApplyBatch(d, &bcs.Batch)
+		return err
+	case models.GrantAccess:
+		var du mw.DomainUser
+		if err := json.Unmarshal([]byte(dc.Operation), &du); err != nil {
+			return err
+		}
+		// the user may have a lower role already, or be given a higher one since the request
+		ud := models.UserDomain{UserId: uint(du.UserId), DomainId: d.ID, Role: du.Role}
+		if err := db.DB.Where("user_id = ? AND domain_id = ?", ud.UserId, ud.DomainId).
+			Attrs(&ud).FirstOrCreate(&ud).Error; err != nil {
+			return err
+		}
+		if ud.Role >= du.Role {
+			return nil
+		}
+		return db.DB.Model(&models.UserDomain{}).
+			Where("user_id = ? AND domain_id = ? AND role < ?", ud.UserId, ud.DomainId, du.Role).
+			Update("role", du.Role).Error
+	case models.RevokeAccess:
+		var du mw.DomainUser
+		if err := json.Unmarshal([]byte(dc.Operation), &du); err != nil {
+			return err
+		}
+		return db.DB.Where("user_id = ? AND domain_id = ?", du.UserId, d.ID).Delete(&models.UserDomain{}).Error
+	}
+	return nil
+}
+
+// approverRole is the lowest role to approve the change, managers can approve access changes
+// the same as they can grant and revoke directly, see UserDomainCreate and UserDomainDelete
+func approverRole(dc *models.DomainChange) models.UserDomainRole {
+	if !dc.ActionType.ChangesAccess() {
+		return models.Owner
+	}
+	var du mw.DomainUser
+	if err := json.Unmarshal([]byte(dc.Operation), &du); err != nil {
+		return models.Owner
+	}
+	if dc.ActionType == models.RevokeAccess {
+		// the role may have changed since requested
+		var ud models.UserDomain
+		if err := db.DB.Where("user_id = ? AND domain_id = ?", du.UserId, dc.DomainId).First(&ud).Error; err == nil {
+			du.Role = ud.Role
+		}
+		return utils.IfThen(du.Role >= models.Owner, models.Owner, models.Manager)
+	}
+	return utils.IfThen(du.Role >= models.Manager, models.Owner, models.Manager)
+}
+
 func DomainChangeNotify(c *fiber.Ctx) error {
 	if method := c.Method(); method == http.MethodGet {
 		return c.Next()
@@ -176,8 +618,10 @@ func DomainChangeNotify(c *fiber.Ctx) error {
 	if path := c.Path(); strings.HasPrefix(path, "/api/v1/domain/change") {
 		return c.Next()
 	}
-
 	err := c.Next()
+	if respondedDryRun(c) {
+		return err
+	}
 
 	notifyFeishu(c)
 
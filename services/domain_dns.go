@@ -1,8 +1,8 @@
 package services
 
 import (
-	// md "domain0/modules/dns"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strconv"
 
@@ -13,18 +13,24 @@ import (
 	"domain0/models"
 	mw "domain0/models/web"
 	"domain0/modules"
+	md "domain0/modules/dns"
+	"domain0/utils"
 )
 
 // @Summary List Domain Dns
-// @Description List Domain Dns **AliDNS as Example, read modules for others**
+// @Description List Domain Dns
 // @Description user must have read permission to domain or be admin
 // @Tags domain
 // @Accept json
 // @Param id path string true "domain id"
+// @Param type query string false "filter by record type"
+// @Param name query string false "filter by substring of record name"
+// @Param sort query string false "sort by name, type, content or ttl"
+// @Param order query string false "asc or desc"
+// @Param page query int false "page number, start from 1"
+// @Param page_size query int false "records per page, 0 for all"
 // @Produce json
-// @Success 200 {object} mw.Domain{data=[]md.CloudflareDNSList}
-// @Success 200 {object} mw.Domain{data=[]md.TencentDNSList}
-// @Success 200 {object} mw.Domain{data=[]md.AliDNSList}
+// @Success 200 {object} mw.Domain{data=mw.DnsRecordPage}
 // @Failure 400 {object} mw.Domain{data=int}
 // @Failure 403 {object} mw.Domain{data=int}
 // @Failure 404 {object} mw.Domain{data=int}
@@ -67,9 +73,26 @@ func DomainDnsList(c *fiber.Ctx) error {
 		})
 	}
 
+	// parse list query
+	var query modules.RecordQuery
+	if err := c.QueryParser(&query); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(mw.Domain{
+			Status: fiber.StatusBadRequest,
+			Errors: "invalid query",
+			Data:   qId,
+		})
+	}
+	if err := query.Check(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(mw.Domain{
+			Status: fiber.StatusBadRequest,
+			Errors: err.Error(),
+			Data:   qId,
+		})
+	}
+
 	// get domain dns list
-	dnsList := modules.DnsListObjGen(&domain)
-	if err := dnsList.GetDNSList(&domain); err != nil {
+	records, err := modules.DnsRecordList(&domain)
+	if err != nil {
 		logrus.Error(err)
 		return c.Status(fiber.StatusInternalServerError).JSON(mw.Domain{
 			Status: fiber.StatusInternalServerError,
@@ -78,21 +101,30 @@ func DomainDnsList(c *fiber.Ctx) error {
 		})
 	}
 
+	total, records := query.Apply(records)
 	return c.JSON(mw.Domain{
 		Status: fiber.StatusOK,
-		Data:   dnsList,
+		Data: mw.DnsRecordPage{
+			Total:    total,
+			Page:     utils.IfThen(query.Page < 1, 1, query.Page),
+			PageSize: query.PageSize,
+			Records:  records,
+		},
 	})
 }
 
 // @Summary Delete Domain Dns
 // @Description Delete Domain Dns
 // @Description user must have readwrite permission to domain or be admin
+// @Description for domain which ICP_reg is true, the delete of non-owner waits for owner approval
 // @Tags domain
 // @Accept json
 // @Param id path string true "domain id"
 // @Param dnsId path string true "dns id"
+// @Param dry_run query bool false "only preview the change, data is mw.DnsDryRun"
 // @Produce json
 // @Success 200 {object} mw.Domain{data=int}
+// @Success 208 {object} mw.Domain{data=string}
 // @Failure 400 {object} mw.Domain{data=int}
 // @Failure 403 {object} mw.Domain{data=int}
 // @Failure 404 {object} mw.Domain{data=int}
@@ -132,7 +164,14 @@ func DomainDnsDelete(c *fiber.Ctx) error {
 
 	// delete dns record
 	// get dns record
-	dnsObj := modules.DnsObjGen(&domain)
+	dnsObj, err := modules.DnsObjGen(&domain)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(mw.Domain{
+			Status: fiber.StatusBadRequest,
+			Errors: err.Error(),
+			Data:   qId,
+		})
+	}
 	if err := dnsObj.Get(dnsId); err != nil {
 		logrus.Error(err)
 		return c.Status(fiber.StatusNotFound).JSON(mw.Domain{
@@ -142,6 +181,61 @@ func DomainDnsDelete(c *fiber.Ctx) error {
 		})
 	}
 
+	deleted := dnsObj.ToRecord()
+	needApproval := domain.ICPReg > 0 && !checkUserDomainPermission(uId, qId, models.Owner)
+	if dryRun(c) {
+		return dryRunRecord(c, needApproval, &deleted, nil)
+	}
+	if needApproval {
+		dnsObjson, err := json.Marshal(modules.DnsChangeStruct{
+			Dns:    deleted,
+			Base:   &deleted,
+			Domain: domain,
+		})
+		if err != nil {
+			logrus.Error(err)
+			return c.Status(fiber.StatusInternalServerError).JSON(mw.Domain{
+				Status: fiber.StatusInternalServerError,
+				Errors: err.Error(),
+				Data:   qId,
+			})
+		}
+		dc := models.DomainChange{
+			DomainId:     domain.ID,
+			UserId:       uId,
+			ActionType:   models.Delete,
+			ActionStatus: models.Reviewing,
+			Reason:       fmt.Sprintf("%d want to delete dns record %s %s for domain %s:", uId, deleted.Name, deleted.Type, domain.Name),
+			Operation:    string(dnsObjson),
+		}
+		if err := db.DB.Create(&dc).Error; err != nil {
+			logrus.Error(err)
+			return c.Status(fiber.StatusInternalServerError).JSON(mw.Domain{
+				Status: fiber.StatusInternalServerError,
+				Errors: err.Error(),
+				Data:   qId,
+			})
+		}
+		// the change is only submitted, it is audited again when applied
+		auditChange(c, nil, dc)
+		return c.Status(fiber.StatusAlreadyReported).JSON(mw.Domain{
+			Status: fiber.StatusAlreadyReported,
+			Data:   "ICP domain need owner permission, please wait for approval",
+		})
+	}
+
+	// keep the records before change, so it can be rolled back
+	prior, err := capturePriorState(&domain, uId)
+	if err != nil {
+		logrus.Error(err)
+		return c.Status(fiber.StatusInternalServerError).JSON(mw.Domain{
+			Status: fiber.StatusInternalServerError,
+			Errors: err.Error(),
+			Data:   qId,
+		})
+	}
+	defer prior.Release()
+
 	if err := dnsObj.Delete(); err != nil {
 		logrus.Error(err)
 		return c.Status(fiber.StatusInternalServerError).JSON(mw.Domain{
@@ -150,6 +244,8 @@ func DomainDnsDelete(c *fiber.Ctx) error {
 			Data:   qId,
 		})
 	}
+	prior.Save()
+	auditChange(c, deleted, nil)
 
 	return c.JSON(mw.Domain{
 		Status: fiber.StatusOK,
@@ -158,20 +254,21 @@ func DomainDnsDelete(c *fiber.Ctx) error {
 }
 
 // @Summary Create Domain Dns
-// @Description Create Domain Dns **AliDNS as Example, read modules for others**
+// @Description Create Domain Dns
 // @Description user must have readwrite permission to domain or be admin
 // @Description for now only owner can edit domain which ICP_reg is true
+// @Description records conflicting with the zone are refused: CNAME with other records at a name, or duplicates
 // @Tags domain
 // @Accept json
 // @Param id path string true "domain id"
-// @Param dns body md.AliDNS true "dns info"
+// @Param dns body md.Record true "dns info"
+// @Param dry_run query bool false "only preview the change, data is mw.DnsDryRun"
 // @Produce json
-// @Success 200 {object} mw.Domain{data=md.AliDNS}
-// @Success 200 {object} mw.Domain{data=md.TencentDNS}
-// @Success 200 {object} mw.Domain{data=md.CloudflareDNS}
-// @Failure 400 {object} mw.Domain{data=int}
+// @Success 200 {object} mw.Domain{data=md.Record}
+// @Failure 400 {object} mw.Domain{data=[]modules.FieldError}
 // @Failure 403 {object} mw.Domain{data=int}
 // @Failure 404 {object} mw.Domain{data=int}
+// @Failure 409 {object} mw.Domain{data=[]modules.FieldError}
 // @Failure 500 {object} mw.Domain{data=int}
 // @Router /api/v1/domain/{id}/dns [post]
 func DomainDnsCreate(c *fiber.Ctx) error {
@@ -203,9 +300,9 @@ func DomainDnsCreate(c *fiber.Ctx) error {
 		})
 	}
 
-	// generate dns record
-	dnsObj := modules.DnsObjGen(&domain)
-	if err := c.BodyParser(dnsObj); err != nil {
+	// parse dns record
+	var record md.Record
+	if err := c.BodyParser(&record); err != nil {
 		logrus.Error(err)
 		return c.Status(fiber.StatusBadRequest).JSON(mw.Domain{
 			Status: fiber.StatusBadRequest,
@@ -213,11 +310,32 @@ func DomainDnsCreate(c *fiber.Ctx) error {
 			Data:   qId,
 		})
 	}
+	record.Id = ""
+	// a full name is posted as well, records are compared by names relative to the zone
+	record.Name = md.RelativeName(record.Name, domain.Name)
+
+	// generate dns record
+	dnsObj, err := modules.DnsObjFromRecord(&domain, record)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(mw.Domain{
+			Status: fiber.StatusBadRequest,
+			Errors: err.Error(),
+			Data:   invalidRecordData(c, err),
+		})
+	}
+
+	if ok, err := recordConflictResponse(c, &domain, &record); !ok {
+		return err
+	}
 
-	if domain.ICPReg > 0 && !checkUserDomainPermission(uId, qId, models.Owner) {
+	needApproval := domain.ICPReg > 0 && !checkUserDomainPermission(uId, qId, models.Owner)
+	if dryRun(c) {
+		return dryRunRecord(c, needApproval, nil, &record)
+	}
+	if needApproval {
 		// todo: notify
 		dnsObjson, err := json.Marshal(modules.DnsChangeStruct{
-			Dns:    dnsObj,
+			Dns:    record,
 			Domain: domain,
 		})
 		if err != nil {
@@ -245,13 +363,16 @@ func DomainDnsCreate(c *fiber.Ctx) error {
 				Data:   qId,
 			})
 		}
+		// the change is only submitted, it is audited again when applied
+		auditChange(c, nil, dc)
 		return c.Status(fiber.StatusAlreadyReported).JSON(mw.Domain{
 			Status: fiber.StatusAlreadyReported,
 			Data:   "ICP domain need owner permission, please wait for approval",
 		})
 	} else {
 		// create dns record
-		if err := dnsObj.Create(); err != nil {
+		created, err := createRecord(&domain, uId, dnsObj)
+		if err != nil {
 			logrus.Error(err)
 			return c.Status(fiber.StatusInternalServerError).JSON(mw.Domain{
 				Status: fiber.StatusInternalServerError,
@@ -259,31 +380,50 @@ func DomainDnsCreate(c *fiber.Ctx) error {
 				Data:   qId,
 			})
 		}
+		auditRecord(c, created.Id)
+		auditChange(c, nil, created)
 
-		logrus.Info("User: ", uId, " create dns record: ", dnsObj, " for domain: ", qId)
+		logrus.Info("User: ", uId, " create dns record: ", created.Id, " ", created.Name, " for domain: ", domain.Name)
 		return c.JSON(mw.Domain{
 			Status: fiber.StatusCreated,
-			Data:   dnsObj,
+			Data:   created,
 		})
 	}
 }
 
+// createRecord creates the record of dnsObj, which is validated and checked against the zone, the
+// records before are kept as a version of domain d by user uId, so it can be rolled back
+func createRecord(d *models.Domain, uId uint, dnsObj modules.DnsObj) (md.Record, error) {
+	prior, err := capturePriorState(d, uId)
+	if err != nil {
+		return md.Record{}, err
+	}
+	defer prior.Release()
+
+	if err := dnsObj.Create(); err != nil {
+		return md.Record{}, err
+	}
+	prior.Save()
+	return dnsObj.ToRecord(), nil
+}
+
 // @Summary Update Domain Dns
-// @Description Update Domain Dns **AliDNS as Example, read modules for others**
+// @Description Update Domain Dns
 // @Description user must have readwrite permission to domain or be admin
 // @Description for now only owner can edit domain which ICP_reg is true
+// @Description records conflicting with the zone are refused: CNAME with other records at a name, or duplicates
 // @Tags domain
 // @Accept json
 // @Param id path string true "domain id"
 // @Param dnsId path string true "dns id"
-// @Param dns body md.AliDNS true "dns info"
+// @Param dns body md.Record true "dns info"
+// @Param dry_run query bool false "only preview the change, data is mw.DnsDryRun"
 // @Produce json
-// @Success 200 {object} mw.Domain{data=md.AliDNS}
-// @Success 200 {object} mw.Domain{data=md.TencentDNS}
-// @Success 200 {object} mw.Domain{data=md.CloudflareDNS}
-// @Failure 400 {object} mw.Domain{data=int}
+// @Success 200 {object} mw.Domain{data=md.Record}
+// @Failure 400 {object} mw.Domain{data=[]modules.FieldError}
 // @Failure 403 {object} mw.Domain{data=int}
 // @Failure 404 {object} mw.Domain{data=int}
+// @Failure 409 {object} mw.Domain{data=[]modules.FieldError}
 // @Failure 500 {object} mw.Domain{data=int}
 // @Router /api/v1/domain/{id}/dns/{dnsId} [put]
 func DomainDnsUpdate(c *fiber.Ctx) error {
@@ -319,7 +459,14 @@ func DomainDnsUpdate(c *fiber.Ctx) error {
 	dnsId := c.Params("dnsId")
 
 	// get dns record
-	dnsObj := modules.DnsObjGen(&domain)
+	dnsObj, err := modules.DnsObjGen(&domain)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(mw.Domain{
+			Status: fiber.StatusBadRequest,
+			Errors: err.Error(),
+			Data:   qId,
+		})
+	}
 	if err := dnsObj.Get(dnsId); err != nil {
 		logrus.Error(err)
 		return c.Status(fiber.StatusNotFound).JSON(mw.Domain{
@@ -329,8 +476,10 @@ func DomainDnsUpdate(c *fiber.Ctx) error {
 		})
 	}
 
-	// update dns record
-	if err := c.BodyParser(dnsObj); err != nil {
+	// update dns record, fields absent in body are kept
+	old := dnsObj.ToRecord()
+	record := old
+	if err := c.BodyParser(&record); err != nil {
 		logrus.Error(err)
 		return c.Status(fiber.StatusBadRequest).JSON(mw.Domain{
 			Status: fiber.StatusBadRequest,
@@ -338,10 +487,28 @@ func DomainDnsUpdate(c *fiber.Ctx) error {
 			Data:   qId,
 		})
 	}
+	record.Id = dnsId
+	record.Name = md.RelativeName(record.Name, domain.Name)
+	if dnsObj, err = modules.DnsObjFromRecord(&domain, record); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(mw.Domain{
+			Status: fiber.StatusBadRequest,
+			Errors: err.Error(),
+			Data:   invalidRecordData(c, err),
+		})
+	}
 
-	if domain.ICPReg > 0 && !checkUserDomainPermission(uId, qId, models.Owner) {
+	if ok, err := recordConflictResponse(c, &domain, &record); !ok {
+		return err
+	}
+
+	needApproval := domain.ICPReg > 0 && !checkUserDomainPermission(uId, qId, models.Owner)
+	if dryRun(c) {
+		return dryRunRecord(c, needApproval, &old, &record)
+	}
+	if needApproval {
 		dnsObjson, err := json.Marshal(modules.DnsChangeStruct{
-			Dns:    dnsObj,
+			Dns:    record,
+			Base:   &old,
 			Domain: domain,
 		})
 		if err != nil {
@@ -369,11 +536,25 @@ func DomainDnsUpdate(c *fiber.Ctx) error {
 				Data:   qId,
 			})
 		}
+		// the change is only submitted, it is audited again when applied
+		auditChange(c, nil, dc)
 		return c.Status(fiber.StatusAlreadyReported).JSON(mw.Domain{
 			Status: fiber.StatusAlreadyReported,
 			Data:   "ICP domain need owner permission, please wait for approval",
 		})
 	} else {
+		// keep the records before change, so it can be rolled back
+		prior, err := capturePriorState(&domain, uId)
+		if err != nil {
+			logrus.Error(err)
+			return c.Status(fiber.StatusInternalServerError).JSON(mw.Domain{
+				Status: fiber.StatusInternalServerError,
+				Errors: err.Error(),
+				Data:   qId,
+			})
+		}
+		defer prior.Release()
+
 		if err := dnsObj.Update(); err != nil {
 			logrus.Error(err)
 			return c.Status(fiber.StatusInternalServerError).JSON(mw.Domain{
@@ -382,11 +563,112 @@ func DomainDnsUpdate(c *fiber.Ctx) error {
 				Data:   qId,
 			})
 		}
+		prior.Save()
+		updated := dnsObj.ToRecord()
+		auditChange(c, old, updated)
 
-		logrus.Info("User: ", uId, " update dns record: ", dnsObj, " for domain: ", qId)
+		logrus.Info("User: ", uId, " update dns record: ", updated.Id, " ", updated.Name, " for domain: ", domain.Name)
 		return c.JSON(mw.Domain{
 			Status: fiber.StatusOK,
-			Data:   dnsObj,
+			Data:   updated,
 		})
 	}
 }
+
+// dryRun reports whether the request only previews the change, vendor is not called to change records
+func dryRun(c *fiber.Ctx) bool {
+	return c.QueryBool("dry_run", false)
+}
+
+// respondedDryRun reports whether the handler responded a dry run, the query alone is not trusted
+// as it is ignored by routes without dry run
+func respondedDryRun(c *fiber.Ctx) bool {
+	responded, _ := c.Locals(localsDryRun).(bool)
+	return responded
+}
+
+// dryRunResponse responds the changes that would be made, approval tells the change would be sent
+// to owner for approval
+func dryRunResponse(c *fiber.Ctx, approval bool, changes []modules.RecordDiff) error {
+	c.Locals(localsDryRun, true)
+	return c.JSON(mw.Domain{
+		Status: fiber.StatusOK,
+		Data: mw.DnsDryRun{
+			DryRun:   true,
+			Approval: approval,
+			Changes:  changes,
+		},
+	})
+}
+
+// dryRunRecord responds the change of a single record, base is nil for create and proposed is nil for delete
+func dryRunRecord(c *fiber.Ctx, approval bool, base, proposed *md.Record) error {
+	diff, err := modules.NewRecordDiff(base, base, proposed)
+	if err != nil {
+		logrus.Error(err)
+		return c.Status(fiber.StatusInternalServerError).JSON(mw.Domain{
+			Status: fiber.StatusInternalServerError,
+			Errors: err.Error(),
+			Data:   c.Params("id"),
+		})
+	}
+	return dryRunResponse(c, approval, []modules.RecordDiff{diff})
+}
+
+// dryRunPlan responds the changes of a plan just computed against live records
+func dryRunPlan(c *fiber.Ctx, approval bool, plan *modules.Plan) error {
+	changes, err := plan.Preview()
+	if err != nil {
+		logrus.Error(err)
+		return c.Status(fiber.StatusInternalServerError).JSON(mw.Domain{
+			Status: fiber.StatusInternalServerError,
+			Errors: err.Error(),
+			Data:   c.Params("id"),
+		})
+	}
+	return dryRunResponse(c, approval, changes)
+}
+
+// invalidRecordData is the data of a response to invalid or conflicting records, the field errors,
+// or the domain id as other responses
+func invalidRecordData(c *fiber.Ctx, err error) interface{} {
+	var verr *modules.ValidationError
+	if errors.As(err, &verr) {
+		return verr.Fields
+	}
+	var conflict *modules.ZoneConflictError
+	if errors.As(err, &conflict) {
+		return conflict.Fields
+	}
+	return c.Params("id")
+}
+
+// recordErrorStatus is the response status of err checking records, 400 for invalid records,
+// 409 for records conflicting with the zone, fallback for others
+func recordErrorStatus(err error, fallback int) int {
+	var verr *modules.ValidationError
+	var conflict *modules.ZoneConflictError
+	switch {
+	case errors.As(err, &verr):
+		return fiber.StatusBadRequest
+	case errors.As(err, &conflict):
+		return fiber.StatusConflict
+	}
+	return fallback
+}
+
+// recordConflictResponse checks the record to create or update against the live zone,
+// the error response is sent if it fails
+func recordConflictResponse(c *fiber.Ctx, d *models.Domain, r *md.Record) (bool, error) {
+	err := modules.CheckRecordConflicts(d, r)
+	if err == nil {
+		return true, nil
+	}
+	logrus.Error(err)
+	status := recordErrorStatus(err, fiber.StatusInternalServerError)
+	return false, c.Status(status).JSON(mw.Domain{
+		Status: status,
+		Errors: err.Error(),
+		Data:   invalidRecordData(c, err),
+	})
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+
+	db "domain0/database"
+	"domain0/models"
+	mw "domain0/models/web"
+	"domain0/modules"
+)
+
+// @Summary List Domain Dns Snapshots
+// @Description List the versions of domain records kept before each change, newest first, records are omitted
+// @Description user must have read permission to domain or be admin
+// @Tags domain
+// @Param id path string true "domain id"
+// @Produce json
+// @Success 200 {object} mw.Domain{data=[]mw.DnsSnapshot}
+// @Failure 403 {object} mw.Domain{data=int}
+// @Failure 404 {object} mw.Domain{data=int}
+// @Failure 500 {object} mw.Domain{data=int}
+// @Router /api/v1/domain/{id}/snapshots [get]
+func DomainSnapshotList(c *fiber.Ctx) error {
+	domain, ok, err := permittedDomain(c, models.ReadOnly)
+	if !ok {
+		return err
+	}
+
+	var snapshots []models.DnsSnapshot
+	err = db.DB.Select("id", "created_at", "source", "version", "user_id").
+		Where("domain_id = ? AND source = ?", domain.ID, models.SnapshotVersion).
+		Order("version desc").Find(&snapshots).Error
+	if err != nil {
+		logrus.Error(err)
+		return c.Status(fiber.StatusInternalServerError).JSON(mw.Domain{
+			Status: fiber.StatusInternalServerError,
+			Errors: "Database error",
+			Data:   c.Params("id"),
+		})
+	}
+
+	res := make([]mw.DnsSnapshot, 0, len(snapshots))
+	for _, s := range snapshots {
+		res = append(res, mw.DnsSnapshot{
+			Id:        s.ID,
+			Source:    s.Source,
+			Version:   s.Version,
+			UserId:    s.UserId,
+			CreatedAt: s.CreatedAt,
+		})
+	}
+	return c.JSON(mw.Domain{
+		Status: fiber.StatusOK,
+		Data:   res,
+	})
+}
+
+// loadSnapshot gets the snapshot in path, it must belong to the domain
+func loadSnapshot(c *fiber.Ctx, domain *models.Domain) (*mw.DnsSnapshot, error) {
+	var s models.DnsSnapshot
+	if err := db.DB.Where("id = ? AND domain_id = ?", c.Params("sid"), domain.ID).First(&s).Error; err != nil {
+		return nil, err
+	}
+	records, err := snapshotRecords(&s)
+	if err != nil {
+		return nil, err
+	}
+	return &mw.DnsSnapshot{
+		Id:        s.ID,
+		Source:    s.Source,
+		Version:   s.Version,
+		UserId:    s.UserId,
+		CreatedAt: s.CreatedAt,
+		Records:   records,
+	}, nil
+}
+
+// @Summary Get Domain Dns Snapshot
+// @Description Get a snapshot of domain records
+// @Description user must have read permission to domain or be admin
+// @Tags domain
+// @Param id path string true "domain id"
+// @Param sid path string true "snapshot id"
+// @Produce json
+// @Success 200 {object} mw.Domain{data=mw.DnsSnapshot}
+// @Failure 403 {object} mw.Domain{data=int}
+// @Failure 404 {object} mw.Domain{data=int}
+// @Router /api/v1/domain/{id}/snapshots/{sid} [get]
+func DomainSnapshotGet(c *fiber.Ctx) error {
+	domain, ok, err := permittedDomain(c, models.ReadOnly)
+	if !ok {
+		return err
+	}
+
+	snapshot, err := loadSnapshot(c, domain)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(mw.Domain{
+			Status: fiber.StatusNotFound,
+			Errors: "snapshot not found",
+			Data:   c.Params("id"),
+		})
+	}
+	return c.JSON(mw.Domain{
+		Status: fiber.StatusOK,
+		Data:   snapshot,
+	})
+}
+
+// @Summary Restore Domain Dns Snapshot
+// @Description Compute and apply the changes to return domain records to the snapshot,
+// @Description records added after the snapshot are deleted, the state before restore is kept as a new version
+// @Description user must have readwrite permission to domain or be admin
+// @Description for ICP domain the restore of non-owner is sent to owner for approval as one domain change
+// @Tags domain
+// @Param id path string true "domain id"
+// @Param sid path string true "snapshot id"
+// @Produce json
+// @Success 200 {object} mw.Domain{data=modules.Plan}
+// @Success 208 {object} mw.Domain{data=string}
+// @Failure 403 {object} mw.Domain{data=int}
+// @Failure 404 {object} mw.Domain{data=int}
+// @Failure 409 {object} mw.Domain{data=[]modules.FieldError}
+// @Failure 500 {object} mw.Domain{data=int}
+// @Router /api/v1/domain/{id}/snapshots/{sid}/restore [post]
+func DomainSnapshotRestore(c *fiber.Ctx) error {
+	domain, ok, err := permittedDomain(c, models.ReadWrite)
+	if !ok {
+		return err
+	}
+	uId := c.Locals("sub").(uint)
+
+	snapshot, err := loadSnapshot(c, domain)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(mw.Domain{
+			Status: fiber.StatusNotFound,
+			Errors: "snapshot not found",
+			Data:   c.Params("id"),
+		})
+	}
+
+	live, err := modules.DnsRecordList(domain)
+	if err != nil {
+		logrus.Error(err)
+		return c.Status(fiber.StatusInternalServerError).JSON(mw.Domain{
+			Status: fiber.StatusInternalServerError,
+			Errors: err.Error(),
+			Data:   c.Params("id"),
+		})
+	}
+
+	// ids in snapshot may be gone, records are matched by content
+	for i := range snapshot.Records {
+		snapshot.Records[i].Id = ""
+	}
+	plan := modules.ComputePlan(snapshot.Records, live, true)
+	if plan.Empty() {
+		return c.JSON(mw.Domain{
+			Status: fiber.StatusOK,
+			Data:   plan,
+		})
+	}
+
+	reason := fmt.Sprintf("%d want to restore dns records of domain %s to snapshot %d", uId, domain.Name, snapshot.Id)
+	return applySyncPlan(c, domain, uId, &plan, reason)
+}
@@ -0,0 +1,257 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+
+	db "domain0/database"
+	"domain0/models"
+	mw "domain0/models/web"
+	"domain0/modules"
+)
+
+// syncPlan parses the zone document in request body and computes the plan against live records,
+// the returned status is used for response on error
+func syncPlan(c *fiber.Ctx, domain *models.Domain) (*modules.Plan, int, error) {
+	doc, err := modules.ParseZoneDocument(domain, c.Body())
+	if err != nil {
+		return nil, fiber.StatusBadRequest, err
+	}
+
+	live, err := modules.DnsRecordList(domain)
+	if err != nil {
+		return nil, fiber.StatusInternalServerError, err
+	}
+
+	prune := doc.Prune == nil || *doc.Prune
+	plan := modules.ComputePlan(doc.Records, live, prune)
+	return &plan, fiber.StatusOK, nil
+}
+
+// @Summary Plan Domain Dns Sync
+// @Description Compare the desired records in a yaml or json zone document with the live records
+// @Description and list the creates, updates and deletes needed, nothing is changed
+// @Description user must have read permission to domain or be admin
+// @Tags domain
+// @Accept json
+// @Param id path string true "domain id"
+// @Param doc body modules.ZoneDocument true "zone document"
+// @Produce json
+// @Success 200 {object} mw.Domain{data=modules.Plan}
+// @Failure 400 {object} mw.Domain{data=[]modules.FieldError}
+// @Failure 403 {object} mw.Domain{data=int}
+// @Failure 404 {object} mw.Domain{data=int}
+// @Failure 500 {object} mw.Domain{data=int}
+// @Router /api/v1/domain/{id}/sync/plan [post]
+func DomainSyncPlan(c *fiber.Ctx) error {
+	// get domainId restful api
+	qId := c.Params("id")
+
+	// get query user info from jwt sub
+	uId := c.Locals("sub").(uint)
+
+	// check if user role level
+	isAdmin := c.Locals("role").(models.UserRole) >= models.Admin
+	permission := checkUserDomainPermission(uId, qId, models.ReadOnly)
+	if !(isAdmin || permission) {
+		logrus.Info("User: ", uId, " try to access domain: ", qId, " without permission")
+		return c.Status(fiber.StatusForbidden).JSON(mw.Domain{
+			Status: fiber.StatusForbidden,
+			Errors: "permission denied",
+			Data:   qId,
+		})
+	}
+
+	// get domain info
+	var domain models.Domain
+	if err := db.DB.Where("id = ?", qId).First(&domain).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(mw.Domain{
+			Status: fiber.StatusNotFound,
+			Errors: "domain not found",
+			Data:   qId,
+		})
+	}
+
+	// admin have no access to privacy domain
+	if !permission && isAdmin && domain.Privacy {
+		return c.Status(fiber.StatusForbidden).JSON(mw.Domain{
+			Status: fiber.StatusForbidden,
+			Errors: "permission denied, privacy domain",
+			Data:   qId,
+		})
+	}
+
+	plan, status, err := syncPlan(c, &domain)
+	if err != nil {
+		logrus.Error(err)
+		return c.Status(status).JSON(mw.Domain{
+			Status: status,
+			Errors: err.Error(),
+			Data:   invalidRecordData(c, err),
+		})
+	}
+
+	return c.JSON(mw.Domain{
+		Status: fiber.StatusOK,
+		Data:   plan,
+	})
+}
+
+// @Summary Apply Domain Dns Sync
+// @Description Compute the plan of a yaml or json zone document like sync/plan and apply it
+// @Description user must have readwrite permission to domain or be admin
+// @Description for ICP domain the plan of non-owner is sent to owner for approval as one domain change
+// @Tags domain
+// @Accept json
+// @Param id path string true "domain id"
+// @Param doc body modules.ZoneDocument true "zone document"
+// @Param dry_run query bool false "only preview the change, data is mw.DnsDryRun"
+// @Produce json
+// @Success 200 {object} mw.Domain{data=modules.Plan}
+// @Success 208 {object} mw.Domain{data=string}
+// @Failure 400 {object} mw.Domain{data=[]modules.FieldError}
+// @Failure 403 {object} mw.Domain{data=int}
+// @Failure 404 {object} mw.Domain{data=int}
+// @Failure 409 {object} mw.Domain{data=[]modules.FieldError}
+// @Failure 500 {object} mw.Domain{data=int}
+// @Router /api/v1/domain/{id}/sync/apply [post]
+func DomainSyncApply(c *fiber.Ctx) error {
+	// get domainId restful api
+	qId := c.Params("id")
+
+	// get query user info from jwt sub
+	uId := c.Locals("sub").(uint)
+
+	// check if user role level
+	flag := c.Locals("role").(models.UserRole) >= models.Admin
+	if !(flag || checkUserDomainPermission(uId, qId, models.ReadWrite)) {
+		logrus.Info("User: ", uId, " try to access domain: ", qId, " without permission")
+		return c.Status(fiber.StatusForbidden).JSON(mw.Domain{
+			Status: fiber.StatusForbidden,
+			Errors: "permission denied",
+			Data:   qId,
+		})
+	}
+
+	// get domain info
+	var domain models.Domain
+	if err := db.DB.Where("id = ?", qId).First(&domain).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(mw.Domain{
+			Status: fiber.StatusNotFound,
+			Errors: "domain not found",
+			Data:   qId,
+		})
+	}
+
+	plan, status, err := syncPlan(c, &domain)
+	if err != nil {
+		logrus.Error(err)
+		return c.Status(status).JSON(mw.Domain{
+			Status: status,
+			Errors: err.Error(),
+			Data:   invalidRecordData(c, err),
+		})
+	}
+	if dryRun(c) {
+		return dryRunPlan(c, domain.ICPReg > 0 && !checkUserDomainPermission(uId, qId, models.Owner), plan)
+	}
+	if plan.Empty() {
+		return c.JSON(mw.Domain{
+			Status: fiber.StatusOK,
+			Data:   plan,
+		})
+	}
+
+	reason := fmt.Sprintf("%d want to sync dns records for domain %s", uId, domain.Name)
+	return applySyncPlan(c, &domain, uId, plan, reason)
+}
+
+// applySyncPlan applies the plan to domain, or sends it to owner for approval if the domain is ICP
+// and user is not owner, reason describes the change for approval
+func applySyncPlan(c *fiber.Ctx, domain *models.Domain, uId uint, plan *modules.Plan, reason string) error {
+	qId := c.Params("id")
+
+	// records kept by the plan may conflict with the ones it creates, when prune is off
+	if err := modules.CheckZoneConflicts(domain, plan.Batch()); err != nil {
+		logrus.Error(err)
+		status := recordErrorStatus(err, fiber.StatusInternalServerError)
+		return c.Status(status).JSON(mw.Domain{
+			Status: status,
+			Errors: err.Error(),
+			Data:   invalidRecordData(c, err),
+		})
+	}
+
+	if domain.ICPReg > 0 && !checkUserDomainPermission(uId, qId, models.Owner) {
+		scsJson, err := json.Marshal(modules.SyncChangeStruct{
+			Plan:   *plan,
+			Domain: *domain,
+		})
+		if err != nil {
+			logrus.Error(err)
+			return c.Status(fiber.StatusInternalServerError).JSON(mw.Domain{
+				Status: fiber.StatusInternalServerError,
+				Errors: err.Error(),
+				Data:   qId,
+			})
+		}
+		dc := models.DomainChange{
+			DomainId:     domain.ID,
+			UserId:       uId,
+			ActionType:   models.SyncPlan,
+			ActionStatus: models.Reviewing,
+			Reason: fmt.Sprintf("%s: %d creates, %d updates, %d deletes",
+				reason, len(plan.Creates), len(plan.Updates), len(plan.Deletes)),
+			Operation: string(scsJson),
+		}
+		if err := db.DB.Create(&dc).Error; err != nil {
+			logrus.Error(err)
+			return c.Status(fiber.StatusInternalServerError).JSON(mw.Domain{
+				Status: fiber.StatusInternalServerError,
+				Errors: err.Error(),
+				Data:   qId,
+			})
+		}
+		// the change is only submitted, it is audited again when applied
+		auditChange(c, nil, dc)
+		return c.Status(fiber.StatusAlreadyReported).JSON(mw.Domain{
+			Status: fiber.StatusAlreadyReported,
+			Data:   "ICP domain need owner permission, please wait for approval",
+		})
+	}
+
+	// keep the records before change, so it can be rolled back
+	prior, err := capturePriorState(domain, uId)
+	if err != nil {
+		logrus.Error(err)
+		return c.Status(fiber.StatusInternalServerError).JSON(mw.Domain{
+			Status: fiber.StatusInternalServerError,
+			Errors: err.Error(),
+			Data:   qId,
+		})
+	}
+	defer prior.Release()
+
+	// a failed plan may be applied partly, keep the prior version anyway
+	err = modules.ApplyPlan(domain, plan)
+	prior.Save()
+	auditChange(c, nil, plan)
+	if err != nil {
+		logrus.Error(err)
+		return c.Status(fiber.StatusInternalServerError).JSON(mw.Domain{
+			Status: fiber.StatusInternalServerError,
+			Errors: err.Error(),
+			Data:   qId,
+		})
+	}
+
+	logrus.Info(reason, ", creates: ", len(plan.Creates),
+		", updates: ", len(plan.Updates), ", deletes: ", len(plan.Deletes))
+	return c.JSON(mw.Domain{
+		Status: fiber.StatusOK,
+		Data:   plan,
+	})
+}
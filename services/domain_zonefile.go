@@ -0,0 +1,210 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+
+	db "domain0/database"
+	"domain0/models"
+	mw "domain0/models/web"
+	"domain0/modules"
+	md "domain0/modules/dns"
+)
+
+// @Summary Export Domain Zone File
+// @Description Export all dns records of domain as a RFC 1035 zone file
+// @Description user must have read permission to domain or be admin
+// @Tags domain
+// @Param id path string true "domain id"
+// @Produce plain
+// @Success 200 {string} string "zone file"
+// @Failure 403 {object} mw.Domain{data=int}
+// @Failure 404 {object} mw.Domain{data=int}
+// @Failure 500 {object} mw.Domain{data=int}
+// @Router /api/v1/domain/{id}/zonefile [get]
+func DomainZoneFileExport(c *fiber.Ctx) error {
+	// get domainId restful api
+	qId := c.Params("id")
+
+	// get query user info from jwt sub
+	uId := c.Locals("sub").(uint)
+
+	// check if user role level
+	isAdmin := c.Locals("role").(models.UserRole) >= models.Admin
+	permission := checkUserDomainPermission(uId, qId, models.ReadOnly)
+	if !(isAdmin || permission) {
+		logrus.Info("User: ", uId, " try to access domain: ", qId, " without permission")
+		return c.Status(fiber.StatusForbidden).JSON(mw.Domain{
+			Status: fiber.StatusForbidden,
+			Errors: "permission denied",
+			Data:   qId,
+		})
+	}
+
+	// get domain info
+	var domain models.Domain
+	if err := db.DB.Where("id = ?", qId).First(&domain).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(mw.Domain{
+			Status: fiber.StatusNotFound,
+			Errors: "domain not found",
+			Data:   qId,
+		})
+	}
+
+	// admin have no access to privacy domain
+	if !permission && isAdmin && domain.Privacy {
+		return c.Status(fiber.StatusForbidden).JSON(mw.Domain{
+			Status: fiber.StatusForbidden,
+			Errors: "permission denied, privacy domain",
+			Data:   qId,
+		})
+	}
+
+	records, err := modules.DnsRecordList(&domain)
+	if err != nil {
+		logrus.Error(err)
+		return c.Status(fiber.StatusInternalServerError).JSON(mw.Domain{
+			Status: fiber.StatusInternalServerError,
+			Errors: err.Error(),
+			Data:   qId,
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, fiber.MIMETextPlainCharsetUTF8)
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf("attachment; filename=\"%s.zone\"", domain.Name))
+	return c.SendString(modules.ExportZoneFile(&domain, records))
+}
+
+// @Summary Import Domain Zone File
+// @Description Parse a RFC 1035 zone file and create the records missing in domain
+// @Description the zone file is read from multipart field "file", or the raw request body
+// @Description user must have readwrite permission to domain or be admin
+// @Description for ICP domain the records to create of non-owner are sent to owner for approval as one domain change
+// @Tags domain
+// @Accept plain
+// @Accept mpfd
+// @Param id path string true "domain id"
+// @Param file formData file false "zone file"
+// @Produce json
+// @Success 200 {object} mw.Domain{data=modules.ZoneImportResult}
+// @Success 208 {object} mw.Domain{data=string}
+// @Failure 400 {object} mw.Domain{data=int}
+// @Failure 403 {object} mw.Domain{data=int}
+// @Failure 404 {object} mw.Domain{data=int}
+// @Failure 409 {object} mw.Domain{data=[]modules.FieldError}
+// @Failure 500 {object} mw.Domain{data=int}
+// @Router /api/v1/domain/{id}/zonefile [post]
+func DomainZoneFileImport(c *fiber.Ctx) error {
+	// get domainId restful api
+	qId := c.Params("id")
+
+	// get query user info from jwt sub
+	uId := c.Locals("sub").(uint)
+
+	// check if user role level
+	flag := c.Locals("role").(models.UserRole) >= models.Admin
+	if !(flag || checkUserDomainPermission(uId, qId, models.ReadWrite)) {
+		logrus.Info("User: ", uId, " try to access domain: ", qId, " without permission")
+		return c.Status(fiber.StatusForbidden).JSON(mw.Domain{
+			Status: fiber.StatusForbidden,
+			Errors: "permission denied",
+			Data:   qId,
+		})
+	}
+
+	// get domain info
+	var domain models.Domain
+	if err := db.DB.Where("id = ?", qId).First(&domain).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(mw.Domain{
+			Status: fiber.StatusNotFound,
+			Errors: "domain not found",
+			Data:   qId,
+		})
+	}
+
+	// read zone file
+	var zoneFile io.Reader = bytes.NewReader(c.Body())
+	if fh, err := c.FormFile("file"); err == nil {
+		f, err := fh.Open()
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(mw.Domain{
+				Status: fiber.StatusBadRequest,
+				Errors: err.Error(),
+				Data:   qId,
+			})
+		}
+		defer f.Close()
+		zoneFile = f
+	}
+
+	records, err := modules.ParseZoneFile(&domain, zoneFile)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(mw.Domain{
+			Status: fiber.StatusBadRequest,
+			Errors: err.Error(),
+			Data:   qId,
+		})
+	}
+
+	// import of non-owner into ICP domain is sent to owner for approval like a sync plan
+	if domain.ICPReg > 0 && !checkUserDomainPermission(uId, qId, models.Owner) {
+		plan, skipped, err := modules.ZoneImportPlan(&domain, records)
+		if err != nil {
+			logrus.Error(err)
+			return c.Status(fiber.StatusInternalServerError).JSON(mw.Domain{
+				Status: fiber.StatusInternalServerError,
+				Errors: err.Error(),
+				Data:   qId,
+			})
+		}
+		if plan.Empty() {
+			return c.JSON(mw.Domain{
+				Status: fiber.StatusOK,
+				Data: modules.ZoneImportResult{
+					Created: []md.Record{},
+					Skipped: skipped,
+					Failed:  []modules.ZoneImportFailed{},
+				},
+			})
+		}
+		reason := fmt.Sprintf("%d want to import zone file for domain %s", uId, domain.Name)
+		return applySyncPlan(c, &domain, uId, plan, reason)
+	}
+
+	// keep the records before change, so it can be rolled back
+	prior, err := capturePriorState(&domain, uId)
+	if err != nil {
+		logrus.Error(err)
+		return c.Status(fiber.StatusInternalServerError).JSON(mw.Domain{
+			Status: fiber.StatusInternalServerError,
+			Errors: err.Error(),
+			Data:   qId,
+		})
+	}
+	defer prior.Release()
+
+	res, err := modules.ImportZoneRecords(&domain, records)
+	if err != nil {
+		logrus.Error(err)
+		status := recordErrorStatus(err, fiber.StatusInternalServerError)
+		return c.Status(status).JSON(mw.Domain{
+			Status: status,
+			Errors: err.Error(),
+			Data:   invalidRecordData(c, err),
+		})
+	}
+	if len(res.Created) > 0 {
+		prior.Save()
+	}
+	auditChange(c, nil, res)
+
+	logrus.Info("User: ", uId, " import zone file for domain: ", qId, ", created: ", len(res.Created), ", failed: ", len(res.Failed))
+	return c.JSON(mw.Domain{
+		Status: fiber.StatusOK,
+		Data:   res,
+	})
+}
@@ -0,0 +1,163 @@
+package services
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"domain0/bot"
+	"domain0/config"
+	db "domain0/database"
+	"domain0/models"
+	"domain0/modules"
+	md "domain0/modules/dns"
+)
+
+// StartDriftDetector polls records of all domains from vendors, and notifies the records
+// changed outside domain0, e.g. in the vendor console
+func StartDriftDetector() {
+	modules.SetRecordChangeHook(trackRecordChange)
+	if config.CONFIG.Drift.Interval <= 0 {
+		logrus.Info("drift detection is disabled")
+		return
+	}
+	go func() {
+		for {
+			detectDrift()
+			time.Sleep(time.Duration(config.CONFIG.Drift.Interval) * time.Second)
+		}
+	}()
+}
+
+func detectDrift() {
+	var domains []models.Domain
+	if err := db.DB.Find(&domains).Error; err != nil {
+		logrus.Errorf("drift detection: query domains error:%v", err)
+		return
+	}
+	for i := range domains {
+		if err := detectDomainDrift(&domains[i]); err != nil {
+			logrus.Warnf("drift detection: domain %s error:%v", domains[i].Name, err)
+		}
+	}
+}
+
+func detectDomainDrift(d *models.Domain) error {
+	l := domainLock(d.ID)
+	l.Lock()
+	defer l.Unlock()
+
+	live, err := modules.DnsRecordList(d)
+	if err != nil {
+		return err
+	}
+	// compare records the same way they are read from database
+	liveJson, err := json.Marshal(modules.UserRecords(live))
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(liveJson, &live); err != nil {
+		return err
+	}
+
+	latest, err := latestSnapshot(d.ID)
+	if err != nil {
+		return err
+	}
+	if latest == nil {
+		_, err := saveSnapshot(d.ID, models.SnapshotPolled, live)
+		return err
+	}
+	old, err := snapshotRecords(latest)
+	if err != nil {
+		return err
+	}
+
+	drift := modules.ComputePlan(live, old, true)
+	if drift.Empty() {
+		return nil
+	}
+	if _, err := saveSnapshot(d.ID, models.SnapshotDrift, live); err != nil {
+		return err
+	}
+	changes := drift.Describe()
+	logrus.Warnf("drift detection: %d records of domain %s changed outside domain0", len(changes), d.Name)
+	go bot.NotifyDnsDrift(bot.DnsDriftRecord{
+		Domain:     d.Name,
+		DetectTime: time.Now(),
+		Changes:    changes,
+	})
+	return nil
+}
+
+// trackRecordChange applies the record change made through domain0 to the latest snapshot,
+// so the next poll won't report it as drift
+func trackRecordChange(d *models.Domain, op modules.RecordChangeOp, obj modules.DnsObj, change func() error) error {
+	l := domainLock(d.ID)
+	l.Lock()
+	defer l.Unlock()
+
+	// the record may be incomplete after deleted, and some vendors give it a new id after updated
+	before := obj.ToRecord()
+	if err := change(); err != nil {
+		return err
+	}
+
+	latest, err := latestSnapshot(d.ID)
+	if err != nil || latest == nil {
+		return nil
+	}
+	records, err := snapshotRecords(latest)
+	if err != nil {
+		logrus.Errorf("track record change: decode snapshot %d error:%v", latest.ID, err)
+		return nil
+	}
+
+	switch op {
+	case modules.RecordCreated:
+		records = append(records, storedRecord(obj))
+	case modules.RecordUpdated:
+		records = append(removeRecord(records, before.Id), storedRecord(obj))
+	case modules.RecordDeleted:
+		records = removeRecord(records, before.Id)
+	}
+	if _, err := saveSnapshot(d.ID, models.SnapshotManaged, modules.UserRecords(records)); err != nil {
+		logrus.Errorf("track record change: save snapshot of domain %s error:%v", d.Name, err)
+	}
+	return nil
+}
+
+// storedRecord reads the record back from the vendor, which may fill the ttl and extensions
+// left out when submitted, so they are compared the same way as polled. Fields the vendor
+// doesn't return by id are kept as submitted, e.g. the remark of aliyun is only listed
+func storedRecord(obj modules.DnsObj) md.Record {
+	r := obj.ToRecord()
+	if err := obj.Get(r.Id); err != nil {
+		logrus.Warnf("track record change: get record %s error:%v", r.Id, err)
+		return r
+	}
+	stored := obj.ToRecord()
+	if stored.Comment == "" {
+		stored.Comment = r.Comment
+	}
+	for key, val := range r.Extensions {
+		if _, ok := stored.Extensions[key]; !ok {
+			if stored.Extensions == nil {
+				stored.Extensions = md.Extensions{}
+			}
+			stored.Extensions[key] = val
+		}
+	}
+	return stored
+}
+
+func removeRecord(records []md.Record, id string) []md.Record {
+	res := records[:0]
+	for _, r := range records {
+		if r.Id != id {
+			res = append(res, r)
+		}
+	}
+	return res
+}
@@ -0,0 +1,27 @@
+package services
+
+import (
+	"github.com/sirupsen/logrus"
+
+	"domain0/config"
+	db "domain0/database"
+	md "domain0/modules/dns"
+)
+
+// StartLocalDNS gives the local vendor its store, and serves domains of vendor local with the
+// built-in authoritative server
+func StartLocalDNS() {
+	md.SetLocalStore(db.DB)
+
+	addr := config.CONFIG.LocalDNS.Listen
+	if addr == "" {
+		logrus.Info("local dns server is disabled")
+		return
+	}
+	go func() {
+		logrus.Infof("local dns server listens on %s", addr)
+		if err := md.ServeLocal(addr); err != nil {
+			logrus.Errorf("local dns server error:%v", err)
+		}
+	}()
+}
@@ -0,0 +1,22 @@
+package services
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	mw "domain0/models/web"
+	md "domain0/modules/dns"
+)
+
+// @Summary List DNS providers
+// @Description List all supported DNS vendors with their capabilities and credential schema
+// @Description the name of provider is the value of vendor field in domain
+// @Tags provider
+// @Produce json
+// @Success 200 {object} mw.Domain{data=[]md.Provider}
+// @Router /api/v1/providers [get]
+func ProviderList(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusOK).JSON(mw.Domain{
+		Status: fiber.StatusOK,
+		Data:   md.Providers(),
+	})
+}
@@ -0,0 +1,204 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+
+	"domain0/config"
+	db "domain0/database"
+	m "domain0/models"
+	wm "domain0/models/web"
+)
+
+const refreshTokenBytes = 32
+
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func refreshTokenHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// issueTokens creates a refresh token and signs an access token of the session,
+// a new session is started if session is empty
+func issueTokens(c *fiber.Ctx, user m.User, session string) (wm.UserToken, error) {
+	raw, err := randomToken(refreshTokenBytes)
+	if err != nil {
+		return wm.UserToken{}, err
+	}
+	if session == "" {
+		if session, err = randomToken(16); err != nil {
+			return wm.UserToken{}, err
+		}
+	}
+	refresh := m.RefreshToken{
+		UserId:    user.ID,
+		Session:   session,
+		TokenHash: refreshTokenHash(raw),
+		ExpiresAt: time.Now().Add(time.Duration(config.CONFIG.Jwt.RefreshExpire) * time.Second),
+		IP:        c.IP(),
+		UserAgent: c.Get(fiber.HeaderUserAgent),
+	}
+	if err := db.DB.Create(&refresh).Error; err != nil {
+		return wm.UserToken{}, err
+	}
+	// expired ones are useless even for reuse detection
+	db.DB.Unscoped().Where("user_id = ? AND expires_at < ?", user.ID, time.Now()).Delete(&m.RefreshToken{})
+
+	token, err := jwtSign(user, session)
+	if err != nil {
+		return wm.UserToken{}, err
+	}
+	return wm.UserToken{
+		Status:       fiber.StatusOK,
+		Data:         token,
+		RefreshToken: raw,
+		ExpiresIn:    config.CONFIG.Jwt.Expire,
+	}, nil
+}
+
+// sessionActive reports whether the session has a refresh token not revoked, the access
+// tokens of a session are invalid once it is logged out
+func sessionActive(uId uint, session string) bool {
+	if session == "" {
+		return false
+	}
+	var count int64
+	db.DB.Model(&m.RefreshToken{}).
+		Where("user_id = ? AND session = ? AND revoked_at IS NULL AND expires_at > ?", uId, session, time.Now()).
+		Count(&count)
+	return count > 0
+}
+
+func revokeSession(uId uint, session string) error {
+	return db.DB.Model(&m.RefreshToken{}).
+		Where("user_id = ? AND session = ? AND revoked_at IS NULL", uId, session).
+		Update("revoked_at", time.Now()).Error
+}
+
+// invalidateUserTokens revokes all sessions of the user and invalidates the jwt issued before,
+// it is called on security relevant changes, e.g. password, role or deletion of user
+func invalidateUserTokens(uId uint) error {
+	now := time.Now()
+	if err := db.DB.Model(&m.User{}).Where("id = ?", uId).Update("token_valid_after", now).Error; err != nil {
+		return err
+	}
+	return db.DB.Model(&m.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", uId).
+		Update("revoked_at", now).Error
+}
+
+// @Summary refresh
+// @description get a new access token by refresh token, the refresh token is rotated,
+// @description the old one must not be used again, or the whole session is revoked
+// @Param refresh_token formData string true "refresh token"
+// @Produce json
+// @Success 200 {object} wm.UserToken
+// @Failure 400 {object} wm.User{data=int}
+// @Failure 401 {object} wm.User{data=int}
+// @Failure 500 {object} wm.User{data=int}
+// @Router /api/v1/user/refresh [post]
+// @tags user
+func Refresh(c *fiber.Ctx) error {
+	raw := c.FormValue("refresh_token")
+	if raw == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(wm.User{
+			Status: fiber.StatusBadRequest,
+			Errors: "refresh_token is empty",
+		})
+	}
+
+	var refresh m.RefreshToken
+	if err := db.DB.Where("token_hash = ?", refreshTokenHash(raw)).First(&refresh).Error; err != nil ||
+		time.Now().After(refresh.ExpiresAt) {
+		return c.Status(fiber.StatusUnauthorized).JSON(wm.User{
+			Status: fiber.StatusUnauthorized,
+			Errors: "invalid or expired refresh token",
+		})
+	}
+	if refresh.RevokedAt != nil {
+		if refresh.Rotated {
+			logrus.Warnf("rotated refresh token %d of user %d is reused, revoke session", refresh.ID, refresh.UserId)
+			if err := revokeSession(refresh.UserId, refresh.Session); err != nil {
+				logrus.Error(err)
+			}
+		}
+		return c.Status(fiber.StatusUnauthorized).JSON(wm.User{
+			Status: fiber.StatusUnauthorized,
+			Errors: "invalid or expired refresh token",
+		})
+	}
+	var user m.User
+	if err := db.DB.Where("id = ?", refresh.UserId).First(&user).Error; err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(wm.User{
+			Status: fiber.StatusUnauthorized,
+			Errors: "invalid or expired refresh token",
+		})
+	}
+	c.Locals("sub", user.ID)
+	c.Locals(localsUserName, user.Name)
+
+	// revoke before issuing, so a concurrent refresh by the same token is taken as a reuse
+	now := time.Now()
+	result := db.DB.Model(&refresh).Where("revoked_at IS NULL").
+		Updates(map[string]interface{}{"revoked_at": now, "rotated": true})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return c.Status(fiber.StatusUnauthorized).JSON(wm.User{
+			Status: fiber.StatusUnauthorized,
+			Errors: "invalid or expired refresh token",
+		})
+	}
+	tokens, err := issueTokens(c, user, refresh.Session)
+	if err != nil {
+		logrus.Errorf("refresh token error : %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(wm.User{
+			Status: fiber.StatusInternalServerError,
+			Errors: "internal server error",
+		})
+	}
+	return c.JSON(tokens)
+}
+
+// @Summary logout
+// @description revoke the session of current access token, its access tokens and refresh tokens
+// @description are invalid at once, all sessions of current user are revoked if all is true
+// @Param all query bool false "logout all sessions"
+// @Produce json
+// @Success 200 {object} wm.User{data=int}
+// @Failure 500 {object} wm.User{data=int}
+// @Router /api/v1/user/logout [post]
+// @tags user
+func Logout(c *fiber.Ctx) error {
+	uId := c.Locals("sub").(uint)
+
+	var err error
+	if c.QueryBool("all", false) {
+		err = invalidateUserTokens(uId)
+	} else if session, ok := c.Locals(localsSession).(string); ok {
+		err = revokeSession(uId, session)
+	}
+	if err != nil {
+		logrus.Errorf("logout error : %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(wm.User{
+			Status: fiber.StatusInternalServerError,
+			Errors: "internal server error",
+			Data:   uId,
+		})
+	}
+	return c.JSON(wm.User{
+		Status: fiber.StatusOK,
+		Data:   uId,
+	})
+}
@@ -0,0 +1,324 @@
+package services
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	"domain0/config"
+	db "domain0/database"
+	"domain0/models"
+	mw "domain0/models/web"
+)
+
+const (
+	signingKeyRSABits     = 2048
+	signingKeyCheckEvery  = time.Minute
+	signingKeyPublishedBy = time.Hour // a new key is in jwks this long before it signs, longer than jwks max age
+	signingKeyReloadAfter = 10 * time.Second
+	signingKeyClockSkew   = time.Minute
+	jwksMaxAge            = 10 * 60
+)
+
+var errNoSigningKey = errors.New("no active signing key")
+
+type signingKey struct {
+	kid      string
+	alg      string
+	method   jwt.SigningMethod
+	private  crypto.Signer
+	activeAt time.Time
+}
+
+// signingKeys caches keys of database, which may be rotated by other instances
+var signingKeys struct {
+	sync.RWMutex
+	byKid    map[string]*signingKey
+	loadedAt time.Time
+}
+
+func signingMethod(alg string) (jwt.SigningMethod, error) {
+	switch alg {
+	case jwt.SigningMethodRS256.Alg():
+		return jwt.SigningMethodRS256, nil
+	case jwt.SigningMethodEdDSA.Alg():
+		return jwt.SigningMethodEdDSA, nil
+	}
+	return nil, fmt.Errorf("unsupported jwt algorithm: %s", alg)
+}
+
+// publicJwk converts the public key to jwk, kid is the RFC 7638 thumbprint if empty
+func publicJwk(pub crypto.PublicKey, alg, kid string) (mw.Jwk, error) {
+	var key mw.Jwk
+	var members interface{}
+	switch pub := pub.(type) {
+	case *rsa.PublicKey:
+		key = mw.Jwk{
+			Kty: "RSA",
+			N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{key.E, key.Kty, key.N}
+	case ed25519.PublicKey:
+		key = mw.Jwk{
+			Kty: "OKP",
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(pub),
+		}
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{key.Crv, key.Kty, key.X}
+	default:
+		return key, fmt.Errorf("unsupported public key type %T", pub)
+	}
+	key.Use, key.Alg, key.Kid = "sig", alg, kid
+	if kid == "" {
+		// required members in lexicographic order, without whitespace
+		b, err := json.Marshal(members)
+		if err != nil {
+			return key, err
+		}
+		sum := sha256.Sum256(b)
+		key.Kid = base64.RawURLEncoding.EncodeToString(sum[:])
+	}
+	return key, nil
+}
+
+func generateSigningKey(alg string, activeAt time.Time) (*models.SigningKey, error) {
+	if _, err := signingMethod(alg); err != nil {
+		return nil, err
+	}
+	var private crypto.Signer
+	var err error
+	if alg == jwt.SigningMethodEdDSA.Alg() {
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	} else {
+		private, err = rsa.GenerateKey(rand.Reader, signingKeyRSABits)
+	}
+	if err != nil {
+		return nil, err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, err
+	}
+	jwk, err := publicJwk(private.Public(), alg, "")
+	if err != nil {
+		return nil, err
+	}
+	return &models.SigningKey{
+		Kid:        jwk.Kid,
+		Algorithm:  alg,
+		PrivateKey: string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		ActiveAt:   activeAt,
+	}, nil
+}
+
+func parseSigningKey(k *models.SigningKey) (*signingKey, error) {
+	method, err := signingMethod(k.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode([]byte(k.PrivateKey))
+	if block == nil {
+		return nil, fmt.Errorf("signing key %s is not pem", k.Kid)
+	}
+	private, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := private.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("signing key %s is not a signer", k.Kid)
+	}
+	return &signingKey{kid: k.Kid, alg: k.Algorithm, method: method, private: signer, activeAt: k.ActiveAt}, nil
+}
+
+func loadSigningKeys() error {
+	var keys []models.SigningKey
+	if err := db.DB.Find(&keys).Error; err != nil {
+		return err
+	}
+	byKid := make(map[string]*signingKey, len(keys))
+	for i := range keys {
+		key, err := parseSigningKey(&keys[i])
+		if err != nil {
+			logrus.Errorf("load signing key %s error: %v", keys[i].Kid, err)
+			continue
+		}
+		byKid[key.kid] = key
+	}
+	signingKeys.Lock()
+	signingKeys.byKid, signingKeys.loadedAt = byKid, time.Now()
+	signingKeys.Unlock()
+	return nil
+}
+
+// rotateSigningKeys generates the next key to be published ahead of rotation, or an active one
+// at once if there is none of the configured algorithm, retires the keys replaced, and deletes
+// the retired ones whose tokens are expired
+func rotateSigningKeys() error {
+	cfg := config.CONFIG.Jwt
+	now := time.Now()
+	interval := time.Duration(cfg.RotateInterval) * time.Second
+
+	var newest models.SigningKey
+	err := db.DB.Order("active_at desc").First(&newest).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	var next *models.SigningKey
+	if err != nil || newest.Algorithm != cfg.Algorithm {
+		next, err = generateSigningKey(cfg.Algorithm, now)
+	} else if newest.ActiveAt.Before(now) && now.Sub(newest.ActiveAt) > interval-signingKeyPublishedBy {
+		activeAt := newest.ActiveAt.Add(interval)
+		if activeAt.Before(now) {
+			activeAt = now
+		}
+		next, err = generateSigningKey(cfg.Algorithm, activeAt)
+	}
+	if err != nil {
+		return err
+	}
+	if next != nil {
+		follows := newest.ID
+		next.Follows = &follows
+		// the unique follows lets one instance generate the key following the newest one
+		if err := db.DB.Create(next).Error; err != nil {
+			var existing int64
+			db.DB.Model(&models.SigningKey{}).Where("follows = ?", follows).Count(&existing)
+			if existing == 0 {
+				return err
+			}
+			logrus.Infof("jwt signing key following %d is generated by another instance", follows)
+		} else {
+			logrus.Infof("jwt signing key %s of %s is generated, active at %s", next.Kid, next.Algorithm, next.ActiveAt.Format(time.RFC3339))
+		}
+	}
+
+	// keys older than the latest active one sign no more
+	var active models.SigningKey
+	if err := db.DB.Where("active_at <= ?", now).Order("active_at desc").First(&active).Error; err == nil {
+		db.DB.Model(&models.SigningKey{}).
+			Where("retired_at IS NULL AND active_at < ?", active.ActiveAt).
+			Update("retired_at", now)
+	}
+	expired := now.Add(-time.Duration(cfg.Expire)*time.Second - signingKeyClockSkew)
+	db.DB.Unscoped().Where("retired_at < ?", expired).Delete(&models.SigningKey{})
+
+	return loadSigningKeys()
+}
+
+// InitSigningKeys makes sure there is an active signing key before any jwt is issued
+func InitSigningKeys() error {
+	if _, err := signingMethod(config.CONFIG.Jwt.Algorithm); err != nil {
+		return err
+	}
+	// a key must sign for a while after the next one is published, or one is generated every check
+	if interval := time.Duration(config.CONFIG.Jwt.RotateInterval) * time.Second; interval <= signingKeyPublishedBy {
+		return fmt.Errorf("jwt rotate_interval must be longer than %d seconds", int(signingKeyPublishedBy.Seconds()))
+	}
+	return rotateSigningKeys()
+}
+
+// StartKeyRotator rotates jwt signing keys by rotate_interval of config
+func StartKeyRotator() {
+	go func() {
+		for {
+			time.Sleep(signingKeyCheckEvery)
+			if err := rotateSigningKeys(); err != nil {
+				logrus.Errorf("rotate jwt signing keys error: %v", err)
+			}
+		}
+	}()
+}
+
+// activeSigningKey is the latest key active, keys published ahead are not used yet
+func activeSigningKey() (*signingKey, error) {
+	now := time.Now()
+	signingKeys.RLock()
+	defer signingKeys.RUnlock()
+	var active *signingKey
+	for _, key := range signingKeys.byKid {
+		if !key.activeAt.After(now) && (active == nil || key.activeAt.After(active.activeAt)) {
+			active = key
+		}
+	}
+	if active == nil {
+		return nil, errNoSigningKey
+	}
+	return active, nil
+}
+
+// JwtKeyFunc gets the public key of the kid to verify jwt, keys rotated by other instances are
+// loaded on demand
+func JwtKeyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	signingKeys.RLock()
+	key, ok := signingKeys.byKid[kid]
+	stale := time.Since(signingKeys.loadedAt) > signingKeyReloadAfter
+	signingKeys.RUnlock()
+	if !ok && stale {
+		if err := loadSigningKeys(); err != nil {
+			return nil, err
+		}
+		signingKeys.RLock()
+		key, ok = signingKeys.byKid[kid]
+		signingKeys.RUnlock()
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key: %s", kid)
+	}
+	if token.Method.Alg() != key.alg {
+		return nil, fmt.Errorf("unexpected signing method: %s", token.Method.Alg())
+	}
+	return key.private.Public(), nil
+}
+
+// @Summary JWKS
+// @Description Public keys to verify jwt issued by domain0, including keys to be active and retired
+// @Description ones whose tokens are not expired yet, tokens are signed by the key of kid in header
+// @Tags user
+// @Produce json
+// @Success 200 {object} mw.Jwks
+// @Router /.well-known/jwks.json [get]
+func Jwks(c *fiber.Ctx) error {
+	signingKeys.RLock()
+	keys := make([]mw.Jwk, 0, len(signingKeys.byKid))
+	for _, key := range signingKeys.byKid {
+		jwk, err := publicJwk(key.private.Public(), key.alg, key.kid)
+		if err != nil {
+			logrus.Error(err)
+			continue
+		}
+		keys = append(keys, jwk)
+	}
+	signingKeys.RUnlock()
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Kid < keys[j].Kid })
+
+	c.Set(fiber.HeaderCacheControl, fmt.Sprintf("public, max-age=%d", jwksMaxAge))
+	return c.JSON(mw.Jwks{Keys: keys})
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+
+	db "domain0/database"
+	"domain0/models"
+	"domain0/modules"
+	md "domain0/modules/dns"
+)
+
+// domainLocks serializes record changes and snapshots of a domain, so a change made through
+// domain0 is never mistaken for drift
+var domainLocks sync.Map
+
+func domainLock(domainId uint) *sync.Mutex {
+	l, _ := domainLocks.LoadOrStore(domainId, &sync.Mutex{})
+	return l.(*sync.Mutex)
+}
+
+// versionLocks serializes changes of a domain through domain0 from reading the prior state to
+// saving it, so a version always holds the records right before its change, it is taken before
+// domainLocks, which the change takes while it is tracked
+var versionLocks sync.Map
+
+func versionLock(domainId uint) *sync.Mutex {
+	l, _ := versionLocks.LoadOrStore(domainId, &sync.Mutex{})
+	return l.(*sync.Mutex)
+}
+
+// latestSnapshot returns the last snapshot of domain for drift detection, nil if there is none
+func latestSnapshot(domainId uint) (*models.DnsSnapshot, error) {
+	var snapshots []models.DnsSnapshot
+	err := db.DB.Where("domain_id = ? AND source <> ?", domainId, models.SnapshotVersion).
+		Order("id desc").Limit(1).Find(&snapshots).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(snapshots) == 0 {
+		return nil, nil
+	}
+	return &snapshots[0], nil
+}
+
+func snapshotRecords(s *models.DnsSnapshot) ([]md.Record, error) {
+	var records []md.Record
+	if err := json.Unmarshal([]byte(s.Records), &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+func saveSnapshot(domainId uint, source models.SnapshotSource, records []md.Record) (*models.DnsSnapshot, error) {
+	recordsJson, err := json.Marshal(records)
+	if err != nil {
+		return nil, err
+	}
+	s := models.DnsSnapshot{
+		DomainId: domainId,
+		Source:   source,
+		Records:  string(recordsJson),
+	}
+	if err := db.DB.Create(&s).Error; err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// priorState is the records of a domain read before a change through domain0
+type priorState struct {
+	domain   *models.Domain
+	userId   uint
+	records  []md.Record
+	released sync.Once
+}
+
+// capturePriorState reads the records of domain d before user uId changes it, other changes of
+// the domain wait until Save or Release, so defer Release right after it
+func capturePriorState(d *models.Domain, uId uint) (*priorState, error) {
+	l := versionLock(d.ID)
+	l.Lock()
+	records, err := modules.DnsRecordList(d)
+	if err != nil {
+		l.Unlock()
+		return nil, err
+	}
+	return &priorState{domain: d, userId: uId, records: modules.UserRecords(records)}, nil
+}
+
+// Release lets other changes of the domain go on without saving the prior state, it does
+// nothing after Save or Release
+func (p *priorState) Release() {
+	p.released.Do(versionLock(p.domain.ID).Unlock)
+}
+
+// Save keeps the prior state as the next version of the domain, call it after the change succeeded
+func (p *priorState) Save() {
+	defer p.Release()
+
+	var version uint
+	err := db.DB.Model(&models.DnsSnapshot{}).Where("domain_id = ? AND source = ?", p.domain.ID, models.SnapshotVersion).
+		Select("COALESCE(MAX(version), 0)").Scan(&version).Error
+	if err != nil {
+		logrus.Errorf("save snapshot version of domain %s error:%v", p.domain.Name, err)
+		return
+	}
+	recordsJson, err := json.Marshal(p.records)
+	if err != nil {
+		logrus.Errorf("save snapshot version of domain %s error:%v", p.domain.Name, err)
+		return
+	}
+	s := models.DnsSnapshot{
+		DomainId: p.domain.ID,
+		Source:   models.SnapshotVersion,
+		Version:  version + 1,
+		UserId:   p.userId,
+		Records:  string(recordsJson),
+	}
+	if err := db.DB.Create(&s).Error; err != nil {
+		logrus.Errorf("save snapshot version of domain %s error:%v", p.domain.Name, err)
+	}
+}
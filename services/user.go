@@ -25,29 +25,55 @@ var emailReg = regexp.MustCompile(`\w+([-+.]\w+)*@\w+([-.]\w+)*\.\w+([-.]\w+)*`)
 
 const (
 	localsUserName = "user_name"
+	localsSession  = "session"
 )
 
-func jwtSign(user m.User) (string, error) {
-	rawToken := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+// jwtSign signs a short-lived access token of the session, see issueTokens
+func jwtSign(user m.User, session string) (string, error) {
+	key, err := activeSigningKey()
+	if err != nil {
+		return "", err
+	}
+	rawToken := jwt.NewWithClaims(key.method, jwt.MapClaims{
 		"sub":    user.ID,
+		"sid":    session,
 		"stu_id": user.GetStuId(),
 		"name":   user.Name,
 		"email":  user.Email,
 		"role":   user.Role,
 		"iat":    time.Now().Unix(),
-		"exp":    time.Now().Add(time.Hour * 72).Unix(),
+		"exp":    time.Now().Add(time.Duration(c.CONFIG.Jwt.Expire) * time.Second).Unix(),
 	})
-	return rawToken.SignedString([]byte(c.CONFIG.JwtKey))
+	rawToken.Header["kid"] = key.kid
+	return rawToken.SignedString(key.private)
 }
 
+// JwtToLocalsWare rejects jwt of deleted user, of revoked session, or issued before the last
+// security relevant change of user, role is read from database instead of the claim
 func JwtToLocalsWare(c *fiber.Ctx) error {
-	user := c.Locals("user").(*jwt.Token)
-	if user.Valid {
-		claims := user.Claims.(jwt.MapClaims)
-		c.Locals("sub", uint(claims["sub"].(float64)))
-		c.Locals("role", m.UserRole(claims["role"].(float64)))
-		c.Locals(localsUserName, claims["name"].(string))
+	// not set if the request is authenticated by an access token
+	token, ok := c.Locals("user").(*jwt.Token)
+	if !ok || !token.Valid {
+		return c.Next()
+	}
+	claims := token.Claims.(jwt.MapClaims)
+	sub, _ := claims["sub"].(float64)
+	iat, _ := claims["iat"].(float64)
+	sid, _ := claims["sid"].(string)
+
+	var user m.User
+	if err := db.DB.Where("id = ?", uint(sub)).First(&user).Error; err != nil ||
+		(user.TokenValidAfter != nil && int64(iat) < user.TokenValidAfter.Unix()) ||
+		!sessionActive(user.ID, sid) {
+		return c.Status(fiber.StatusUnauthorized).JSON(wm.User{
+			Status: fiber.StatusUnauthorized,
+			Errors: "token is revoked",
+		})
 	}
+	c.Locals("sub", user.ID)
+	c.Locals("role", user.Role)
+	c.Locals(localsUserName, user.Name)
+	c.Locals(localsSession, sid)
 	return c.Next()
 }
 
@@ -57,7 +83,7 @@ func JwtToLocalsWare(c *fiber.Ctx) error {
 // @Param user formData string true "user email or stu_id"
 // @Param pass formData string true "user password"
 // @Produce json
-// @Success 200 {object} wm.User{data=string}
+// @Success 200 {object} wm.UserToken
 // @Failure 400 {object} wm.User{data=int}
 // @Failure 401 {object} wm.User{data=int}
 // @Failure 500 {object} wm.User{data=int}
@@ -103,7 +129,7 @@ func Login(c *fiber.Ctx) error {
 		}
 
 		// generate jwt token
-		token, err := jwtSign(userObject)
+		tokens, err := issueTokens(c, userObject, "")
 		if err != nil {
 			logrus.Errorf("%d login error : %v", randtag, err)
 			return c.Status(fiber.StatusInternalServerError).JSON(wm.User{
@@ -114,10 +140,7 @@ func Login(c *fiber.Ctx) error {
 		}
 
 		// set localstorage, not cookie
-		return c.Status(fiber.StatusOK).JSON(wm.User{
-			Status: fiber.StatusOK,
-			Data:   token,
-		})
+		return c.Status(fiber.StatusOK).JSON(tokens)
 	}
 }
 
@@ -127,7 +150,7 @@ func Login(c *fiber.Ctx) error {
 // @Param email formData string true "user email"
 // @Param pass formData string true "user password"
 // @Produce json
-// @Success 200 {object} wm.User{data=string}
+// @Success 200 {object} wm.UserToken
 // @Failure 400 {object} wm.User{data=int}
 // @Failure 500 {object} wm.User{data=int}
 // @Router /api/v1/user/register [post]
@@ -165,9 +188,10 @@ func Register(c *fiber.Ctx) error {
 			Data:   randtag,
 		})
 	}
+	auditChange(c, nil, userObject)
 
 	// generate jwt token
-	token, err := jwtSign(userObject)
+	tokens, err := issueTokens(c, userObject, "")
 	if err != nil {
 		logrus.Errorf("%d register error : %v", randtag, err)
 		return c.Status(fiber.StatusInternalServerError).JSON(wm.User{
@@ -178,10 +202,7 @@ func Register(c *fiber.Ctx) error {
 	}
 
 	// set localstorage, not cookie
-	return c.Status(fiber.StatusOK).JSON(wm.User{
-		Status: fiber.StatusOK,
-		Data:   token,
-	})
+	return c.Status(fiber.StatusOK).JSON(tokens)
 }
 
 // @Summary feishu auth enable
@@ -205,7 +226,7 @@ func FeishuAuthEnable(c *fiber.Ctx) error {
 // @description feishu auth redirect api
 // @Produce json
 // @Success 302
-// @Failure 400 {error}
+// @Failure 400
 // @Router /api/v1/user/feishu [get]
 func FeishuAuthRedirect(c *fiber.Ctx) error {
 	if config.CONFIG.Feishu.Enable {
@@ -239,7 +260,7 @@ func OIDCAuthEnable(c *fiber.Ctx) error {
 // @description OIDC auth redirect api
 // @Produce json
 // @Success 302
-// @Failure 400 {error}
+// @Failure 400
 // @Router /api/v1/user/oidc [get]
 func OIDCAuthRedirect(c *fiber.Ctx) error {
 	if config.CONFIG.OIDC.Enable {
@@ -254,7 +275,7 @@ func OIDCAuthRedirect(c *fiber.Ctx) error {
 // @Param code query string true "oauth code"
 // @Param state query string true "oauth state"
 // @Produce json
-// @Success 200 {object} wm.User{data=string}
+// @Success 200 {object} wm.UserToken
 // @Failure 400 {object} wm.User{data=int}
 // @Failure 500 {object} wm.User{data=int}
 // @Router /api/v1/user/callback [get]
@@ -340,10 +361,12 @@ func Callback(c *fiber.Ctx) error {
 				Data:   0,
 			})
 		}
+		// the callback is a GET, it's audited only with the change set
+		auditChange(c, nil, userObject)
 	}
 
 	// generate jwt token
-	token, err := jwtSign(userObject)
+	tokens, err := issueTokens(c, userObject, "")
 	if err != nil {
 		logrus.Errorf("generate jwt token error : %v", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(wm.User{
@@ -353,8 +376,5 @@ func Callback(c *fiber.Ctx) error {
 		})
 	}
 
-	return c.Status(fiber.StatusOK).JSON(wm.User{
-		Status: fiber.StatusOK,
-		Data:   token,
-	})
+	return c.Status(fiber.StatusOK).JSON(tokens)
 }
@@ -8,6 +8,7 @@ import (
 	"strconv"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
 )
 
 func checkUserDomainPermission(uId interface{}, dId interface{}, target models.UserDomainRole) bool {
@@ -18,6 +19,65 @@ func checkUserDomainPermission(uId interface{}, dId interface{}, target models.U
 	return ud.Role >= target
 }
 
+// permittedDomain checks the role of user to domain in path and loads the domain,
+// the returned error is the response on failure
+func permittedDomain(c *fiber.Ctx, role models.UserDomainRole) (*models.Domain, bool, error) {
+	// get domainId restful api
+	qId := c.Params("id")
+
+	// get query user info from jwt sub
+	uId := c.Locals("sub").(uint)
+
+	// check if user role level
+	isAdmin := c.Locals("role").(models.UserRole) >= models.Admin
+	permission := checkUserDomainPermission(uId, qId, role)
+	if !(isAdmin || permission) {
+		logrus.Info("User: ", uId, " try to access domain: ", qId, " without permission")
+		return nil, false, c.Status(fiber.StatusForbidden).JSON(mw.Domain{
+			Status: fiber.StatusForbidden,
+			Errors: "permission denied",
+			Data:   qId,
+		})
+	}
+
+	// get domain info
+	var domain models.Domain
+	if err := db.DB.Where("id = ?", qId).First(&domain).Error; err != nil {
+		return nil, false, c.Status(fiber.StatusNotFound).JSON(mw.Domain{
+			Status: fiber.StatusNotFound,
+			Errors: "domain not found",
+			Data:   qId,
+		})
+	}
+
+	// admin have no access to privacy domain
+	if !permission && isAdmin && domain.Privacy {
+		return nil, false, c.Status(fiber.StatusForbidden).JSON(mw.Domain{
+			Status: fiber.StatusForbidden,
+			Errors: "permission denied, privacy domain",
+			Data:   qId,
+		})
+	}
+	return &domain, true, nil
+}
+
+// unreviewedPermitted checks the user can change the domain without review, it is for the
+// credentials of a user that change records directly, e.g. ddns tokens
+func unreviewedPermitted(uId uint, d *models.Domain) (*models.User, bool) {
+	var user models.User
+	if err := db.DB.Where("id = ?", uId).First(&user).Error; err != nil {
+		return nil, false
+	}
+	if d.ICPReg > 0 {
+		return &user, checkUserDomainPermission(uId, d.ID, models.Owner)
+	}
+	if checkUserDomainPermission(uId, d.ID, models.ReadWrite) {
+		return &user, true
+	}
+	// admin have no access to privacy domain
+	return &user, user.Role >= models.Admin && !d.Privacy
+}
+
 // @Summary Create UserDomain Relation
 // @Description Create UserDomain Relation
 // @Description user must have manager permission to domain or be admin
@@ -70,17 +130,19 @@ func UserDomainCreate(c *fiber.Ctx) error {
 	}
 
 	// add user to domain
-	if err := db.DB.Create(&models.UserDomain{
+	ud := models.UserDomain{
 		UserId:   uint(userRole.UserId),
 		DomainId: uint(qId),
 		Role:     userRole.Role,
-	}).Error; err != nil {
+	}
+	if err := db.DB.Create(&ud).Error; err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(mw.Domain{
 			Status: fiber.StatusInternalServerError,
 			Errors: "internal server error",
 			Data:   nil,
 		})
 	}
+	auditChange(c, nil, ud)
 
 	return c.Status(fiber.StatusOK).JSON(mw.Domain{
 		Status: fiber.StatusOK,
@@ -123,7 +185,10 @@ func UserDomainDelete(c *fiber.Ctx) error {
 	}
 
 	// delete user from domain
+	userDomain := models.UserDomain{}
 	if checkUserDomainPermission(uId, qId, models.Owner) {
+		// only for audit, the relation may not exist
+		db.DB.Where("user_id = ? AND domain_id = ?", quId, qId).First(&userDomain)
 		if err := db.DB.Where("user_id = ? AND domain_id = ?", quId, qId).Delete(&models.UserDomain{}).Error; err != nil {
 			return c.Status(fiber.StatusInternalServerError).JSON(mw.Domain{
 				Status: fiber.StatusInternalServerError,
@@ -132,7 +197,6 @@ func UserDomainDelete(c *fiber.Ctx) error {
 			})
 		}
 	} else {
-		userDomain := models.UserDomain{}
 		if err := db.DB.Where("user_id = ? AND domain_id = ? AND role < ?", quId, qId, models.Owner).First(&userDomain).Error; err != nil {
 			return c.Status(fiber.StatusForbidden).JSON(mw.Domain{
 				Status: fiber.StatusForbidden,
@@ -148,6 +212,7 @@ func UserDomainDelete(c *fiber.Ctx) error {
 			})
 		}
 	}
+	auditChange(c, userDomain, nil)
 
 	return c.Status(fiber.StatusOK).JSON(mw.Domain{
 		Status: fiber.StatusOK,
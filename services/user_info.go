@@ -114,7 +114,7 @@ func UserInfoUpdate(c *fiber.Ctx) error {
 			})
 		} // only admin can update name and stuid, in the future, we may allow user to update name and stuid with check
 		if updateInfo.Role != nil {
-			logrus.Warnf("user %s try to update role of user %s", uId, qId)
+			logrus.Warnf("user %d try to update role of user %s", uId, qId)
 			return c.Status(fiber.StatusForbidden).JSON(mw.User{
 				Status: fiber.StatusForbidden,
 				Errors: "permission denied, you've been reported",
@@ -122,7 +122,7 @@ func UserInfoUpdate(c *fiber.Ctx) error {
 		} // only admin can update role
 	} else {
 		if updateInfo.Role != nil && c.Locals("role").(models.UserRole) <= *updateInfo.Role {
-			logrus.Warnf("user %s try to overstep update role of user %s", uId, qId)
+			logrus.Warnf("user %d try to overstep update role of user %s", uId, qId)
 			return c.Status(fiber.StatusForbidden).JSON(mw.User{
 				Status: fiber.StatusForbidden,
 				Errors: "permission denied, you've been reported",
@@ -130,6 +130,7 @@ func UserInfoUpdate(c *fiber.Ctx) error {
 		} // admin can't update role to the same or higher than himself
 	}
 
+	old := user
 	user.Email = utils.IfThenPtr(updateInfo.Email, user.Email)
 	user.Name = utils.IfThenPtr(updateInfo.Name, user.Name)
 	if updateInfo.StuId != nil {
@@ -159,6 +160,18 @@ func UserInfoUpdate(c *fiber.Ctx) error {
 			Data:   uId,
 		})
 	}
+	// tokens issued before security relevant changes must not be used any more
+	if user.Password != old.Password || user.Role != old.Role || user.Email != old.Email {
+		if err := invalidateUserTokens(user.ID); err != nil {
+			logrus.Errorf("invalidate tokens of user %d error: %v", user.ID, err)
+			return c.Status(fiber.StatusInternalServerError).JSON(mw.User{
+				Status: fiber.StatusInternalServerError,
+				Errors: "internal server error",
+				Data:   uId,
+			})
+		}
+	}
+	auditChange(c, old, user)
 
 	return c.Status(fiber.StatusOK).JSON(mw.User{
 		Status: fiber.StatusOK,
@@ -215,7 +228,10 @@ func UserInfoDelete(c *fiber.Ctx) error {
 		})
 	}
 
-	// delete user
+	// delete user, its sessions are revoked as well
+	if err := invalidateUserTokens(user.ID); err != nil {
+		logrus.Errorf("invalidate tokens of user %d error: %v", user.ID, err)
+	}
 	if err := db.DB.Delete(&user).Error; err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(mw.User{
 			Status: fiber.StatusInternalServerError,
@@ -223,6 +239,7 @@ func UserInfoDelete(c *fiber.Ctx) error {
 			Data:   uId,
 		})
 	}
+	auditChange(c, user, nil)
 
 	return c.Status(fiber.StatusOK).JSON(mw.User{
 		Status: fiber.StatusOK,